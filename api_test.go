@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// testAPI serves the API from a fresh in-memory store for one test.
+type testAPI struct {
+	t      *testing.T
+	stores stores
+	router *gin.Engine
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	s := memoryStores()
+
+	return &testAPI{t: t, stores: s, router: newRouter(s)}
+}
+
+// do sends a request with body encoded as JSON, or sent as is when it is a
+// string. headers are name/value pairs.
+func (api *testAPI) do(method, url string, body any, headers ...string) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatalf("encoding %s %s body: %v", method, url, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request := httptest.NewRequest(method, url, reader)
+	if reader != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	api.router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// expect sends a request and fails the test unless it is answered with
+// status.
+func (api *testAPI) expect(status int, method, url string, body any, headers ...string) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	recorder := api.do(method, url, body, headers...)
+	if recorder.Code != status {
+		api.t.Fatalf("%s %s: got status %d, want %d: %s", method, url, recorder.Code, status, recorder.Body.String())
+	}
+
+	return recorder
+}
+
+// create posts body to url and returns the id of the created record.
+func (api *testAPI) create(url string, body any) int {
+	api.t.Helper()
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	decode(api.t, api.expect(http.StatusCreated, http.MethodPost, url, body), &created)
+
+	return created.ID
+}
+
+func (api *testAPI) createArtist(name string) int {
+	api.t.Helper()
+
+	return api.create("/artists", map[string]any{"name": name})
+}
+
+func (api *testAPI) createAlbum(artistID int, name string) int {
+	api.t.Helper()
+
+	return api.create("/albums", map[string]any{"artist_id": artistID, "name": name, "release_year": 2000})
+}
+
+func (api *testAPI) createSong(albumID int, title string) int {
+	api.t.Helper()
+
+	return api.create("/songs", map[string]any{"album_id": albumID, "title": title})
+}
+
+// trackNumber returns the track number the song is at.
+func (api *testAPI) trackNumber(songID int) int {
+	api.t.Helper()
+
+	var song struct {
+		TrackNumber int `json:"track_number"`
+	}
+	decode(api.t, api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil), &song)
+
+	return song.TrackNumber
+}
+
+// expectTracks checks the track number of each song in want.
+func expectTracks(t *testing.T, api *testAPI, want map[int]int) {
+	t.Helper()
+
+	for songID, track := range want {
+		if got := api.trackNumber(songID); got != track {
+			t.Errorf("song %d: got track %d, want %d", songID, got, track)
+		}
+	}
+}
+
+// decode reads the JSON body of a response into v.
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body.String(), err)
+	}
+}
+
+// expectLocation checks the Location header of a response.
+func expectLocation(t *testing.T, recorder *httptest.ResponseRecorder, want string) {
+	t.Helper()
+
+	if location := recorder.Header().Get("Location"); location != want {
+		t.Errorf("got Location %q, want %q", location, want)
+	}
+}
+
+// pageIDs returns the ids of the records in a page of results.
+func pageIDs(t *testing.T, recorder *httptest.ResponseRecorder) []int {
+	t.Helper()
+
+	var page struct {
+		Data []struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	decode(t, recorder, &page)
+
+	ids := make([]int, 0, len(page.Data))
+	for _, record := range page.Data {
+		ids = append(ids, record.ID)
+	}
+
+	return ids
+}
+
+// path joins ids and names into a URL path, as in /artists/5/albums.
+func path(parts ...any) string {
+	var url string
+	for _, part := range parts {
+		switch part := part.(type) {
+		case int:
+			url += "/" + strconv.Itoa(part)
+		case string:
+			url += "/" + part
+		}
+	}
+
+	return url
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestArchivedRecordsAreNotFound(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("songs", songID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", songID), nil)
+
+	api.expect(http.StatusOK, http.MethodDelete, path("albums", albumID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("albums", albumID), nil)
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+	recorder := api.expect(http.StatusNotFound, http.MethodGet, path("artists", artistID), nil)
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("got Content-Type %q, want application/problem+json", contentType)
+	}
+
+	for _, url := range []string{"/artists", "/albums", "/songs"} {
+		if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, url, nil)); len(got) != 0 {
+			t.Errorf("GET %s: got ids %v, want archived records left out", url, got)
+		}
+	}
+}
+
+func TestDeleteArtistArchivesAlbumsAndSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("King Gizzard & the Lizard Wizard")
+	firstAlbumID := api.createAlbum(artistID, "Nonagon Infinity")
+	secondAlbumID := api.createAlbum(artistID, "Flying Microtonal Banana")
+	songIDs := []int{
+		api.createSong(firstAlbumID, "Robot Stop"),
+		api.createSong(firstAlbumID, "Big Fig Wasp"),
+		api.createSong(secondAlbumID, "Rattlesnake"),
+	}
+
+	otherArtistID := api.createArtist("Tame Impala")
+	otherAlbumID := api.createAlbum(otherArtistID, "Currents")
+	otherSongID := api.createSong(otherAlbumID, "Let It Happen")
+
+	var archived struct {
+		ID             int `json:"id"`
+		AlbumsArchived int `json:"albums_archived"`
+		SongsArchived  int `json:"songs_archived"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil), &archived)
+
+	if archived.ID != artistID || archived.AlbumsArchived != 2 || archived.SongsArchived != 3 {
+		t.Errorf("got %+v, want artist %d with 2 albums and 3 songs archived", archived, artistID)
+	}
+
+	for _, albumID := range []int{firstAlbumID, secondAlbumID} {
+		api.expect(http.StatusNotFound, http.MethodGet, path("albums", albumID), nil)
+	}
+	for _, songID := range songIDs {
+		api.expect(http.StatusNotFound, http.MethodGet, path("songs", songID), nil)
+	}
+
+	api.expect(http.StatusOK, http.MethodGet, path("artists", otherArtistID), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("albums", otherAlbumID), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("songs", otherSongID), nil)
+}
+
+func TestDeleteAlbumArchivesSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	firstSongID := api.createSong(albumID, "Somewhat Damaged")
+	secondSongID := api.createSong(albumID, "The Day the World Went Away")
+
+	var archived struct {
+		SongsArchived int `json:"songs_archived"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodDelete, path("albums", albumID), nil), &archived)
+
+	if archived.SongsArchived != 2 {
+		t.Errorf("got %d songs archived, want 2", archived.SongsArchived)
+	}
+
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", firstSongID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", secondSongID), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("artists", artistID), nil)
+}
+
+func TestDeleteMissingOrArchivedIsNotFound(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	api.createSong(albumID, "Somewhat Damaged")
+
+	api.expect(http.StatusNotFound, http.MethodDelete, "/artists/999", nil)
+	api.expect(http.StatusNotFound, http.MethodDelete, "/albums/999", nil)
+
+	api.expect(http.StatusOK, http.MethodDelete, path("albums", albumID), nil)
+	api.expect(http.StatusNotFound, http.MethodDelete, path("albums", albumID), nil)
+
+	var archived struct {
+		AlbumsArchived int `json:"albums_archived"`
+		SongsArchived  int `json:"songs_archived"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil), &archived)
+	if archived.AlbumsArchived != 0 || archived.SongsArchived != 0 {
+		t.Errorf("got %+v, want the already archived album and its song left out", archived)
+	}
+
+	api.expect(http.StatusNotFound, http.MethodDelete, path("artists", artistID), nil)
+}
+
+func TestArchivedListing(t *testing.T) {
+	api := newTestAPI(t)
+
+	keptID := api.createArtist("Tame Impala")
+	archivedID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(archivedID, "The Fragile")
+	keptAlbumID := api.createAlbum(keptID, "Currents")
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", archivedID), nil)
+
+	tests := []struct {
+		url  string
+		want []int
+	}{
+		{"/artists", []int{keptID}},
+		{"/artists?archived=true", []int{archivedID}},
+		{"/albums", []int{keptAlbumID}},
+		{"/albums?archived=true", []int{albumID}},
+	}
+
+	for _, test := range tests {
+		got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, test.url, nil))
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("GET %s: got ids %v, want %v", test.url, got, test.want)
+		}
+	}
+
+	api.expect(http.StatusBadRequest, http.MethodGet, "/artists?archived=maybe", nil)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type bulkResponse struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+	Results []struct {
+		Index  int    `json:"index"`
+		Status int    `json:"status"`
+		ID     int    `json:"id"`
+		Type   string `json:"type"`
+	} `json:"results"`
+}
+
+func TestBulkBestEffort(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		artistID := api.createArtist("Nine Inch Nails")
+		albumID := api.createAlbum(artistID, "The Fragile")
+		songID := api.createSong(albumID, "Somewhat Damaged")
+
+		items := []map[string]any{
+			{"album_id": albumID, "title": "The Day the World Went Away"},
+			{"album_id": albumID},
+			{"id": songID, "title": "Somewhat Damaged (Remastered)", "track_number": 1},
+			{"id": 999, "title": "Missing", "track_number": 2},
+			{"album_id": 999, "title": "Orphan"},
+		}
+
+		var response bulkResponse
+		decode(t, api.expect(http.StatusOK, http.MethodPost, "/songs/bulk?mode=best-effort", items), &response)
+
+		if response.Created != 1 || response.Updated != 1 || response.Failed != 3 {
+			t.Errorf("got %d created, %d updated and %d failed, want 1, 1 and 3", response.Created, response.Updated, response.Failed)
+		}
+
+		wantStatuses := []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusOK, http.StatusNotFound, http.StatusUnprocessableEntity}
+		for i, result := range response.Results {
+			if result.Index != i || result.Status != wantStatuses[i] {
+				t.Errorf("result %d: got index %d with status %d, want status %d", i, result.Index, result.Status, wantStatuses[i])
+			}
+		}
+
+		api.expect(http.StatusOK, http.MethodGet, path("songs", response.Results[0].ID), nil)
+
+		var song struct {
+			Title string `json:"title"`
+		}
+		decode(t, api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil), &song)
+
+		if song.Title != "Somewhat Damaged (Remastered)" {
+			t.Errorf("got title %q, want the update written", song.Title)
+		}
+	})
+}
+
+func TestBulkAtomic(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		items := []map[string]any{
+			{"name": "Nine Inch Nails"},
+			{"id": 999, "name": "Missing"},
+		}
+
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		decode(t, api.expect(http.StatusNotFound, http.MethodPost, "/artists/bulk", items), &problem)
+
+		if !strings.HasPrefix(problem.Detail, "Item 1:") {
+			t.Errorf("got detail %q, want it to name item 1", problem.Detail)
+		}
+
+		if ids := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/artists", nil)); len(ids) != 0 {
+			t.Errorf("got artists %v after a failed atomic write, want none", ids)
+		}
+
+		var response bulkResponse
+		decode(t, api.expect(http.StatusOK, http.MethodPost, "/artists/bulk", items[:1]), &response)
+
+		if response.Created != 1 || response.Failed != 0 {
+			t.Errorf("got %d created and %d failed, want 1 and 0", response.Created, response.Failed)
+		}
+
+		api.expect(http.StatusBadRequest, http.MethodPost, "/artists/bulk?mode=sometimes", items)
+	})
+}
+
+func TestBulkAtomicSongs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+
+		items := []map[string]any{
+			{"album_id": albumID, "title": "Somewhat Damaged"},
+			{"album_id": 999, "title": "Orphan"},
+		}
+
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs/bulk", items), &problem)
+
+		if !strings.HasPrefix(problem.Detail, "Item 1:") {
+			t.Errorf("got detail %q, want it to name item 1", problem.Detail)
+		}
+
+		if ids := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/songs", nil)); len(ids) != 0 {
+			t.Errorf("got songs %v after a failed atomic write, want none", ids)
+		}
+	})
+}
+
+// TestBulkAtomicCopyRefused has the database refuse a row that passes every
+// check made before the COPY, which only Postgres can be made to do.
+func TestBulkAtomicCopyRefused(t *testing.T) {
+	dbPool := migratedPool(t)
+
+	if _, err := dbPool.Exec(context.Background(), `ALTER TABLE artist ADD CONSTRAINT artist_refused_check CHECK (name <> 'Refused')`); err != nil {
+		t.Fatal(err)
+	}
+
+	s := postgresStores(dbPool)
+	api := &testAPI{t: t, stores: s, router: newRouter(s)}
+
+	items := []map[string]any{
+		{"name": "Nine Inch Nails"},
+		{"name": "Refused"},
+	}
+
+	var problem struct {
+		Detail string `json:"detail"`
+	}
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/artists/bulk", items), &problem)
+
+	if !strings.HasPrefix(problem.Detail, "Item 1:") {
+		t.Errorf("got detail %q, want it to name item 1", problem.Detail)
+	}
+
+	if ids := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/artists", nil)); len(ids) != 0 {
+		t.Errorf("got artists %v after a failed atomic write, want none", ids)
+	}
+}
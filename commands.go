@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/migrate"
+	"github.com/liamcoleman/music-go/internal/purge"
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func connect(ctx context.Context) *pgxpool.Pool {
+	dbPool, err := pgxpool.New(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatal("Unable to create connection pool:", err)
+	}
+
+	return dbPool
+}
+
+func runCommand(name string, args []string) {
+	switch name {
+	case "migrate":
+		migrateCommand(args)
+	case "purge":
+		purgeCommand(args)
+	default:
+		log.Fatalf("Unknown command %q", name)
+	}
+}
+
+func migrateUp(ctx context.Context, dbPool *pgxpool.Pool) {
+	migrator, err := migrate.NewMigrator(dbPool)
+	if err != nil {
+		log.Fatal("Unable to load migrations:", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		log.Fatal("Unable to apply migrations:", err)
+	}
+
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+}
+
+// migrateCommand handles `migrate up`, `migrate down [steps]` and
+// `migrate status`.
+func migrateCommand(args []string) {
+	ctx := context.Background()
+
+	dbPool := connect(ctx)
+	defer dbPool.Close()
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		migrateUp(ctx, dbPool)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("Invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		migrator, err := migrate.NewMigrator(dbPool)
+		if err != nil {
+			log.Fatal("Unable to load migrations:", err)
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatal("Unable to revert migrations:", err)
+		}
+
+		for _, migration := range reverted {
+			log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+		}
+
+	case "status":
+		migrator, err := migrate.NewMigrator(dbPool)
+		if err != nil {
+			log.Fatal("Unable to load migrations:", err)
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal("Unable to read migration status:", err)
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+	default:
+		log.Fatalf("Unknown migrate action %q, expected up, down or status", action)
+	}
+}
+
+// purgeCommand handles `purge [-retention 720h] [-dry-run]`.
+func purgeCommand(args []string) {
+	defaultRetention, err := purge.RetentionFromEnv()
+	if err != nil {
+		log.Fatal("Invalid PURGE_RETENTION:", err)
+	}
+
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	retention := flags.Duration("retention", defaultRetention, "purge records archived for longer than this")
+	dryRun := flags.Bool("dry-run", false, "report what would be purged without deleting anything")
+	flags.Parse(args)
+
+	ctx := context.Background()
+
+	dbPool := connect(ctx)
+	defer dbPool.Close()
+
+	result, err := purge.Run(ctx, repository.NewPurgeRepository(dbPool), *retention, *dryRun)
+	if err != nil {
+		log.Fatal("Unable to purge archived records:", err)
+	}
+
+	verb := "Purged"
+	if result.DryRun {
+		verb = "Would purge"
+	}
+
+	fmt.Printf("%s records archived before %s\n", verb, result.ArchivedBefore.Format(time.RFC3339))
+	fmt.Printf("  artists: %d %v\n", len(result.ArtistIDs), result.ArtistIDs)
+	fmt.Printf("  albums:  %d %v\n", len(result.AlbumIDs), result.AlbumIDs)
+	fmt.Printf("  songs:   %d %v\n", len(result.SongIDs), result.SongIDs)
+	fmt.Printf("  people:  %d %v\n", len(result.PersonIDs), result.PersonIDs)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func (api *testAPI) createCompilation(name string, artistIDs ...int) (int, []int) {
+	api.t.Helper()
+
+	albumID := api.create("/albums", map[string]any{"compilation": true, "name": name, "release_year": 1997})
+
+	songIDs := []int{}
+	for _, artistID := range artistIDs {
+		songIDs = append(songIDs, api.create("/songs", map[string]any{"album_id": albumID, "artist_id": artistID, "title": "Track"}))
+	}
+
+	return albumID, songIDs
+}
+
+func TestCompilation(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	bowie := api.createArtist("David Bowie")
+
+	albumID, songIDs := api.createCompilation("Lost Highway", nin, bowie)
+	api.createAlbum(nin, "The Fragile")
+
+	if got := api.credits(path("albums", albumID)).ArtistCredit; got != "Various Artists" {
+		t.Errorf("got album credit %q, want Various Artists", got)
+	}
+	if got := api.credits(path("songs", songIDs[1])).ArtistCredit; got != "David Bowie" {
+		t.Errorf("got song credit %q, want David Bowie", got)
+	}
+
+	for _, url := range []string{"/various-artists/albums", "/albums?compilation=true"} {
+		if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, url, nil)); !slices.Equal(got, []int{albumID}) {
+			t.Errorf("GET %s: got ids %v, want %v", url, got, []int{albumID})
+		}
+	}
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{"album_id": albumID, "title": "No Artist"})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/albums", map[string]any{"compilation": true, "artist_id": nin, "name": "Both", "release_year": 1997})
+}
+
+func TestDeleteArtistArchivesCompilationSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	bowie := api.createArtist("David Bowie")
+
+	albumID, songIDs := api.createCompilation("Lost Highway", bowie, nin, bowie, nin)
+
+	var archived struct {
+		SongsArchived      int `json:"songs_archived"`
+		OtherSongsArchived int `json:"other_songs_archived"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodDelete, path("artists", nin), nil), &archived)
+
+	if archived.SongsArchived != 0 || archived.OtherSongsArchived != 2 {
+		t.Errorf("got %+v, want 2 other songs archived", archived)
+	}
+
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", songIDs[1]), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", songIDs[3]), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("albums", albumID), nil)
+	expectTracks(t, api, map[int]int{songIDs[0]: 1, songIDs[2]: 2})
+
+	var restored struct {
+		OtherSongsRestored int `json:"other_songs_restored"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPost, path("artists", nin, "restore")+"?cascade=true", nil), &restored)
+
+	if restored.OtherSongsRestored != 2 {
+		t.Errorf("got %d other songs restored, want 2", restored.OtherSongsRestored)
+	}
+	expectTracks(t, api, map[int]int{songIDs[0]: 1, songIDs[2]: 2, songIDs[3]: 4, songIDs[1]: 5})
+}
+
+func TestPurgeArtistKeepsTheirLiveSongsOnOtherAlbums(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		nin := api.createArtist("Nine Inch Nails")
+		bowie := api.createArtist("David Bowie")
+
+		albumID, songIDs := api.createCompilation("Lost Highway", bowie, nin)
+		guestID := api.create("/songs", map[string]any{"album_id": api.createAlbum(bowie, "Outside"), "artist_id": nin, "title": "I'm Afraid of Americans"})
+
+		api.expect(http.StatusOK, http.MethodDelete, path("artists", nin), nil)
+		api.expect(http.StatusOK, http.MethodPost, path("songs", guestID, "restore"), nil)
+
+		result, err := api.stores.purge.Purge(context.Background(), time.Now().Add(time.Minute), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The compilation song was archived with the artist and goes on its
+		// own account, the restored one stays.
+		if !slices.Equal(result.ArtistIDs, []int{nin}) || len(result.AlbumIDs) != 0 || !slices.Equal(result.SongIDs, []int{songIDs[1]}) {
+			t.Errorf("got %+v, want artist %d and song %d purged", result, nin, songIDs[1])
+		}
+
+		api.expect(http.StatusOK, http.MethodGet, path("albums", albumID), nil)
+		api.expect(http.StatusOK, http.MethodGet, path("songs", songIDs[0]), nil)
+		if got := api.credits(path("songs", guestID)).ArtistCredit; got != "David Bowie" {
+			t.Errorf("got song credit %q, want it back on its album's artist", got)
+		}
+	})
+}
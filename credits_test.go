@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type creditedRecord struct {
+	ID      int `json:"id"`
+	Artists []struct {
+		ArtistID int    `json:"artist_id"`
+		Role     string `json:"role"`
+	} `json:"artists"`
+	ArtistCredit string `json:"artist_credit"`
+}
+
+func (record creditedRecord) artistIDs() []int {
+	ids := []int{}
+	for _, artist := range record.Artists {
+		ids = append(ids, artist.ArtistID)
+	}
+
+	return ids
+}
+
+func TestArtistCredit(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	bowie := api.createArtist("David Bowie")
+	ogilvie := api.createArtist("Dave Ogilvie")
+
+	albumID := api.create("/albums", map[string]any{
+		"artist_id":    nin,
+		"name":         "I'm Afraid of Americans",
+		"release_year": 1997,
+		"artists":      []map[string]any{{"artist_id": bowie, "role": "primary"}},
+	})
+	songID := api.create("/songs", map[string]any{
+		"album_id": albumID,
+		"title":    "I'm Afraid of Americans (V1)",
+		"artists":  []map[string]any{{"artist_id": ogilvie, "role": "remixer"}},
+	})
+
+	var album, song creditedRecord
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("albums", albumID), nil), &album)
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil), &song)
+
+	if want := "Nine Inch Nails & David Bowie"; album.ArtistCredit != want {
+		t.Errorf("got album credit %q, want %q", album.ArtistCredit, want)
+	}
+	if want := "Nine Inch Nails & David Bowie remixed by Dave Ogilvie"; song.ArtistCredit != want {
+		t.Errorf("got song credit %q, want %q", song.ArtistCredit, want)
+	}
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{
+		"album_id": albumID,
+		"title":    "Duplicate",
+		"artists":  []map[string]any{{"artist_id": ogilvie, "role": "remixer"}, {"artist_id": ogilvie, "role": "featured"}},
+	})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{
+		"album_id": albumID,
+		"title":    "Missing",
+		"artists":  []map[string]any{{"artist_id": 999, "role": "featured"}},
+	})
+}
+
+func TestArtistAppearances(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	bowie := api.createArtist("David Bowie")
+
+	ownAlbumID := api.createAlbum(bowie, "Outside")
+	albumID := api.createAlbum(nin, "The Fragile")
+	songID := api.create("/songs", map[string]any{
+		"album_id": albumID,
+		"title":    "Hurt (Live)",
+		"artists":  []map[string]any{{"artist_id": bowie, "role": "featured"}},
+	})
+
+	var artist struct {
+		Albums []struct {
+			ID int `json:"id"`
+		} `json:"Albums"`
+		Appearances []struct {
+			ID    int `json:"id"`
+			Songs []struct {
+				ID int `json:"id"`
+			} `json:"songs"`
+		} `json:"appearances"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("artists", bowie), nil), &artist)
+
+	if len(artist.Albums) != 1 || artist.Albums[0].ID != ownAlbumID {
+		t.Errorf("got albums %+v, want only album %d", artist.Albums, ownAlbumID)
+	}
+	if len(artist.Appearances) != 1 || artist.Appearances[0].ID != albumID {
+		t.Fatalf("got appearances %+v, want album %d", artist.Appearances, albumID)
+	}
+	if songs := artist.Appearances[0].Songs; len(songs) != 1 || songs[0].ID != songID {
+		t.Errorf("got songs %+v, want song %d", songs, songID)
+	}
+
+	var song creditedRecord
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil), &song)
+
+	if got := song.artistIDs(); !slices.Equal(got, []int{nin, bowie}) {
+		t.Errorf("got credited artists %v, want %v", got, []int{nin, bowie})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestDiscs(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+
+	song := func(disc int, discTitle, title string) int {
+		return api.create("/songs", map[string]any{"album_id": albumID, "title": title, "disc_number": disc, "disc_title": discTitle})
+	}
+
+	right := song(2, "Right", "The Way Out Is Through")
+	left := song(1, "Left", "Somewhat Damaged")
+	rightAgain := song(2, "", "Into the Void")
+	leftAgain := api.createSong(albumID, "The Day the World Went Away")
+
+	expectTracks(t, api, map[int]int{left: 1, leftAgain: 2, right: 1, rightAgain: 2})
+
+	if got, want := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("albums", albumID, "songs"), nil)), []int{left, leftAgain, right, rightAgain}; !slices.Equal(got, want) {
+		t.Errorf("got songs %v, want %v in disc and track order", got, want)
+	}
+
+	expectDiscs(t, api, albumID, []disc{{"Left", []int{left, leftAgain}}, {"Right", []int{right, rightAgain}}})
+
+	api.expect(http.StatusConflict, http.MethodPatch, path("songs", leftAgain), map[string]any{"disc_number": 2})
+	api.expect(http.StatusCreated, http.MethodPatch, path("songs", leftAgain), map[string]any{"disc_number": 2, "track_number": 3})
+	expectTracks(t, api, map[int]int{leftAgain: 3})
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("songs", right), nil)
+	expectTracks(t, api, map[int]int{left: 1, rightAgain: 1, leftAgain: 2})
+	// The title of disc 2 went with the only song that gave it one.
+	expectDiscs(t, api, albumID, []disc{{"Left", []int{left}}, {"", []int{rightAgain, leftAgain}}})
+}
+
+// disc is the title and songs, in track order, expected of an album's disc.
+type disc struct {
+	title string
+	songs []int
+}
+
+// expectDiscs checks the album lists want as its discs, numbered from 1.
+func expectDiscs(t *testing.T, api *testAPI, albumID int, want []disc) {
+	t.Helper()
+
+	var album struct {
+		Discs []struct {
+			DiscNumber int    `json:"disc_number"`
+			Title      string `json:"title"`
+			Songs      []struct {
+				ID int `json:"id"`
+			} `json:"songs"`
+		} `json:"discs"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("albums", albumID), nil), &album)
+
+	if len(album.Discs) != len(want) {
+		t.Fatalf("got %+v, want %d discs", album, len(want))
+	}
+	for i, want := range want {
+		got := album.Discs[i]
+
+		ids := []int{}
+		for _, song := range got.Songs {
+			ids = append(ids, song.ID)
+		}
+
+		if got.DiscNumber != i+1 || got.Title != want.title || !slices.Equal(ids, want.songs) {
+			t.Errorf("got disc %+v, want disc %d %q with songs %v", got, i+1, want.title, want.songs)
+		}
+	}
+}
+
+func TestReorderKeepsSongsOnTheirDisc(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+	first := api.createSong(albumID, "Somewhat Damaged")
+	second := api.createSong(albumID, "The Day the World Went Away")
+	other := api.create("/songs", map[string]any{"album_id": albumID, "title": "The Way Out Is Through", "disc_number": 2})
+
+	api.expect(http.StatusOK, http.MethodPost, path("albums", albumID, "songs", "reorder"), map[string]any{"song_ids": []int{other, second, first}})
+
+	expectTracks(t, api, map[int]int{second: 1, first: 2, other: 1})
+	expectDiscs(t, api, albumID, []disc{{"", []int{second, first}}, {"", []int{other}}})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestFilterAndSort(t *testing.T) {
+	api := newTestAPI(t)
+
+	kraftwerk := api.createArtist("Kraftwerk")
+	nin := api.createArtist("Nine Inch Nails")
+
+	autobahn := api.create("/albums", map[string]any{"artist_id": kraftwerk, "name": "Autobahn", "release_year": 1974})
+	tour := api.create("/albums", map[string]any{"artist_id": kraftwerk, "name": "Tour de France Soundtracks", "release_year": 2003})
+	fragile := api.create("/albums", map[string]any{"artist_id": nin, "name": "The Fragile", "release_year": 1999})
+
+	song := func(albumID int, title string, duration int) int {
+		return api.create("/songs", map[string]any{"album_id": albumID, "title": title, "duration_seconds": duration})
+	}
+
+	long := song(autobahn, "Autobahn", 1362)
+	song(autobahn, "Kometenmelodie 1", 380)
+	prologue := song(tour, "Prologue", 33)
+	etape := song(tour, "Tour de France Étape 1", 270)
+	chrono := song(tour, "Chrono", 380)
+	longer := song(tour, "Vitamin", 405)
+	song(fragile, "The Frail", 114)
+
+	tests := []struct {
+		url  string
+		want []int
+	}{
+		{"/songs?duration_seconds[gt]=300&release_year[gte]=1999&artist=Kraftwerk&sort=-duration_seconds", []int{longer, chrono}},
+		{"/songs?album_id=" + strconv.Itoa(tour) + "&sort=duration_seconds", []int{prologue, etape, chrono, longer}},
+		{"/songs?title[contains]=auto", []int{long}},
+		{"/songs?id[in]=" + strconv.Itoa(etape) + "," + strconv.Itoa(prologue) + "&sort=-id", []int{etape, prologue}},
+		{"/albums?release_year[lt]=2000&sort=-release_year", []int{fragile, autobahn}},
+		{"/albums?artist_id[ne]=" + strconv.Itoa(kraftwerk), []int{fragile}},
+		{"/artists?sort=-name", []int{nin, kraftwerk}},
+	}
+
+	for _, test := range tests {
+		if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, test.url, nil)); !slices.Equal(got, test.want) {
+			t.Errorf("GET %s: got ids %v, want %v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestFilterRejectsUnknownFields(t *testing.T) {
+	api := newTestAPI(t)
+
+	for _, target := range []string{
+		"/songs?sort=-colour",
+		"/songs?colour=red",
+		"/songs?duration_seconds[contains]=3",
+		"/albums?release_year[gt]=soon",
+		"/artists?sort=description",
+	} {
+		api.expect(http.StatusBadRequest, http.MethodGet, target, nil)
+	}
+}
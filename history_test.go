@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type revision struct {
+	ID     int            `json:"id"`
+	Action string         `json:"action"`
+	Actor  string         `json:"actor"`
+	Before map[string]any `json:"before"`
+	After  map[string]any `json:"after"`
+}
+
+func (api *testAPI) history(url string) []revision {
+	api.t.Helper()
+
+	var revisions []revision
+	decode(api.t, api.expect(http.StatusOK, http.MethodGet, url+"/history", nil), &revisions)
+
+	return revisions
+}
+
+func TestHistory(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	url := path("artists", artistID)
+
+	api.expect(http.StatusOK, http.MethodPatch, url, map[string]any{"description": "Industrial rock"}, "X-Actor", "liam")
+	api.expect(http.StatusOK, http.MethodDelete, url, nil)
+	api.expect(http.StatusOK, http.MethodPost, url+"/restore", nil)
+
+	revisions := api.history(url)
+
+	actors := []string{}
+	for _, revision := range revisions {
+		actors = append(actors, revision.Actor)
+	}
+	if want := []string{"anonymous", "anonymous", "liam", "anonymous"}; !slices.Equal(actors, want) {
+		t.Fatalf("got actors %v, want %v, newest first", actors, want)
+	}
+
+	if edit := revisions[2]; edit.Before["description"] != "" || edit.After["description"] != "Industrial rock" || edit.After["name"] != nil {
+		t.Errorf("got edit %+v, want only the description changed", edit)
+	}
+
+	api.expect(http.StatusNotFound, http.MethodGet, "/artists/999/history", nil)
+}
+
+func TestRevert(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	url := path("artists", artistID)
+
+	api.expect(http.StatusOK, http.MethodPut, url, map[string]any{"name": "NIN", "description": "Industrial rock"})
+
+	created := api.history(url)[1]
+
+	var reverted revision
+	decode(t, api.expect(http.StatusOK, http.MethodPost, path("artists", artistID, "history", created.ID, "revert"), nil, "X-Actor", "liam"), &reverted)
+
+	if reverted.Actor != "liam" || reverted.After["name"] != "Nine Inch Nails" {
+		t.Errorf("got revert %+v, want the name put back by liam", reverted)
+	}
+
+	var artist struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, url, nil), &artist)
+	if artist.Name != "Nine Inch Nails" || artist.Description != "" {
+		t.Errorf("got %+v after the revert, want the artist as created", artist)
+	}
+
+	api.expect(http.StatusNoContent, http.MethodPost, path("artists", artistID, "history", created.ID, "revert"), nil)
+	api.expect(http.StatusNotFound, http.MethodPost, path("artists", artistID, "history", 999, "revert"), nil)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorHeader names the request header identifying who is making a change.
+const ActorHeader = "X-Actor"
+
+// Actor records the X-Actor header of each request as the actor of the
+// changes it makes, for the change history.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := strings.TrimSpace(c.GetHeader(ActorHeader)); actor != "" {
+			c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), actor))
+		}
+
+		c.Next()
+	}
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"errors"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -15,25 +14,67 @@ import (
 )
 
 type AlbumHandler struct {
-	albumRepo *repository.AlbumRepository
+	albumRepo repository.AlbumStore
 }
 
-func NewAlbumHandler(albumRepo *repository.AlbumRepository) *AlbumHandler {
+func NewAlbumHandler(albumRepo repository.AlbumStore) *AlbumHandler {
 	return &AlbumHandler{
 		albumRepo: albumRepo,
 	}
 }
 
 func (h *AlbumHandler) GetAll(c *gin.Context) {
-	albums, err := h.albumRepo.GetAlbums(c.Request.Context())
+	params, err := parseListParams(c, model.AlbumFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	albums, err := h.albumRepo.GetAlbums(c.Request.Context(), params)
 
 	if err != nil {
-		log.Printf("Error fetching artists: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		abortError(c, err, "Album")
 		return
 	}
 
-	c.JSON(http.StatusOK, albums)
+	writePage(c, albums)
+}
+
+// GetArtistAlbums lists the albums of the artist in the path.
+func (h *AlbumHandler) GetArtistAlbums(c *gin.Context) {
+	params, err := parseListParams(c, model.AlbumFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	albums, err := h.albumRepo.GetArtistAlbums(c.Request.Context(), c.Param("id"), params)
+
+	if err != nil {
+		abortError(c, err, "Artist")
+		return
+	}
+
+	writePage(c, albums)
+}
+
+// GetCompilations lists the albums filed under Various Artists: the
+// compilations, which have no artist of their own.
+func (h *AlbumHandler) GetCompilations(c *gin.Context) {
+	params, err := parseListParams(c, model.AlbumFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	albums, err := h.albumRepo.GetCompilations(c.Request.Context(), params)
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	writePage(c, albums)
 }
 
 func (h *AlbumHandler) GetAlbum(c *gin.Context) {
@@ -42,112 +83,293 @@ func (h *AlbumHandler) GetAlbum(c *gin.Context) {
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
+			h.redirectMergedAlbum(c, id)
 			return
 		}
 
-		log.Printf("Error fetching album %s: %v", id, err)
+		abortError(c, err, "Album")
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	if notModified(c, album.Version) {
 		return
 	}
 
 	c.JSON(http.StatusOK, album)
 }
 
+// CreateAlbum creates an album and, when the body lists songs, its songs
+// along with it.
 func (h *AlbumHandler) CreateAlbum(c *gin.Context) {
-	var newAlbum model.CreateAlbum
+	var newAlbum model.AlbumTree
 
-	err := c.BindJSON(&newAlbum)
-	if err != nil {
+	if !bindJSON(c, &newAlbum) {
+		return
+	}
+
+	h.createAlbum(c, newAlbum, invalidFieldsProblem([]model.FieldError{{Field: "artist_id", Message: "must be an artist that exists and isn't archived"}}))
+}
+
+// CreateArtistAlbum creates an album for the artist in the path. The body
+// needn't repeat the artist_id.
+func (h *AlbumHandler) CreateArtistAlbum(c *gin.Context) {
+	artistID, ok := parentID(c, "artist")
+	if !ok {
+		return
+	}
+
+	newAlbum := model.AlbumTree{CreateAlbum: model.CreateAlbum{ArtistID: artistID}}
+
+	if !bindJSON(c, &newAlbum) {
+		return
+	}
+
+	if newAlbum.ArtistID != artistID {
+		rejectInvalid(c, []model.FieldError{{Field: "artist_id", Message: "must match the artist in the path"}})
+		return
+	}
+
+	h.createAlbum(c, newAlbum, parentNotFound("Artist"))
+}
+
+// createAlbum creates the album, answering parentMissing when its artist
+// doesn't exist or is archived.
+func (h *AlbumHandler) createAlbum(c *gin.Context, newAlbum model.AlbumTree, parentMissing error) {
+	if newAlbum.Songs != nil {
+		h.createAlbumTree(c, newAlbum, parentMissing)
 		return
 	}
 
-	albumCreated, err := h.albumRepo.CreateAlbum(c.Request.Context(), newAlbum)
+	albumCreated, err := h.albumRepo.CreateAlbum(c.Request.Context(), newAlbum.CreateAlbum)
 
 	if err != nil {
-		log.Printf("Error creating album %v", err)
+		if errors.Is(err, repository.ErrParentNotFound) {
+			err = parentMissing
+		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Album")
 		return
 	}
 
 	newUrl := "Location: /albums/" + strconv.Itoa(albumCreated.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(albumCreated.Version))
+	c.JSON(http.StatusCreated, albumCreated)
+}
+
+// createAlbumTree creates the album with its songs and answers with both.
+func (h *AlbumHandler) createAlbumTree(c *gin.Context, newAlbum model.AlbumTree, parentMissing error) {
+	albumCreated, err := h.albumRepo.CreateAlbumTree(c.Request.Context(), newAlbum)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrParentNotFound) {
+			err = parentMissing
+		}
+
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.Header("Location", "/albums/"+strconv.Itoa(albumCreated.ID))
+	c.Header("ETag", etag(albumCreated.Version))
 	c.JSON(http.StatusCreated, albumCreated)
 }
 
+// BulkAlbums creates and replaces albums in one request.
+func (h *AlbumHandler) BulkAlbums(c *gin.Context) {
+	writeBulk(c, "Album", invalidFieldsProblem([]model.FieldError{{Field: "artist_id", Message: "must be an artist that exists and isn't archived"}}), h.albumRepo.BulkAlbums)
+}
+
 func (h *AlbumHandler) UpdateAlbum(c *gin.Context) {
 	id := c.Param("id")
 	var newAlbum model.UpdateAlbum
 
-	err := c.BindJSON(&newAlbum)
-	if err != nil {
+	if !bindJSON(c, &newAlbum) {
 		return
 	}
 
-	updatedAlbum, err := h.albumRepo.UpdateAlbum(c.Request.Context(), newAlbum, id)
+	updatedAlbum, err := h.albumRepo.UpdateAlbum(c.Request.Context(), newAlbum, id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
-			return
-		}
-
-		log.Printf("Error updating album %v", err)
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Album")
 		return
 	}
 
 	newUrl := "Location: /artists/" + strconv.Itoa(updatedAlbum.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(updatedAlbum.Version))
 	c.JSON(http.StatusOK, updatedAlbum)
 }
 
 func (h *AlbumHandler) PatchAlbum(c *gin.Context) {
+	if isDocumentPatch(c) {
+		h.changeAlbum(c)
+		return
+	}
+
 	id := c.Param("id")
 	var newAlbum model.PatchAlbum
 
-	err := c.BindJSON(&newAlbum)
-	if err != nil {
+	if !bindJSON(c, &newAlbum) {
 		return
 	}
 
-	patchedAlbum, err := h.albumRepo.PatchAlbum(c.Request.Context(), newAlbum, id)
+	patchedAlbum, err := h.albumRepo.PatchAlbum(c.Request.Context(), newAlbum, id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
-			return
-		}
-
-		log.Printf("Error patching album %v", err)
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Album")
 		return
 	}
 
 	newUrl := "Location: /artists/" + strconv.Itoa(patchedAlbum.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(patchedAlbum.Version))
 	c.JSON(http.StatusOK, patchedAlbum)
 }
 
+// changeAlbum applies a merge patch or JSON Patch to the album document.
+func (h *AlbumHandler) changeAlbum(c *gin.Context) {
+	id := c.Param("id")
+
+	patch, ok := readDocumentPatch(c)
+	if !ok {
+		return
+	}
+
+	changedAlbum, err := h.albumRepo.ChangeAlbum(c.Request.Context(), id, parseIfMatch(c), albumChange(patch))
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.Header("Location", "/albums/"+strconv.Itoa(changedAlbum.ID))
+	c.Header("ETag", etag(changedAlbum.Version))
+	c.JSON(http.StatusOK, changedAlbum)
+}
+
+// ReplaceSongs replaces the tracklist of the album with the one in the body,
+// in one transaction.
+func (h *AlbumHandler) ReplaceSongs(c *gin.Context) {
+	id := c.Param("id")
+	var tracklist []model.TracklistSong
+
+	if !bindJSON(c, &tracklist) {
+		return
+	}
+
+	changedAlbum, err := h.albumRepo.ChangeAlbum(c.Request.Context(), id, parseIfMatch(c), tracklistChange(tracklist))
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.JSON(http.StatusOK, changedAlbum.Songs)
+}
+
+// ReorderSongs numbers the songs on each disc of the album from 1 in the
+// order of the ids in the body, which must list each of them once.
+func (h *AlbumHandler) ReorderSongs(c *gin.Context) {
+	id := c.Param("id")
+	var order model.ReorderSongs
+
+	if !bindJSON(c, &order) {
+		return
+	}
+
+	changedAlbum, err := h.albumRepo.ChangeAlbum(c.Request.Context(), id, parseIfMatch(c), reorderChange(order.SongIDs))
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.JSON(http.StatusOK, changedAlbum.Songs)
+}
+
 func (h *AlbumHandler) DeleteAlbum(c *gin.Context) {
 	id := c.Param("id")
-	err := h.albumRepo.DeleteAlbum(c.Request.Context(), id)
+	archivedAlbum, err := h.albumRepo.DeleteAlbum(c.Request.Context(), id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
-			return
-		}
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.JSON(http.StatusOK, archivedAlbum)
+}
+
+func (h *AlbumHandler) RestoreAlbum(c *gin.Context) {
+	id := c.Param("id")
 
-		log.Printf("Error deleting album %s: %v", id, err)
+	cascade, err := parseBoolQuery(c, "cascade")
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "Invalid value for cascade")
+		return
+	}
+
+	restoredAlbum, err := h.albumRepo.RestoreAlbum(c.Request.Context(), id, cascade)
+
+	if err != nil {
+		abortError(c, err, "Archived album")
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredAlbum)
+}
+
+func (h *AlbumHandler) MatchAlbums(c *gin.Context) {
+	artistID, err := strconv.Atoi(c.Query("artist_id"))
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "artist_id is required")
+		return
+	}
+
+	params, err := parseMatchParams(c)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	albums, err := h.albumRepo.MatchAlbums(c.Request.Context(), artistID, params)
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.JSON(http.StatusOK, albums)
+}
+
+// redirectMergedAlbum answers a lookup of a missing album with a redirect to
+// the album it was merged into, if any.
+func (h *AlbumHandler) redirectMergedAlbum(c *gin.Context, id string) {
+	targetID, err := h.albumRepo.AlbumRedirect(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	c.Redirect(http.StatusMovedPermanently, "/albums/"+strconv.Itoa(targetID))
+}
+
+func (h *AlbumHandler) MergeAlbum(c *gin.Context) {
+	id := c.Param("id")
+	var merge model.MergeAlbum
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	if !bindJSON(c, &merge) {
+		return
+	}
+
+	mergedAlbum, err := h.albumRepo.MergeAlbum(c.Request.Context(), id, merge.TargetID)
+
+	if err != nil {
+		abortError(c, err, "Album")
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.Header("Location", "/albums/"+strconv.Itoa(mergedAlbum.TargetID))
+	c.JSON(http.StatusOK, mergedAlbum)
 }
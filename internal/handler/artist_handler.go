@@ -2,7 +2,6 @@ package handler
 
 import (
 	"errors"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -15,25 +14,51 @@ import (
 )
 
 type ArtistHandler struct {
-	artistRepo *repository.ArtistRepository
+	artistRepo repository.ArtistStore
 }
 
-func NewArtistHandler(artistRepo *repository.ArtistRepository) *ArtistHandler {
+func NewArtistHandler(artistRepo repository.ArtistStore) *ArtistHandler {
 	return &ArtistHandler{
 		artistRepo: artistRepo,
 	}
 }
 
 func (h *ArtistHandler) GetAll(c *gin.Context) {
-	artists, err := h.artistRepo.GetArtists(c.Request.Context())
+	params, err := parseListParams(c, model.ArtistFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	artists, err := h.artistRepo.GetArtists(c.Request.Context(), params)
 
 	if err != nil {
-		log.Printf("Error fetching artists: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		abortError(c, err, "Artist")
 		return
 	}
 
-	c.JSON(http.StatusOK, artists)
+	writePage(c, artists)
+}
+
+// BulkArtists creates and replaces artists in one request.
+func (h *ArtistHandler) BulkArtists(c *gin.Context) {
+	writeBulk(c, "Artist", nil, h.artistRepo.BulkArtists)
+}
+
+// createArtistTree creates the artist with its albums and their songs, and
+// answers with the artist and its albums.
+func (h *ArtistHandler) createArtistTree(c *gin.Context, newArtist model.ArtistTree) {
+	createdArtist, err := h.artistRepo.CreateArtistTree(c.Request.Context(), newArtist)
+
+	if err != nil {
+		abortError(c, err, "Artist")
+		return
+	}
+
+	newUrl := "Location: /artists/" + strconv.Itoa(createdArtist.ID)
+	c.Header("location", newUrl)
+	c.Header("ETag", etag(createdArtist.Version))
+	c.JSON(http.StatusCreated, createdArtist)
 }
 
 func (h *ArtistHandler) GetArtist(c *gin.Context) {
@@ -42,113 +67,237 @@ func (h *ArtistHandler) GetArtist(c *gin.Context) {
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
+			h.redirectMergedArtist(c, id)
 			return
 		}
 
-		log.Printf("Error fetching artist %s: %v", id, err)
+		abortError(c, err, "Artist")
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	if notModified(c, artist.Version) {
 		return
 	}
 
 	c.JSON(http.StatusOK, artist)
 }
 
+// CreateArtist creates an artist and, when the body lists albums, its albums
+// and their songs along with it.
 func (h *ArtistHandler) CreateArtist(c *gin.Context) {
-	var newArtist model.CreateArtist
+	var newArtist model.ArtistTree
 
-	err := c.BindJSON(&newArtist)
+	if !bindJSON(c, &newArtist) {
+		return
+	}
+
+	findOrCreate, err := parseBoolQuery(c, "match")
 	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "Invalid value for match")
 		return
 	}
 
-	createdArtist, err := h.artistRepo.CreateArtist(c.Request.Context(), newArtist)
+	if findOrCreate && newArtist.Albums != nil {
+		abortProblem(c, http.StatusBadRequest, "match can't be used when creating albums along with the artist")
+		return
+	}
 
-	if err != nil {
-		log.Printf("Error creating artist %v", err)
+	if findOrCreate {
+		h.findOrCreateArtist(c, newArtist.CreateArtist)
+		return
+	}
+
+	if newArtist.Albums != nil {
+		h.createArtistTree(c, newArtist)
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	createdArtist, err := h.artistRepo.CreateArtist(c.Request.Context(), newArtist.CreateArtist)
+
+	if err != nil {
+		abortError(c, err, "Artist")
 		return
 	}
 
 	newUrl := "Location: /artists/" + strconv.Itoa(createdArtist.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(createdArtist.Version))
 	c.JSON(http.StatusCreated, createdArtist)
 }
 
-func (h *ArtistHandler) UpdateArtist(c *gin.Context) {
-	id := c.Param("id")
-	var newArtist model.UpdateArtist
+// findOrCreateArtist returns an existing artist whose name is similar enough
+// to the new one with 200, and only creates the artist when there is none.
+func (h *ArtistHandler) findOrCreateArtist(c *gin.Context, newArtist model.CreateArtist) {
+	threshold, err := parseThreshold(c, defaultFindOrCreateThreshold)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	artist, created, err := h.artistRepo.FindOrCreateArtist(c.Request.Context(), newArtist, threshold)
+
+	if err != nil {
+		abortError(c, err, "Artist")
+		return
+	}
+
+	newUrl := "Location: /artists/" + strconv.Itoa(artist.ID)
+	c.Header("location", newUrl)
+	c.Header("ETag", etag(artist.Version))
 
-	err := c.BindJSON(&newArtist)
+	if !created {
+		c.JSON(http.StatusOK, artist)
+		return
+	}
+
+	c.JSON(http.StatusCreated, artist)
+}
+
+func (h *ArtistHandler) MatchArtists(c *gin.Context) {
+	params, err := parseMatchParams(c)
 	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	updatedArtist, err := h.artistRepo.UpdateArtist(c.Request.Context(), newArtist, id)
+	artists, err := h.artistRepo.MatchArtists(c.Request.Context(), params)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
-			return
-		}
+		abortError(c, err, "Artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, artists)
+}
+
+func (h *ArtistHandler) UpdateArtist(c *gin.Context) {
+	id := c.Param("id")
+	var newArtist model.UpdateArtist
+
+	if !bindJSON(c, &newArtist) {
+		return
+	}
 
-		log.Printf("Error updating artist %v", err)
+	updatedArtist, err := h.artistRepo.UpdateArtist(c.Request.Context(), newArtist, id, parseIfMatch(c))
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	if err != nil {
+		abortError(c, err, "Artist")
 		return
 	}
 
 	newUrl := "Location: /artists/" + strconv.Itoa(updatedArtist.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(updatedArtist.Version))
 	c.JSON(http.StatusOK, updatedArtist)
 }
 
 func (h *ArtistHandler) PatchArtist(c *gin.Context) {
+	if isDocumentPatch(c) {
+		h.changeArtist(c)
+		return
+	}
+
 	id := c.Param("id")
 
 	var newArtist model.PatchArtist
 
-	err := c.BindJSON(&newArtist)
-	if err != nil {
+	if !bindJSON(c, &newArtist) {
 		return
 	}
 
-	patchedArtist, err := h.artistRepo.PatchArtist(c.Request.Context(), newArtist, id)
+	patchedArtist, err := h.artistRepo.PatchArtist(c.Request.Context(), newArtist, id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
-			return
-		}
-
-		log.Printf("Error patching artist %v", err)
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Artist")
 		return
 	}
 
 	newUrl := "Location: /artists/" + strconv.Itoa(patchedArtist.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(patchedArtist.Version))
 	c.JSON(http.StatusOK, patchedArtist)
 }
 
+// changeArtist applies a merge patch or JSON Patch to the artist document.
+func (h *ArtistHandler) changeArtist(c *gin.Context) {
+	id := c.Param("id")
+
+	patch, ok := readDocumentPatch(c)
+	if !ok {
+		return
+	}
+
+	changedArtist, err := h.artistRepo.ChangeArtist(c.Request.Context(), id, parseIfMatch(c), artistChange(patch))
+
+	if err != nil {
+		abortError(c, err, "Artist")
+		return
+	}
+
+	c.Header("Location", "/artists/"+strconv.Itoa(changedArtist.ID))
+	c.Header("ETag", etag(changedArtist.Version))
+	c.JSON(http.StatusOK, changedArtist)
+}
+
 func (h *ArtistHandler) DeleteArtist(c *gin.Context) {
 	id := c.Param("id")
-	err := h.artistRepo.DeleteArtist(c.Request.Context(), id)
+	archivedArtist, err := h.artistRepo.DeleteArtist(c.Request.Context(), id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
-			return
-		}
+		abortError(c, err, "Artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, archivedArtist)
+}
+
+func (h *ArtistHandler) RestoreArtist(c *gin.Context) {
+	id := c.Param("id")
+
+	cascade, err := parseBoolQuery(c, "cascade")
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "Invalid value for cascade")
+		return
+	}
+
+	restoredArtist, err := h.artistRepo.RestoreArtist(c.Request.Context(), id, cascade)
+
+	if err != nil {
+		abortError(c, err, "Archived artist")
+		return
+	}
 
-		log.Printf("Error deleting artist %s: %v", id, err)
+	c.JSON(http.StatusOK, restoredArtist)
+}
+
+// redirectMergedArtist answers a lookup of a missing artist with a redirect to
+// the artist it was merged into, if any.
+func (h *ArtistHandler) redirectMergedArtist(c *gin.Context, id string) {
+	targetID, err := h.artistRepo.ArtistRedirect(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Artist")
+		return
+	}
+
+	c.Redirect(http.StatusMovedPermanently, "/artists/"+strconv.Itoa(targetID))
+}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+func (h *ArtistHandler) MergeArtist(c *gin.Context) {
+	id := c.Param("id")
+	var merge model.MergeArtist
+
+	if !bindJSON(c, &merge) {
+		return
+	}
+
+	mergedArtist, err := h.artistRepo.MergeArtist(c.Request.Context(), id, merge.TargetID)
+
+	if err != nil {
+		abortError(c, err, "Artist")
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.Header("Location", "/artists/"+strconv.Itoa(mergedArtist.TargetID))
+	c.JSON(http.StatusOK, mergedArtist)
 }
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/liamcoleman/music-go/internal/model"
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ndjsonType   = "application/x-ndjson"
+	maxBulkItems = 10000
+)
+
+// bulkResult reports what became of one item of a bulk request. A failed
+// item carries the status, type, detail and fields its own request would
+// have been answered with.
+type bulkResult struct {
+	Index  int                `json:"index"`
+	Status int                `json:"status"`
+	ID     int                `json:"id,omitempty"`
+	Type   string             `json:"type,omitempty"`
+	Detail string             `json:"detail,omitempty"`
+	Fields []model.FieldError `json:"fields,omitempty"`
+}
+
+type bulkResponse struct {
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Failed  int          `json:"failed"`
+	Results []bulkResult `json:"results"`
+}
+
+// bulkWriter is a repository's bulk write, such as ArtistStore.BulkArtists.
+type bulkWriter[C, U any] func(ctx context.Context, items []model.BulkItem[C, U], atomic bool) ([]model.BulkResult, error)
+
+// writeBulk answers a bulk request with write. Items are created, or
+// replaced when they carry an id. By default the request is all or nothing:
+// any invalid or failing item is answered with its problem and nothing is
+// written. With mode=best-effort every item that can be written is, and the
+// response lists what became of each. parentMissing answers an item whose
+// parent doesn't exist or is archived.
+func writeBulk[C, U any](c *gin.Context, resource string, parentMissing error, write bulkWriter[C, U]) {
+	atomic := true
+
+	switch c.Query("mode") {
+	case "", "atomic":
+	case "best-effort":
+		atomic = false
+	default:
+		abortProblem(c, http.StatusBadRequest, "mode must be atomic or best-effort")
+		return
+	}
+
+	raw, ok := readBulk(c)
+	if !ok {
+		return
+	}
+
+	items, invalid := decodeBulk[C, U](raw)
+
+	if atomic && len(invalid) > 0 {
+		fields := []model.FieldError{}
+		for _, i := range slices.Sorted(maps.Keys(invalid)) {
+			fields = append(fields, itemFields(i, invalid[i])...)
+		}
+
+		abortError(c, invalidFieldsProblem(fields), "")
+		return
+	}
+
+	// Only valid items are written, so remember where each came from.
+	indexes := []int{}
+	valid := []model.BulkItem[C, U]{}
+	for i, item := range items {
+		if _, bad := invalid[i]; !bad {
+			indexes = append(indexes, i)
+			valid = append(valid, item)
+		}
+	}
+
+	results, err := write(c.Request.Context(), valid, atomic)
+
+	if err != nil {
+		var bulkErr *repository.BulkError
+		if errors.As(err, &bulkErr) {
+			i := indexes[bulkErr.Index]
+			problem := problemFor(itemError(bulkErr.Err, parentMissing), resource)
+			problem.Detail = "Item " + strconv.Itoa(i) + ": " + problem.Detail
+			problem.Fields = itemFields(i, problem.Fields)
+			err = problem
+		}
+
+		abortError(c, err, resource)
+		return
+	}
+
+	response := bulkResponse{Results: make([]bulkResult, len(raw))}
+
+	for i, fields := range invalid {
+		response.Results[i] = bulkResult{
+			Status: http.StatusUnprocessableEntity,
+			Type:   problemInvalidFields,
+			Detail: "Invalid fields",
+			Fields: fields,
+		}
+	}
+
+	for n, result := range results {
+		i := indexes[n]
+
+		switch {
+		case result.Err != nil:
+			problem := problemFor(itemError(result.Err, parentMissing), resource)
+			response.Results[i] = bulkResult{Status: problem.Status, Type: problem.Type, Detail: problem.Detail, Fields: problem.Fields}
+		case result.Created:
+			response.Results[i] = bulkResult{Status: http.StatusCreated, ID: result.ID}
+		default:
+			response.Results[i] = bulkResult{Status: http.StatusOK, ID: result.ID}
+		}
+	}
+
+	for i := range response.Results {
+		response.Results[i].Index = i
+
+		switch response.Results[i].Status {
+		case http.StatusCreated:
+			response.Created++
+		case http.StatusOK:
+			response.Updated++
+		default:
+			response.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func itemError(err error, parentMissing error) error {
+	if parentMissing != nil && errors.Is(err, repository.ErrParentNotFound) {
+		return parentMissing
+	}
+
+	return err
+}
+
+// itemFields names the fields of the item at index i after it, as in
+// "3/title", in a new list.
+func itemFields(i int, fields []model.FieldError) []model.FieldError {
+	named := make([]model.FieldError, len(fields))
+	for n, field := range fields {
+		named[n] = model.FieldError{Field: strconv.Itoa(i), Message: field.Message}
+		if field.Field != "" {
+			named[n].Field += "/" + field.Field
+		}
+	}
+
+	return named
+}
+
+// readBulk reads the items of a bulk request: a JSON array or, with
+// Content-Type application/x-ndjson, one JSON value per line.
+func readBulk(c *gin.Context) ([]json.RawMessage, bool) {
+	decoder := json.NewDecoder(c.Request.Body)
+	raw := []json.RawMessage{}
+
+	if c.ContentType() == ndjsonType {
+		for len(raw) <= maxBulkItems {
+			var item json.RawMessage
+			err := decoder.Decode(&item)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				abortError(c, newProblem(http.StatusBadRequest, problemValidation, "Invalid request body at line "+strconv.Itoa(len(raw)+1)), "")
+				return nil, false
+			}
+
+			raw = append(raw, item)
+		}
+	} else if err := decoder.Decode(&raw); err != nil {
+		problem := newProblem(http.StatusBadRequest, problemValidation, "Invalid request body")
+		problem.Fields = []model.FieldError{{Field: "", Message: "must be a list"}}
+		abortError(c, problem, "")
+		return nil, false
+	}
+
+	if len(raw) > maxBulkItems {
+		abortProblem(c, http.StatusRequestEntityTooLarge, "A bulk request holds at most "+strconv.Itoa(maxBulkItems)+" items")
+		return nil, false
+	}
+
+	return raw, true
+}
+
+// decodeBulk decodes each item into a record to create or, when it has an
+// id, the new state of that record, and checks it. Items that can't be used
+// are listed by index with their invalid fields.
+func decodeBulk[C, U any](raw []json.RawMessage) ([]model.BulkItem[C, U], map[int][]model.FieldError) {
+	items := make([]model.BulkItem[C, U], len(raw))
+	invalid := map[int][]model.FieldError{}
+
+	for i, data := range raw {
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil || members == nil {
+			invalid[i] = []model.FieldError{{Field: "", Message: "must be an object"}}
+			continue
+		}
+
+		var target any = &items[i].Create
+		if id, ok := members["id"]; ok && string(id) != "null" {
+			if err := json.Unmarshal(id, &items[i].ID); err != nil || items[i].ID < 1 {
+				invalid[i] = []model.FieldError{{Field: "id", Message: "must be a positive integer"}}
+				continue
+			}
+			target = &items[i].Update
+		}
+
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal(data, target); errors.As(err, &typeErr) {
+			invalid[i] = []model.FieldError{{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)}}
+			continue
+		}
+
+		if errs := model.Validate(target); len(errs) > 0 {
+			invalid[i] = errs
+		}
+	}
+
+	return items, invalid
+}
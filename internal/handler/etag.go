@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a record version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch returns the versions listed in If-Match, or nil when the
+// header is missing or "*". Weak and foreign tags are dropped, so a header
+// listing only those never matches.
+func parseIfMatch(c *gin.Context) []int {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+
+	versions := []int{}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions
+}
+
+// notModified sets the ETag header for version and, when If-None-Match
+// already lists it, answers 304 Not Modified and reports true.
+func notModified(c *gin.Context, version int) bool {
+	tag := etag(version)
+	c.Header("ETag", tag)
+
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
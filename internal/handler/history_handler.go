@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+var entityNames = map[string]string{
+	"artist": "Artist",
+	"album":  "Album",
+	"song":   "Song",
+	"person": "Person",
+}
+
+type HistoryHandler struct {
+	historyRepo repository.HistoryStore
+}
+
+func NewHistoryHandler(historyRepo repository.HistoryStore) *HistoryHandler {
+	return &HistoryHandler{
+		historyRepo: historyRepo,
+	}
+}
+
+// History lists the revisions of one entity, newest first.
+func (h *HistoryHandler) History(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		revisions, err := h.historyRepo.History(c.Request.Context(), entity, id)
+
+		if err != nil {
+			abortError(c, err, entityNames[entity])
+			return
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+// Revert puts one entity back to how it was right after a revision.
+func (h *HistoryHandler) Revert(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		revisionID, err := strconv.Atoi(c.Param("revision"))
+		if err != nil {
+			abortError(c, repository.ErrRevisionNotFound, "")
+			return
+		}
+
+		revision, err := h.historyRepo.Revert(c.Request.Context(), entity, id, revisionID)
+
+		if err != nil {
+			abortError(c, err, entityNames[entity])
+			return
+		}
+
+		if revision == nil {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		c.JSON(http.StatusOK, revision)
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMatchThreshold        = 0.3
+	defaultFindOrCreateThreshold = 0.6
+	defaultMatchLimit            = 10
+	maxMatchLimit                = 50
+)
+
+// parseThreshold reads the optional similarity threshold, which must lie in
+// (0, 1].
+func parseThreshold(c *gin.Context, defaultThreshold float64) (float64, error) {
+	value := c.Query("threshold")
+	if value == "" {
+		return defaultThreshold, nil
+	}
+
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return 0, errors.New("threshold must be greater than 0 and at most 1")
+	}
+
+	return threshold, nil
+}
+
+func parseMatchParams(c *gin.Context) (model.MatchParams, error) {
+	params := model.MatchParams{
+		Name:  strings.TrimSpace(c.Query("name")),
+		Limit: defaultMatchLimit,
+	}
+
+	if params.Name == "" {
+		return params, errors.New("name is required")
+	}
+
+	threshold, err := parseThreshold(c, defaultMatchThreshold)
+	if err != nil {
+		return params, err
+	}
+	params.Threshold = threshold
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxMatchLimit {
+			return params, errors.New("limit must be between 1 and " + strconv.Itoa(maxMatchLimit))
+		}
+		params.Limit = limit
+	}
+
+	return params, nil
+}
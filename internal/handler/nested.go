@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parentID reads the id of the artist or album a nested route is under,
+// answering 400 Bad Request when it isn't one.
+func parentID(c *gin.Context, parent string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		abortError(c, newProblem(http.StatusBadRequest, problemValidation, "Invalid "+parent+" id"), "")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// parentNotFound is the answer to creating a record under a nested route
+// whose parent doesn't exist or is archived.
+func parentNotFound(resource string) *Problem {
+	return newProblem(http.StatusNotFound, problemNotFound, resource+" not found")
+}
+
+// tracklistChange turns a tracklist that replaces an album's songs into
+// model.AlbumChanges. Songs of the album left out of the list are removed.
+func tracklistChange(tracklist []model.TracklistSong) func(model.AlbumWithSongs) (model.AlbumChanges, error) {
+	return func(album model.AlbumWithSongs) (model.AlbumChanges, error) {
+		changes := model.AlbumChanges{Songs: map[int]model.PatchSong{}}
+		errs := []model.FieldError{}
+
+		current := map[int]model.Song{}
+		for _, song := range album.Songs {
+			current[song.ID] = song
+		}
+
+		positions := model.DiscPositions{}
+
+		for i, entry := range tracklist {
+			disc := model.DiscOrFirst(entry.DiscNumber)
+			track := entry.TrackAt(positions.Next(disc))
+
+			if entry.ID == nil {
+				changes.Added = append(changes.Added, model.CreateSong{
+					AlbumID:         album.ID,
+					ArtistID:        entry.ArtistID,
+					Title:           entry.Title,
+					DiscNumber:      disc,
+					DiscTitle:       entry.DiscTitle,
+					TrackNumber:     track,
+					DurationSeconds: entry.DurationSeconds,
+					Artists:         entry.Artists,
+				})
+				continue
+			}
+
+			field := strconv.Itoa(i) + "/id"
+
+			song, ok := current[*entry.ID]
+			if !ok {
+				errs = append(errs, model.FieldError{Field: field, Message: "must be the id of a song on this album"})
+				continue
+			}
+
+			if _, seen := changes.Songs[song.ID]; seen {
+				errs = append(errs, model.FieldError{Field: field, Message: "must not appear more than once"})
+				continue
+			}
+
+			// Only what differs is written, so untouched songs keep their
+			// version and history.
+			var patch model.PatchSong
+			// An entry without an artist_id keeps the song's own artist.
+			if entry.ArtistID != 0 && entry.ArtistID != song.ArtistID {
+				patch.ArtistID = &entry.ArtistID
+			}
+			if entry.Title != song.Title {
+				patch.Title = &entry.Title
+			}
+			if disc != song.DiscNumber {
+				patch.DiscNumber = &disc
+			}
+			if entry.DiscTitle != song.DiscTitle {
+				patch.DiscTitle = &entry.DiscTitle
+			}
+			if track != song.TrackNumber {
+				patch.TrackNumber = &track
+			}
+			if entry.DurationSeconds != song.DurationSeconds {
+				patch.DurationSeconds = &entry.DurationSeconds
+			}
+			// An entry that leaves out artists keeps the song's credits.
+			if entry.Artists != nil {
+				patch.Artists = &entry.Artists
+			}
+
+			changes.Songs[song.ID] = patch
+		}
+
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		for _, song := range album.Songs {
+			if _, kept := changes.Songs[song.ID]; !kept {
+				changes.Removed = append(changes.Removed, song.ID)
+			}
+		}
+
+		return changes, nil
+	}
+}
+
+// reorderChange numbers the songs on each disc of an album from 1 in the
+// order of songIDs, which must list each of them once. Songs stay on their
+// disc.
+func reorderChange(songIDs []int) func(model.AlbumWithSongs) (model.AlbumChanges, error) {
+	return func(album model.AlbumWithSongs) (model.AlbumChanges, error) {
+		changes := model.AlbumChanges{Songs: map[int]model.PatchSong{}}
+		errs := []model.FieldError{}
+
+		current := map[int]model.Song{}
+		for _, song := range album.Songs {
+			current[song.ID] = song
+		}
+
+		positions := model.DiscPositions{}
+
+		for i, id := range songIDs {
+			field := "song_ids/" + strconv.Itoa(i)
+
+			song, ok := current[id]
+			if !ok {
+				errs = append(errs, model.FieldError{Field: field, Message: "must be the id of a song on this album"})
+				continue
+			}
+
+			if _, seen := changes.Songs[id]; seen {
+				errs = append(errs, model.FieldError{Field: field, Message: "must not appear more than once"})
+				continue
+			}
+
+			var patch model.PatchSong
+			if track := positions.Next(song.DiscNumber); track != song.TrackNumber {
+				patch.TrackNumber = &track
+			}
+
+			changes.Songs[id] = patch
+		}
+
+		for _, song := range album.Songs {
+			if _, listed := changes.Songs[song.ID]; !listed {
+				errs = append(errs, model.FieldError{Field: "song_ids", Message: "must include song " + strconv.Itoa(song.ID)})
+			}
+		}
+
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		return changes, nil
+	}
+}
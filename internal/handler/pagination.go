@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+func parseListParams(c *gin.Context, fields map[string]model.FieldSpec) (model.ListParams, error) {
+	params := model.ListParams{
+		Limit:  defaultPageLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	archived, err := parseBoolQuery(c, "archived")
+	if err != nil {
+		return params, errors.New("Invalid value for archived")
+	}
+	params.Archived = archived
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		params.Limit = limit
+	}
+
+	params.Sort, err = parseSort(c.Query("sort"), fields)
+	if err != nil {
+		return params, err
+	}
+
+	params.Filters, err = parseFilters(c, fields, listParams)
+	if err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// writePage renders a page of results, advertising the neighbouring pages in
+// a Link header as well as in the body.
+func writePage[T any](c *gin.Context, page *model.Page[T]) {
+	var links []string
+
+	if page.NextCursor != "" {
+		links = append(links, `<`+pageURL(c, page.NextCursor)+`>; rel="next"`)
+	}
+	if page.PrevCursor != "" {
+		links = append(links, `<`+pageURL(c, page.PrevCursor)+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func pageURL(c *gin.Context, cursor string) string {
+	query := c.Request.URL.Query()
+	query.Set("cursor", cursor)
+
+	return c.Request.URL.Path + "?" + query.Encode()
+}
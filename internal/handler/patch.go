@@ -0,0 +1,472 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/liamcoleman/music-go/internal/jsonpatch"
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// documentPatch is a JSON Merge Patch or a JSON Patch. Either one is applied
+// to the JSON representation of a record, as returned by GET.
+type documentPatch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// isDocumentPatch reports whether a PATCH request carries a merge patch or a
+// JSON Patch rather than a plain object of the fields to set.
+func isDocumentPatch(c *gin.Context) bool {
+	contentType := c.ContentType()
+	return contentType == mergePatchType || contentType == jsonPatchType
+}
+
+// readDocumentPatch decodes the body of a merge patch or JSON Patch request,
+// answering 400 Bad Request when it is malformed.
+func readDocumentPatch(c *gin.Context) (documentPatch, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abortError(c, newProblem(http.StatusBadRequest, problemValidation, "Invalid request body"), "")
+		return nil, false
+	}
+
+	var patch documentPatch
+	if c.ContentType() == jsonPatchType {
+		patch, err = jsonpatch.Decode(body)
+	} else {
+		patch, err = jsonpatch.DecodeMerge(body)
+	}
+
+	if err != nil {
+		abortError(c, newProblem(http.StatusBadRequest, problemValidation, capitalize(err.Error())), "")
+		return nil, false
+	}
+
+	return patch, true
+}
+
+type fieldKind int
+
+const (
+	// textField is a string that cannot be null.
+	textField fieldKind = iota
+	// optionalTextField is a string that null or removal clears.
+	optionalTextField
+	integerField
+)
+
+// The members of each document a patch may change. Any other member is read
+// only.
+var (
+	artistDocument = map[string]fieldKind{"name": textField, "description": optionalTextField}
+	personDocument = map[string]fieldKind{"name": textField, "description": optionalTextField}
+	albumDocument  = map[string]fieldKind{"name": textField, "release_year": integerField, "release_date": textField, "release_type": textField}
+	songDocument   = map[string]fieldKind{"artist_id": integerField, "title": textField, "disc_number": integerField, "disc_title": optionalTextField, "track_number": integerField, "duration_seconds": integerField}
+)
+
+// applyDocumentPatch applies patch to the JSON representation of record and
+// returns the members of the document before and after.
+func applyDocumentPatch(patch documentPatch, record any) (map[string]any, map[string]any, error) {
+	doc, err := json.Marshal(record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before map[string]any
+	if err := decodeDocument(doc, &before); err != nil {
+		return nil, nil, err
+	}
+
+	var after map[string]any
+	if err := decodeDocument(patched, &after); err != nil || after == nil {
+		return nil, nil, invalidFieldsProblem([]model.FieldError{{Field: "", Message: "must be an object"}})
+	}
+
+	return before, after, nil
+}
+
+func decodeDocument(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// patchedFields compares the members of a document before and after a patch
+// and decodes those that changed into target, a model.Patch* struct. Field
+// names in errors are prefixed with prefix.
+func patchedFields(before, after map[string]any, fields map[string]fieldKind, prefix string, target any) []model.FieldError {
+	errs := []model.FieldError{}
+	changed := map[string]any{}
+
+	keys := maps.Clone(before)
+	maps.Copy(keys, after)
+
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		old, hadKey := before[key]
+		value, hasKey := after[key]
+		if hadKey == hasKey && reflect.DeepEqual(old, value) {
+			continue
+		}
+
+		kind, ok := fields[key]
+		if !ok {
+			errs = append(errs, model.FieldError{Field: prefix + key, Message: "cannot be changed"})
+			continue
+		}
+
+		switch {
+		case value == nil && kind == optionalTextField:
+			changed[key] = ""
+		case value == nil:
+			errs = append(errs, model.FieldError{Field: prefix + key, Message: "must not be null"})
+		case kind == integerField:
+			n, ok := integer(value)
+			if !ok {
+				errs = append(errs, model.FieldError{Field: prefix + key, Message: "must be an integer"})
+				continue
+			}
+			changed[key] = n
+		default:
+			s, ok := value.(string)
+			if !ok {
+				errs = append(errs, model.FieldError{Field: prefix + key, Message: "must be a string"})
+				continue
+			}
+			changed[key] = s
+		}
+	}
+
+	data, err := json.Marshal(changed)
+	if err == nil {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		errs = append(errs, model.FieldError{Field: prefix, Message: err.Error()})
+	}
+
+	return errs
+}
+
+// integer returns a JSON number as an int, if it is one.
+func integer(value any) (int, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(number.String())
+	return n, err == nil
+}
+
+// prefixed qualifies the field names in errs with prefix.
+func prefixed(prefix string, errs []model.FieldError) []model.FieldError {
+	for i := range errs {
+		errs[i].Field = prefix + errs[i].Field
+	}
+
+	return errs
+}
+
+// artistChange turns a patch of an artist document into a model.PatchArtist.
+func artistChange(patch documentPatch) func(model.Artist) (model.PatchArtist, error) {
+	return func(artist model.Artist) (model.PatchArtist, error) {
+		var changes model.PatchArtist
+
+		before, after, err := applyDocumentPatch(patch, artist)
+		if err != nil {
+			return changes, err
+		}
+
+		errs := patchedFields(before, after, artistDocument, "", &changes)
+		errs = append(errs, model.Validate(changes)...)
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		return changes, nil
+	}
+}
+
+// personChange turns a patch of a person document into a model.PatchPerson.
+func personChange(patch documentPatch) func(model.Person) (model.PatchPerson, error) {
+	return func(person model.Person) (model.PatchPerson, error) {
+		var changes model.PatchPerson
+
+		before, after, err := applyDocumentPatch(patch, person)
+		if err != nil {
+			return changes, err
+		}
+
+		errs := patchedFields(before, after, personDocument, "", &changes)
+		errs = append(errs, model.Validate(changes)...)
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		return changes, nil
+	}
+}
+
+// songChange turns a patch of a song document into a model.PatchSong.
+func songChange(patch documentPatch) func(model.Song) (model.PatchSong, error) {
+	return func(song model.Song) (model.PatchSong, error) {
+		var changes model.PatchSong
+
+		before, after, err := applyDocumentPatch(patch, song)
+		if err != nil {
+			return changes, err
+		}
+
+		errs := patchedFields(before, after, songDocument, "", &changes)
+		errs = append(errs, model.Validate(changes)...)
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		return changes, nil
+	}
+}
+
+// patchedSong is an entry of an album's patched song list: an existing song,
+// or a new one when id is 0.
+type patchedSong struct {
+	id    int
+	patch model.PatchSong
+}
+
+// discEntry is a song listed in an album document, with the path of the
+// song in the document.
+type discEntry struct {
+	field string
+	song  any
+}
+
+// discSongs lists the songs of an album document disc by disc. Each song
+// takes the number and title of the disc it is listed under, so moving a song
+// to another disc's list moves it to that disc.
+func discSongs(doc map[string]any) ([]discEntry, []model.FieldError) {
+	discs, ok := doc["discs"].([]any)
+	if !ok {
+		return nil, []model.FieldError{{Field: "discs", Message: "must be a list"}}
+	}
+
+	entries := []discEntry{}
+	errs := []model.FieldError{}
+
+	for i, item := range discs {
+		field := "discs/" + strconv.Itoa(i)
+
+		disc, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, model.FieldError{Field: field, Message: "must be an object"})
+			continue
+		}
+
+		number, ok := integer(disc["disc_number"])
+		if !ok || number < 1 {
+			errs = append(errs, model.FieldError{Field: field + "/disc_number", Message: "must be a positive integer"})
+			continue
+		}
+
+		title := disc["title"]
+		if _, ok := title.(string); title != nil && !ok {
+			errs = append(errs, model.FieldError{Field: field + "/title", Message: "must be a string"})
+			continue
+		}
+
+		songs, ok := disc["songs"].([]any)
+		if !ok {
+			errs = append(errs, model.FieldError{Field: field + "/songs", Message: "must be a list"})
+			continue
+		}
+
+		for j, item := range songs {
+			if song, ok := item.(map[string]any); ok {
+				song["disc_number"] = json.Number(strconv.Itoa(number))
+				delete(song, "disc_title")
+				if title != nil {
+					song["disc_title"] = title
+				}
+			}
+
+			entries = append(entries, discEntry{field: field + "/songs/" + strconv.Itoa(j), song: item})
+		}
+	}
+
+	return entries, errs
+}
+
+// albumChange turns a patch of an album document into model.AlbumChanges.
+// Songs of the album missing from the patched discs are removed and entries
+// without an id are added. When songs are added, removed or moved, each disc
+// is renumbered in list order.
+func albumChange(patch documentPatch) func(model.AlbumWithSongs) (model.AlbumChanges, error) {
+	return func(album model.AlbumWithSongs) (model.AlbumChanges, error) {
+		changes := model.AlbumChanges{Songs: map[int]model.PatchSong{}}
+
+		before, after, err := applyDocumentPatch(patch, album)
+		if err != nil {
+			return changes, err
+		}
+
+		// Songs are compared with how they are stored rather than how they
+		// are listed, so a disc's title reaches each of its songs.
+		songsBefore := map[int]map[string]any{}
+		for _, song := range album.Songs {
+			var fields map[string]any
+
+			doc, err := json.Marshal(song)
+			if err == nil {
+				err = decodeDocument(doc, &fields)
+			}
+			if err != nil {
+				return changes, err
+			}
+
+			songsBefore[song.ID] = fields
+		}
+
+		entries, discErrs := discSongs(after)
+		delete(before, "discs")
+		delete(after, "discs")
+
+		errs := patchedFields(before, after, albumDocument, "", &changes.Album)
+		errs = append(errs, model.Validate(changes.Album)...)
+
+		if entries == nil {
+			return changes, invalidFieldsProblem(append(errs, discErrs...))
+		}
+		errs = append(errs, discErrs...)
+
+		songs := []patchedSong{}
+		reordered := len(entries) != len(album.Songs)
+
+		for i, entry := range entries {
+			prefix := entry.field + "/"
+
+			song, ok := entry.song.(map[string]any)
+			if !ok {
+				errs = append(errs, model.FieldError{Field: entry.field, Message: "must be an object"})
+				continue
+			}
+
+			var patched patchedSong
+
+			if rawID, ok := song["id"]; !ok || rawID == nil {
+				delete(song, "id")
+
+				errs = append(errs, patchedFields(map[string]any{}, song, songDocument, prefix, &patched.patch)...)
+				if _, ok := song["title"]; !ok {
+					errs = append(errs, model.FieldError{Field: prefix + "title", Message: "is required"})
+				}
+
+				reordered = true
+			} else {
+				id, ok := integer(rawID)
+				if _, exists := songsBefore[id]; !ok || !exists {
+					errs = append(errs, model.FieldError{Field: prefix + "id", Message: "must be the id of a song on this album"})
+					continue
+				}
+
+				if slices.ContainsFunc(songs, func(s patchedSong) bool { return s.id == id }) {
+					errs = append(errs, model.FieldError{Field: prefix + "id", Message: "must not appear more than once"})
+					continue
+				}
+
+				patched.id = id
+				errs = append(errs, patchedFields(songsBefore[id], song, songDocument, prefix, &patched.patch)...)
+
+				if i >= len(album.Songs) || album.Songs[i].ID != id || patched.patch.DiscNumber != nil {
+					reordered = true
+				}
+			}
+
+			errs = append(errs, prefixed(prefix, model.Validate(patched.patch))...)
+			songs = append(songs, patched)
+		}
+
+		if len(errs) > 0 {
+			return changes, invalidFieldsProblem(errs)
+		}
+
+		for _, song := range album.Songs {
+			if !slices.ContainsFunc(songs, func(s patchedSong) bool { return s.id == song.ID }) {
+				changes.Removed = append(changes.Removed, song.ID)
+			}
+		}
+
+		current := map[int]model.Song{}
+		for _, song := range album.Songs {
+			current[song.ID] = song
+		}
+
+		positions := model.DiscPositions{}
+
+		for _, song := range songs {
+			disc := current[song.id].DiscNumber
+			if song.patch.DiscNumber != nil {
+				disc = *song.patch.DiscNumber
+			}
+
+			if reordered {
+				track := positions.Next(disc)
+				song.patch.TrackNumber = &track
+
+				if song.id != 0 && current[song.id].TrackNumber == track {
+					song.patch.TrackNumber = nil
+				}
+			}
+
+			if song.id != 0 {
+				changes.Songs[song.id] = song.patch
+				continue
+			}
+
+			changes.Added = append(changes.Added, model.CreateSong{
+				AlbumID:         album.ID,
+				ArtistID:        derefInt(song.patch.ArtistID),
+				Title:           *song.patch.Title,
+				DiscNumber:      disc,
+				DiscTitle:       derefString(song.patch.DiscTitle),
+				TrackNumber:     derefInt(song.patch.TrackNumber),
+				DurationSeconds: derefInt(song.patch.DurationSeconds),
+			})
+		}
+
+		return changes, nil
+	}
+}
+
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonHandler struct {
+	personRepo repository.PersonStore
+}
+
+func NewPersonHandler(personRepo repository.PersonStore) *PersonHandler {
+	return &PersonHandler{
+		personRepo: personRepo,
+	}
+}
+
+func (h *PersonHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, model.PersonFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	people, err := h.personRepo.GetPeople(c.Request.Context(), params)
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	writePage(c, people)
+}
+
+func (h *PersonHandler) GetPerson(c *gin.Context) {
+	id := c.Param("id")
+	person, err := h.personRepo.GetPerson(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	if notModified(c, person.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+// GetPersonCredits lists the albums and songs the person is credited on,
+// latest release first.
+func (h *PersonHandler) GetPersonCredits(c *gin.Context) {
+	id := c.Param("id")
+	albums, err := h.personRepo.GetPersonCredits(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.JSON(http.StatusOK, albums)
+}
+
+func (h *PersonHandler) CreatePerson(c *gin.Context) {
+	var newPerson model.CreatePerson
+
+	if !bindJSON(c, &newPerson) {
+		return
+	}
+
+	createdPerson, err := h.personRepo.CreatePerson(c.Request.Context(), newPerson)
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.Header("Location", "/people/"+strconv.Itoa(createdPerson.ID))
+	c.Header("ETag", etag(createdPerson.Version))
+	c.JSON(http.StatusCreated, createdPerson)
+}
+
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	id := c.Param("id")
+	var newPerson model.UpdatePerson
+
+	if !bindJSON(c, &newPerson) {
+		return
+	}
+
+	updatedPerson, err := h.personRepo.UpdatePerson(c.Request.Context(), newPerson, id, parseIfMatch(c))
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.Header("Location", "/people/"+strconv.Itoa(updatedPerson.ID))
+	c.Header("ETag", etag(updatedPerson.Version))
+	c.JSON(http.StatusOK, updatedPerson)
+}
+
+func (h *PersonHandler) PatchPerson(c *gin.Context) {
+	if isDocumentPatch(c) {
+		h.changePerson(c)
+		return
+	}
+
+	id := c.Param("id")
+
+	var newPerson model.PatchPerson
+
+	if !bindJSON(c, &newPerson) {
+		return
+	}
+
+	patchedPerson, err := h.personRepo.PatchPerson(c.Request.Context(), newPerson, id, parseIfMatch(c))
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.Header("Location", "/people/"+strconv.Itoa(patchedPerson.ID))
+	c.Header("ETag", etag(patchedPerson.Version))
+	c.JSON(http.StatusOK, patchedPerson)
+}
+
+// changePerson applies a merge patch or JSON Patch to the person document.
+func (h *PersonHandler) changePerson(c *gin.Context) {
+	id := c.Param("id")
+
+	patch, ok := readDocumentPatch(c)
+	if !ok {
+		return
+	}
+
+	changedPerson, err := h.personRepo.ChangePerson(c.Request.Context(), id, parseIfMatch(c), personChange(patch))
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.Header("Location", "/people/"+strconv.Itoa(changedPerson.ID))
+	c.Header("ETag", etag(changedPerson.Version))
+	c.JSON(http.StatusOK, changedPerson)
+}
+
+func (h *PersonHandler) DeletePerson(c *gin.Context) {
+	id := c.Param("id")
+	err := h.personRepo.DeletePerson(c.Request.Context(), id, parseIfMatch(c))
+
+	if err != nil {
+		abortError(c, err, "Person")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PersonHandler) RestorePerson(c *gin.Context) {
+	id := c.Param("id")
+	restoredPerson, err := h.personRepo.RestorePerson(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Archived person")
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredPerson)
+}
+
+// GetRoles lists the roles people can be credited in, in the order credits
+// are shown in.
+func (h *PersonHandler) GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, model.PersonRoles)
+}
+
+func (h *PersonHandler) GetCredit(c *gin.Context) {
+	id := c.Param("id")
+	credit, err := h.personRepo.GetPersonCredit(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Credit")
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+func (h *PersonHandler) CreateCredit(c *gin.Context) {
+	var newCredit model.CreatePersonCredit
+
+	if !bindJSON(c, &newCredit) {
+		return
+	}
+
+	createdCredit, err := h.personRepo.CreatePersonCredit(c.Request.Context(), newCredit)
+
+	if err != nil {
+		abortError(c, creditError(err, newCredit.AlbumID != 0), "Credit")
+		return
+	}
+
+	c.Header("Location", "/credits/"+strconv.Itoa(createdCredit.ID))
+	c.JSON(http.StatusCreated, createdCredit)
+}
+
+func (h *PersonHandler) UpdateCredit(c *gin.Context) {
+	id := c.Param("id")
+	var newCredit model.UpdatePersonCredit
+
+	if !bindJSON(c, &newCredit) {
+		return
+	}
+
+	updatedCredit, err := h.personRepo.UpdatePersonCredit(c.Request.Context(), newCredit, id)
+
+	if err != nil {
+		abortError(c, creditError(err, false), "Credit")
+		return
+	}
+
+	c.Header("Location", "/credits/"+strconv.Itoa(updatedCredit.ID))
+	c.JSON(http.StatusOK, updatedCredit)
+}
+
+func (h *PersonHandler) DeleteCredit(c *gin.Context) {
+	id := c.Param("id")
+	err := h.personRepo.DeletePersonCredit(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Credit")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// creditError names the field at fault when a credit's person, or the album
+// or song it is on, doesn't exist or is archived.
+func creditError(err error, onAlbum bool) error {
+	switch {
+	case errors.Is(err, repository.ErrPersonNotFound):
+		return invalidFieldsProblem([]model.FieldError{{Field: "person_id", Message: "must be a person who exists and isn't archived"}})
+	case errors.Is(err, repository.ErrParentNotFound) && onAlbum:
+		return invalidFieldsProblem([]model.FieldError{{Field: "album_id", Message: "must be an album that exists and isn't archived"}})
+	case errors.Is(err, repository.ErrParentNotFound):
+		return invalidFieldsProblem([]model.FieldError{{Field: "song_id", Message: "must be a song that exists and isn't archived"}})
+	}
+
+	return err
+}
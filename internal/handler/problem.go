@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/liamcoleman/music-go/internal/jsonpatch"
+	"github.com/liamcoleman/music-go/internal/model"
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object. Fields is an extension
+// member listing the invalid fields of a request body.
+type Problem struct {
+	Type     string             `json:"type"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"`
+	Detail   string             `json:"detail,omitempty"`
+	Instance string             `json:"instance,omitempty"`
+	Fields   []model.FieldError `json:"fields,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+func newProblem(status int, problemType, detail string) *Problem {
+	return &Problem{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// The problem types beyond about:blank, as references relative to the API.
+const (
+	problemNotFound            = "/problems/not-found"
+	problemConflict            = "/problems/conflict"
+	problemForeignKeyViolation = "/problems/foreign-key-violation"
+	problemUniqueViolation     = "/problems/unique-violation"
+	problemValidation          = "/problems/validation"
+	problemInvalidFields       = "/problems/invalid-fields"
+	problemVersionMismatch     = "/problems/version-mismatch"
+	problemPatchConflict       = "/problems/patch-conflict"
+)
+
+// errorKinds maps each kind of repository error to how it is answered.
+var errorKinds = []struct {
+	kind        error
+	status      int
+	problemType string
+	// suffix follows the resource name in the detail when the error has
+	// none of its own.
+	suffix string
+}{
+	{repository.ErrNotFound, http.StatusNotFound, problemNotFound, " not found"},
+	{repository.ErrConflict, http.StatusConflict, problemConflict, " conflicts with its current state"},
+	{repository.ErrForeignKeyViolation, http.StatusUnprocessableEntity, problemForeignKeyViolation, " refers to a missing record"},
+	{repository.ErrUniqueViolation, http.StatusConflict, problemUniqueViolation, " already exists"},
+	{repository.ErrValidation, http.StatusBadRequest, problemValidation, " is invalid"},
+}
+
+// abortError stops the request with err, for Problems to render. resource
+// names what the request is about, such as "Artist", for details like
+// "Artist not found".
+func abortError(c *gin.Context, err error, resource string) {
+	c.Error(err).SetMeta(resource)
+	c.Abort()
+}
+
+// abortProblem stops the request with a problem of the given status.
+func abortProblem(c *gin.Context, status int, detail string) {
+	abortError(c, newProblem(status, "about:blank", detail), "")
+}
+
+// Problems renders the error a handler stopped with as an
+// application/problem+json response. Errors it can't classify are logged and
+// answered with 500 Internal Server Error, without detail.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		resource, _ := last.Meta.(string)
+		problem := problemFor(last.Err, resource)
+
+		if problem.Status == http.StatusInternalServerError {
+			log.Printf("Error handling %s %s: %v", c.Request.Method, c.Request.URL.Path, last.Err)
+		}
+
+		problem.Instance = c.Request.URL.RequestURI()
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+func problemFor(err error, resource string) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		copied := *problem
+		return &copied
+	}
+
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return newProblem(http.StatusPreconditionFailed, problemVersionMismatch, resource+" has been modified")
+	}
+
+	if errors.Is(err, jsonpatch.ErrConflict) {
+		return newProblem(http.StatusConflict, problemPatchConflict, capitalize(err.Error()))
+	}
+
+	var repoErr *repository.Error
+	if errors.As(repository.Translate(err), &repoErr) {
+		for _, k := range errorKinds {
+			if !errors.Is(repoErr.Kind, k.kind) {
+				continue
+			}
+
+			detail := capitalize(repoErr.Detail)
+			if detail == "" {
+				detail = resource + k.suffix
+			}
+
+			return newProblem(k.status, k.problemType, detail)
+		}
+	}
+
+	return newProblem(http.StatusInternalServerError, "about:blank", "")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// invalidFieldsProblem is the 422 answer to a request body with invalid
+// fields.
+func invalidFieldsProblem(fields []model.FieldError) *Problem {
+	problem := newProblem(http.StatusUnprocessableEntity, problemInvalidFields, "Invalid fields")
+	problem.Fields = fields
+	return problem
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listParams are query parameters consumed by parseListParams itself rather
+// than treated as filters.
+var listParams = map[string]bool{
+	"archived": true,
+	"cursor":   true,
+	"limit":    true,
+	"sort":     true,
+}
+
+var filterParam = regexp.MustCompile(`^(\w+)(?:\[(\w+)\])?$`)
+
+// parseBoolQuery reads an optional boolean query parameter, treating a
+// missing value as false.
+func parseBoolQuery(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
+
+// parseFilters turns every non-reserved query parameter into a filter of the
+// form `field=value` or `field[op]=value`, checked against the endpoint's
+// whitelisted fields. Values for `in` are comma separated.
+func parseFilters(c *gin.Context, fields map[string]model.FieldSpec, reserved map[string]bool) ([]model.Filter, error) {
+	query := c.Request.URL.Query()
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !reserved[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	filters := []model.Filter{}
+
+	for _, key := range keys {
+		match := filterParam.FindStringSubmatch(key)
+		if match == nil {
+			return nil, fmt.Errorf("Invalid filter parameter %q", key)
+		}
+
+		field, op := match[1], model.FilterOp(match[2])
+		if op == "" {
+			op = model.OpEq
+		}
+
+		spec, ok := fields[field]
+		if !ok {
+			return nil, fmt.Errorf("Unknown filter field %q", field)
+		}
+
+		if !knownFilterOp(op) {
+			return nil, fmt.Errorf("Unknown filter operator %q", op)
+		}
+
+		if !slices.Contains(model.FilterOps[spec.Type], op) {
+			return nil, fmt.Errorf("Operator %q is not supported for field %q", op, field)
+		}
+
+		for _, raw := range query[key] {
+			value, err := parseFilterValue(spec.Type, op, raw)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value %q for %s", raw, key)
+			}
+
+			filters = append(filters, model.Filter{Field: field, Op: op, Value: value})
+		}
+	}
+
+	return filters, nil
+}
+
+func knownFilterOp(op model.FilterOp) bool {
+	for _, ops := range model.FilterOps {
+		if slices.Contains(ops, op) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseFilterValue(fieldType model.FieldType, op model.FilterOp, raw string) (any, error) {
+	if op != model.OpIn {
+		switch fieldType {
+		case model.IntField:
+			return strconv.ParseInt(raw, 10, 64)
+		case model.DateField:
+			return parseDate(raw)
+		case model.BoolField:
+			return strconv.ParseBool(raw)
+		}
+		return raw, nil
+	}
+
+	parts := strings.Split(raw, ",")
+
+	switch fieldType {
+	case model.IntField:
+		values := []int64{}
+		for _, part := range parts {
+			n, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, n)
+		}
+		return values, nil
+	case model.DateField:
+		values := []string{}
+		for _, part := range parts {
+			date, err := parseDate(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, date)
+		}
+		return values, nil
+	}
+
+	return parts, nil
+}
+
+// parseDate checks a release date filter value, as in "1999" or "1999-09".
+func parseDate(raw string) (string, error) {
+	if !model.ReleaseDate(raw).Valid() {
+		return "", fmt.Errorf("invalid release date %q", raw)
+	}
+
+	return raw, nil
+}
+
+// parseSort reads a comma separated list of fields, each optionally prefixed
+// with - for descending order.
+func parseSort(value string, fields map[string]model.FieldSpec) ([]model.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sortFields := []model.SortField{}
+
+	for _, part := range strings.Split(value, ",") {
+		field := strings.TrimSpace(part)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		if spec, ok := fields[field]; !ok || !spec.Sortable {
+			return nil, fmt.Errorf("Cannot sort by %q", field)
+		}
+
+		sortFields = append(sortFields, model.SortField{Field: field, Desc: desc})
+	}
+
+	return sortFields, nil
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/repository"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	searchRepo repository.SearchStore
+}
+
+func NewSearchHandler(searchRepo repository.SearchStore) *SearchHandler {
+	return &SearchHandler{
+		searchRepo: searchRepo,
+	}
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	params := model.SearchParams{
+		Query: strings.TrimSpace(c.Query("q")),
+		Limit: defaultSearchLimit,
+	}
+
+	if params.Query == "" {
+		abortProblem(c, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	if value := c.Query("type"); value != "" {
+		for _, searchType := range strings.Split(value, ",") {
+			if !slices.Contains(repository.SearchTypes, searchType) {
+				abortProblem(c, http.StatusBadRequest, "Unknown search type "+strconv.Quote(searchType))
+				return
+			}
+			params.Types = append(params.Types, searchType)
+		}
+	}
+
+	archived, err := parseBoolQuery(c, "archived")
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "Invalid value for archived")
+		return
+	}
+	params.Archived = archived
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			abortProblem(c, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+			return
+		}
+		params.Limit = limit
+	}
+
+	results, err := h.searchRepo.Search(c.Request.Context(), params)
+
+	if err != nil {
+		abortError(c, err, "Search")
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
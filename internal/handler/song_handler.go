@@ -2,7 +2,6 @@ package handler
 
 import (
 	"errors"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -11,66 +10,125 @@ import (
 	"github.com/liamcoleman/music-go/internal/model"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 )
 
 type SongHandler struct {
-	songRepo *repository.SongRepository
+	songRepo repository.SongStore
 }
 
-func NewSongHandler(songRepo *repository.SongRepository) *SongHandler {
+func NewSongHandler(songRepo repository.SongStore) *SongHandler {
 	return &SongHandler{
 		songRepo: songRepo,
 	}
 }
 
 func (h *SongHandler) GetAll(c *gin.Context) {
-	songs, err := h.songRepo.GetSongs(c.Request.Context())
+	params, err := parseListParams(c, model.SongFields)
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	songs, err := h.songRepo.GetSongs(c.Request.Context(), params)
+
+	if err != nil {
+		abortError(c, err, "Song")
+		return
+	}
+
+	writePage(c, songs)
+}
 
+// GetAlbumSongs lists the songs of the album in the path, in disc and track
+// order unless another sort is asked for.
+func (h *SongHandler) GetAlbumSongs(c *gin.Context) {
+	params, err := parseListParams(c, model.SongFields)
 	if err != nil {
-		log.Printf("Error fetching songs: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		abortProblem(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, songs)
+	if len(params.Sort) == 0 {
+		params.Sort = []model.SortField{{Field: "disc_number"}, {Field: "track_number"}}
+	}
+
+	songs, err := h.songRepo.GetAlbumSongs(c.Request.Context(), c.Param("id"), params)
+
+	if err != nil {
+		abortError(c, err, "Album")
+		return
+	}
+
+	writePage(c, songs)
 }
 
 func (h *SongHandler) CreateSong(c *gin.Context) {
 	var newSong model.CreateSong
 
-	err := c.BindJSON(&newSong)
-	if err != nil {
+	if !bindJSON(c, &newSong) {
 		return
 	}
 
+	h.createSong(c, newSong, invalidFieldsProblem([]model.FieldError{{Field: "album_id", Message: "must be an album that exists and isn't archived"}}))
+}
+
+// CreateAlbumSong adds a song to the album in the path. The body needn't
+// repeat the album_id.
+func (h *SongHandler) CreateAlbumSong(c *gin.Context) {
+	albumID, ok := parentID(c, "album")
+	if !ok {
+		return
+	}
+
+	newSong := model.CreateSong{AlbumID: albumID}
+
+	if !bindJSON(c, &newSong) {
+		return
+	}
+
+	if newSong.AlbumID != albumID {
+		rejectInvalid(c, []model.FieldError{{Field: "album_id", Message: "must match the album in the path"}})
+		return
+	}
+
+	h.createSong(c, newSong, parentNotFound("Album"))
+}
+
+// createSong creates the song, answering parentMissing when its album
+// doesn't exist or is archived.
+func (h *SongHandler) createSong(c *gin.Context, newSong model.CreateSong, parentMissing error) {
 	songCreated, err := h.songRepo.CreateSong(c.Request.Context(), newSong)
 
 	if err != nil {
-		log.Printf("Error creating song %v", err)
+		if errors.Is(err, repository.ErrParentNotFound) {
+			err = parentMissing
+		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Song")
 		return
 	}
 
 	newUrl := "Location: /songs/" + strconv.Itoa(songCreated.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(songCreated.Version))
 	c.JSON(http.StatusCreated, songCreated)
 }
 
+// BulkSongs creates and replaces songs in one request.
+func (h *SongHandler) BulkSongs(c *gin.Context) {
+	writeBulk(c, "Song", invalidFieldsProblem([]model.FieldError{{Field: "album_id", Message: "must be an album that exists and isn't archived"}}), h.songRepo.BulkSongs)
+}
+
 func (h *SongHandler) GetSong(c *gin.Context) {
 	id := c.Param("id")
 	song, err := h.songRepo.GetSong(c.Request.Context(), id)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
-			return
-		}
-
-		log.Printf("Error fetching song %s: %v", id, err)
+		abortError(c, err, "Song")
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	if notModified(c, song.Version) {
 		return
 	}
 
@@ -81,73 +139,90 @@ func (h *SongHandler) UpdateSong(c *gin.Context) {
 	id := c.Param("id")
 	var newSong model.UpdateSong
 
-	err := c.BindJSON(&newSong)
-	if err != nil {
+	if !bindJSON(c, &newSong) {
 		return
 	}
 
-	updatedSong, err := h.songRepo.UpdateSong(c.Request.Context(), newSong, id)
+	updatedSong, err := h.songRepo.UpdateSong(c.Request.Context(), newSong, id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
-			return
-		}
-
-		log.Printf("Error updating song %v", err)
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Song")
 		return
 	}
 
 	newUrl := "Location: /songs/" + strconv.Itoa(updatedSong.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(updatedSong.Version))
 	c.JSON(http.StatusCreated, updatedSong)
 }
 
 func (h *SongHandler) PatchSong(c *gin.Context) {
+	if isDocumentPatch(c) {
+		h.changeSong(c)
+		return
+	}
+
 	id := c.Param("id")
 	var newSong model.PatchSong
 
-	err := c.BindJSON(&newSong)
-	if err != nil {
+	if !bindJSON(c, &newSong) {
 		return
 	}
 
-	patchedSong, err := h.songRepo.PatchSong(c.Request.Context(), newSong, id)
+	patchedSong, err := h.songRepo.PatchSong(c.Request.Context(), newSong, id, parseIfMatch(c))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
-			return
-		}
-
-		log.Printf("Error patching song %v", err)
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		abortError(c, err, "Song")
 		return
 	}
 
 	newUrl := "Location: /songs/" + strconv.Itoa(patchedSong.ID)
 	c.Header("location", newUrl)
+	c.Header("ETag", etag(patchedSong.Version))
 	c.JSON(http.StatusCreated, patchedSong)
 }
 
-func (h *SongHandler) DeleteSong(c *gin.Context) {
+// changeSong applies a merge patch or JSON Patch to the song document.
+func (h *SongHandler) changeSong(c *gin.Context) {
 	id := c.Param("id")
-	err := h.songRepo.DeleteSong(c.Request.Context(), id)
+
+	patch, ok := readDocumentPatch(c)
+	if !ok {
+		return
+	}
+
+	changedSong, err := h.songRepo.ChangeSong(c.Request.Context(), id, parseIfMatch(c), songChange(patch))
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
-			return
-		}
+		abortError(c, err, "Song")
+		return
+	}
 
-		log.Printf("Error deleting song %s: %v", id, err)
+	c.Header("Location", "/songs/"+strconv.Itoa(changedSong.ID))
+	c.Header("ETag", etag(changedSong.Version))
+	c.JSON(http.StatusOK, changedSong)
+}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+func (h *SongHandler) DeleteSong(c *gin.Context) {
+	id := c.Param("id")
+	err := h.songRepo.DeleteSong(c.Request.Context(), id, parseIfMatch(c))
+
+	if err != nil {
+		abortError(c, err, "Song")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+func (h *SongHandler) RestoreSong(c *gin.Context) {
+	id := c.Param("id")
+	restoredSong, err := h.songRepo.RestoreSong(c.Request.Context(), id)
+
+	if err != nil {
+		abortError(c, err, "Archived song")
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredSong)
+}
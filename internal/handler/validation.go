@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON decodes the request body into v and checks it against the rules
+// on its type. A body that isn't JSON of the right shape is answered with 400
+// Bad Request and one with invalid fields with 422. It reports whether v can
+// be used.
+func bindJSON(c *gin.Context, v any) bool {
+	err := c.ShouldBindJSON(v)
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		problem := newProblem(http.StatusBadRequest, problemValidation, "Invalid request body")
+		problem.Fields = []model.FieldError{{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)}}
+		abortError(c, problem, "")
+		return false
+	}
+
+	if err != nil {
+		abortError(c, newProblem(http.StatusBadRequest, problemValidation, "Invalid request body"), "")
+		return false
+	}
+
+	return !rejectInvalid(c, model.Validate(v))
+}
+
+// jsonType describes the JSON value that decodes into t.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice:
+		return "a list"
+	}
+
+	return "an object"
+}
+
+// rejectInvalid answers 422 Unprocessable Entity listing every invalid field
+// and reports true, or reports false when there are none.
+func rejectInvalid(c *gin.Context, errs []model.FieldError) bool {
+	if len(errs) == 0 {
+		return false
+	}
+
+	abortError(c, invalidFieldsProblem(errs), "")
+	return true
+}
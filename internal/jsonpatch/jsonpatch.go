@@ -0,0 +1,420 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch
+// (RFC 7396) documents to JSON values.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid is returned for a patch document that is malformed.
+	ErrInvalid = errors.New("invalid patch")
+	// ErrConflict is returned when a well-formed patch cannot be applied to
+	// the document, including when a test operation fails.
+	ErrConflict = errors.New("patch does not apply")
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	Op    string
+	Path  []string
+	From  []string
+	Value any
+}
+
+// Patch is a decoded JSON Patch document.
+type Patch []Operation
+
+// Decode parses and checks a JSON Patch document.
+func Decode(data []byte) (Patch, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	patch := make(Patch, 0, len(raw))
+
+	for i, member := range raw {
+		op, err := decodeOperation(member)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalid, i, err)
+		}
+
+		patch = append(patch, op)
+	}
+
+	return patch, nil
+}
+
+func decodeOperation(member map[string]json.RawMessage) (Operation, error) {
+	var op Operation
+
+	var name, path string
+	if err := decodeString(member, "op", &name); err != nil {
+		return op, err
+	}
+	if err := decodeString(member, "path", &path); err != nil {
+		return op, err
+	}
+
+	op.Op = name
+
+	var err error
+	if op.Path, err = parsePointer(path); err != nil {
+		return op, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		raw, ok := member["value"]
+		if !ok {
+			return op, errors.New(`missing "value"`)
+		}
+
+		if op.Value, err = decodeValue(raw); err != nil {
+			return op, err
+		}
+	case "move", "copy":
+		var from string
+		if err := decodeString(member, "from", &from); err != nil {
+			return op, err
+		}
+
+		if op.From, err = parsePointer(from); err != nil {
+			return op, err
+		}
+
+		if name == "move" && len(op.From) < len(op.Path) && slices.Equal(op.From, op.Path[:len(op.From)]) {
+			return op, errors.New("cannot move a value into itself")
+		}
+	case "remove":
+	default:
+		return op, fmt.Errorf("unknown op %q", name)
+	}
+
+	if name == "remove" && len(op.Path) == 0 {
+		return op, errors.New("cannot remove the whole document")
+	}
+
+	return op, nil
+}
+
+func decodeString(member map[string]json.RawMessage, key string, s *string) error {
+	raw, ok := member[key]
+	if !ok {
+		return fmt.Errorf("missing %q", key)
+	}
+
+	if err := json.Unmarshal(raw, s); err != nil {
+		return fmt.Errorf("%q must be a string", key)
+	}
+
+	return nil
+}
+
+// decodeValue decodes JSON keeping numbers as json.Number, so integers
+// survive a patch unchanged.
+func decodeValue(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// Apply applies the operations to doc in order and returns the result. The
+// patch fails as a whole when any one operation does.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	v, err := decodeValue(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range p {
+		if v, err = op.apply(v); err != nil {
+			return nil, fmt.Errorf("%w: %s %s: %v", ErrConflict, op.Op, pointer(op.Path), err)
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+func pointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+
+	return b.String()
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, deepCopy(op.Value))
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(op.Value))
+	case "move":
+		doc, value, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(value))
+	case "test":
+		value, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.Value) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = value
+		case []any:
+			i, err := index(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("cannot index into a scalar with %q", token)
+		}
+	}
+
+	return doc, nil
+}
+
+// update replaces the value at the parent of path with what fn makes of it,
+// given the last token of path.
+func update(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	token := path[0]
+
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", token)
+		}
+
+		child, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+
+		return node, nil
+	case []any:
+		i, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := update(node[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("cannot index into a scalar with %q", token)
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			if token == "-" {
+				return append(node, value), nil
+			}
+
+			i, err := index(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+
+			return node, nil
+		}
+
+		return nil, fmt.Errorf("cannot add %q to a scalar", token)
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed any
+
+	doc, err := update(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+
+			removed = value
+			delete(node, token)
+
+			return node, nil
+		case []any:
+			i, err := index(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = node[i]
+
+			return append(node[:i], node[i+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("cannot remove %q from a scalar", token)
+	})
+
+	return doc, removed, err
+}
+
+// index parses an array index token, which may be at most max.
+func index(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+
+	return i, nil
+}
+
+// equal reports whether two decoded values are equal as a test operation
+// compares them: numbers by their value, so 1.0 equals 1, arrays element by
+// element and objects member by member.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		x, okX := new(big.Rat).SetString(string(a))
+		y, okY := new(big.Rat).SetString(string(b))
+		return okX && okY && x.Cmp(y) == 0
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for k, child := range a {
+			other, ok := b[k]
+			if !ok || !equal(child, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func deepCopy(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(node))
+		for k, child := range node {
+			c[k] = deepCopy(child)
+		}
+		return c
+	case []any:
+		c := make([]any, len(node))
+		for i, child := range node {
+			c[i] = deepCopy(child)
+		}
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,133 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// equalJSON reports whether a and b encode the same value.
+func equalJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var x, y any
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("decoding %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("decoding %s: %v", b, err)
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+func TestPatchApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add to array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"append to array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"remove", `{"a":1,"b":2}`, `[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{"replace", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"move within array", `{"a":[1,2,3]}`, `[{"op":"move","from":"/a/0","path":"/a/2"}]`, `{"a":[2,3,1]}`},
+		{"move between arrays", `{"a":[1,2],"b":[]}`, `[{"op":"move","from":"/a/1","path":"/b/0"}]`, `{"a":[1],"b":[2]}`},
+		{"copy", `{"a":{"x":1}}`, `[{"op":"copy","from":"/a","path":"/b"}]`, `{"a":{"x":1},"b":{"x":1}}`},
+		{"test", `{"a":[1,"x"]}`, `[{"op":"test","path":"/a","value":[1,"x"]}]`, `{"a":[1,"x"]}`},
+		{"test numbers by value", `{"a":{"n":1,"m":[250]}}`, `[{"op":"test","path":"/a","value":{"m":[2.5e2],"n":1.0}}]`, `{"a":{"n":1,"m":[250]}}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := Decode([]byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := patch.Apply([]byte(test.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalJSON(t, got, []byte(test.want)) {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPatchConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"missing member", `[{"op":"remove","path":"/b"}]`},
+		{"index out of range", `[{"op":"add","path":"/a/5","value":1}]`},
+		{"failed test", `[{"op":"test","path":"/a/0","value":2}]`},
+		{"failed test of a number as a string", `[{"op":"test","path":"/a/0","value":"1"}]`},
+		{"failed test of an array", `[{"op":"test","path":"/a","value":[1,1]}]`},
+		{"later operation", `[{"op":"replace","path":"/a/0","value":2},{"op":"remove","path":"/c"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := Decode([]byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := patch.Apply([]byte(`{"a":[1]}`)); !errors.Is(err, ErrConflict) {
+				t.Errorf("got error %v, want ErrConflict", err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsMalformedPatches(t *testing.T) {
+	for _, patch := range []string{
+		`{"op":"add","path":"/a","value":1}`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"move","path":"/a"}]`,
+		`[{"op":"add","path":"a","value":1}]`,
+		`[{"path":"/a"}]`,
+	} {
+		if _, err := Decode([]byte(patch)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Decode(%s): got error %v, want ErrInvalid", patch, err)
+		}
+	}
+}
+
+func TestMergePatchApply(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`["a"]`, `{"a":{"b":null}}`, `{"a":{}}`},
+	}
+
+	for _, test := range tests {
+		patch, err := DecodeMerge([]byte(test.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := patch.Apply([]byte(test.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalJSON(t, got, []byte(test.want)) {
+			t.Errorf("merging %s into %s: got %s, want %s", test.patch, test.doc, got, test.want)
+		}
+	}
+}
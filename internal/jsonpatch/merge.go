@@ -0,0 +1,55 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch is a decoded JSON Merge Patch document.
+type MergePatch struct {
+	value any
+}
+
+// DecodeMerge parses a JSON Merge Patch document.
+func DecodeMerge(data []byte) (MergePatch, error) {
+	v, err := decodeValue(data)
+	if err != nil {
+		return MergePatch{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	return MergePatch{value: v}, nil
+}
+
+// Apply merges the patch into doc: members set to null are removed, objects
+// are merged recursively and anything else replaces the target outright.
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	v, err := decodeValue(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(v, p.value))
+}
+
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	node, ok := target.(map[string]any)
+	if !ok {
+		node = map[string]any{}
+	}
+
+	for key, value := range members {
+		if value == nil {
+			delete(node, key)
+			continue
+		}
+
+		node[key] = merge(node[key], deepCopy(value))
+	}
+
+	return node
+}
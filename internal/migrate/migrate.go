@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// advisoryLockID is the pg_advisory_lock key held while migrating, so several
+// instances starting at once apply each migration exactly once.
+const advisoryLockID = 8_311_972_004
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type Migrator struct {
+	dbPool     *pgxpool.Pool
+	migrations []Migration
+}
+
+func NewMigrator(dbPool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		dbPool:     dbPool,
+		migrations: migrations,
+	}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: unexpected file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d has files named %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := []Migration{}
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d has no up migration", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory
+// lock, after making sure the schema_migrations table exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.dbPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockID)
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+				version bigint PRIMARY KEY,
+				name text NOT NULL,
+				applied_at timestamptz NOT NULL DEFAULT now()
+			)`
+	_, err = conn.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn.Conn())
+}
+
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the migrations that were applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	ran := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: applying %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			ran = append(ran, migration)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// Down reverts the most recently applied migrations, newest first, up to
+// steps of them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	ran := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migrate: %d_%s cannot be reverted", migration.Version, migration.Name)
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: reverting %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			ran = append(ran, migration)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := []MigrationStatus{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
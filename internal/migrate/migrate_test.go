@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/liamcoleman/music-go/internal/pgtest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_index.up.sql":        {Data: []byte("CREATE INDEX")},
+		"migrations/0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE")},
+		"migrations/0001_initial_schema.down.sql": {Data: []byte("DROP TABLE")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "initial_schema", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX"},
+	}
+
+	if len(migrations) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d: got %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"unexpected name", []string{"migrations/0001_initial.sql"}, "unexpected file name"},
+		{"mismatched names", []string{"migrations/0001_initial.up.sql", "migrations/0001_other.down.sql"}, "has files named"},
+		{"no up migration", []string{"migrations/0001_initial.down.sql"}, "has no up migration"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range test.files {
+				fsys[file] = &fstest.MapFile{Data: []byte("SELECT 1")}
+			}
+
+			_, err := loadMigrations(fsys)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s is version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	dbPool := pgtest.Pool(t)
+
+	migrator, err := NewMigrator(dbPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := len(migrator.migrations)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != count {
+		t.Fatalf("got %d migrations applied, want all %d", len(applied), count)
+	}
+
+	if again, err := migrator.Up(ctx); err != nil || len(again) != 0 {
+		t.Errorf("got %d, %v migrating up again, want nothing to do", len(again), err)
+	}
+
+	reverted, err := migrator.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 2 || reverted[0].Version != int64(count) || reverted[1].Version != int64(count-1) {
+		t.Fatalf("got %+v reverted, want the last two migrations, newest first", reverted)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, status := range statuses {
+		if want := i < count-2; (status.AppliedAt != nil) != want {
+			t.Errorf("migration %d_%s: got applied %v, want %v", status.Version, status.Name, status.AppliedAt != nil, want)
+		}
+	}
+
+	// Every down migration undoes its up migration, so the whole schema can
+	// be taken down and built again.
+	if reverted, err := migrator.Down(ctx, count); err != nil || len(reverted) != count-2 {
+		t.Fatalf("got %d, %v reverting the rest, want %d", len(reverted), err, count-2)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != count {
+		t.Fatalf("got %d, %v migrating up again, want all %d", len(applied), err, count)
+	}
+}
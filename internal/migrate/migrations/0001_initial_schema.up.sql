@@ -0,0 +1,139 @@
+CREATE TABLE IF NOT EXISTS artist (
+    archived boolean NOT NULL,
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    description character varying(255),
+    name character varying(255),
+    CONSTRAINT artist_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS album (
+    archived boolean NOT NULL,
+    release_year integer NOT NULL,
+    artist_id bigint NOT NULL,
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    name character varying(255),
+    CONSTRAINT album_pkey PRIMARY KEY (id),
+    CONSTRAINT fkmwc4fyyxb6tfi0qba26gcf8s1 FOREIGN KEY (artist_id) REFERENCES artist(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS song (
+    archived boolean NOT NULL,
+    duration_seconds integer NOT NULL,
+    track_number integer NOT NULL,
+    album_id bigint NOT NULL,
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    title character varying(255) NOT NULL,
+    CONSTRAINT song_pkey PRIMARY KEY (id),
+    CONSTRAINT fkrcjmk41yqj3pl3iyii40niab0 FOREIGN KEY (album_id) REFERENCES album(id) ON DELETE CASCADE
+);
+
+-- Sample catalogue previously loaded from music_db.dump. Databases that were
+-- bootstrapped from the dump already have rows and are left untouched.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM artist) THEN
+        INSERT INTO artist (id, name, description, archived) VALUES
+            (1, 'King Gizzard & the Lizard Wizard', 'Australian psychedelic rock band formed in 2010 in Melbourne, Victoria, known for their prolific output and genre-hopping style.', FALSE),
+            (2, 'Minus The Bear', 'American indie rock band formed in Seattle, Washington in 2001, known for their intricate guitar work and math rock influences.', FALSE),
+            (3, 'System Of A Down', 'Armenian-American heavy metal band formed in Glendale, California, in 1994.', FALSE),
+            (4, 'Kraftwerk', 'German electronic band formed in Düsseldorf in 1970 by Ralf Hütter and Florian Schneider.', FALSE);
+
+        INSERT INTO album (id, artist_id, name, release_year, archived) VALUES
+            (1, 1, 'Nonagon Infinity', 2016, FALSE),
+            (2, 1, 'Infest The Rats'' Nest', 2019, FALSE),
+            (3, 2, 'Highly Refined Pirates', 2002, FALSE),
+            (4, 2, 'Menos el Oso', 2005, FALSE),
+            (5, 3, 'Toxicity', 2001, FALSE),
+            (6, 3, 'Mezmerize', 2005, FALSE),
+            (7, 4, 'Autobahn', 1974, FALSE),
+            (8, 4, 'Trans Europa Express', 1977, FALSE);
+
+        INSERT INTO song (id, album_id, title, track_number, duration_seconds, archived) VALUES
+            (1, 1, 'Robot Stop', 1, 322, FALSE),
+            (2, 1, 'Big Fig Wasp', 2, 208, FALSE),
+            (3, 1, 'Gamma Knife', 3, 238, FALSE),
+            (4, 1, 'People-Vultures', 4, 186, FALSE),
+            (5, 1, 'Mr. Beat', 5, 247, FALSE),
+            (6, 1, 'Evil Death Roll', 6, 437, FALSE),
+            (7, 1, 'Invisible Face', 7, 226, FALSE),
+            (8, 1, 'Wah Wah', 8, 257, FALSE),
+            (9, 1, 'Road Train', 9, 441, FALSE),
+            (10, 2, 'Planet B', 1, 210, FALSE),
+            (11, 2, 'Mars For The Rich', 2, 268, FALSE),
+            (12, 2, 'Organ Farmer', 3, 275, FALSE),
+            (13, 2, 'Superbug', 4, 264, FALSE),
+            (14, 2, 'Venusian 1', 5, 199, FALSE),
+            (15, 2, 'Perihelion', 6, 309, FALSE),
+            (16, 2, 'Venusian 2', 7, 209, FALSE),
+            (17, 2, 'Self-Immolate', 8, 260, FALSE),
+            (18, 2, 'Hell', 9, 375, FALSE),
+            (19, 3, 'Thanks For The Killer Game Of Crisco Twister', 1, 215, FALSE),
+            (20, 3, 'Monkey!!! Knife!!! Fight!!!', 2, 212, FALSE),
+            (21, 3, 'Absinthe Party At The Fly Honey Warehouse', 3, 324, FALSE),
+            (22, 3, 'Hey, Wanna Throw Up?', 4, 34, FALSE),
+            (23, 3, 'Get Me Naked 2: Electric Boogaloo', 5, 249, FALSE),
+            (24, 3, 'We Are Not A Football Team', 6, 182, FALSE),
+            (25, 3, 'You Kill Bugs Good, Man', 7, 70, FALSE),
+            (26, 3, 'Spritz!!! Spritz!!!', 8, 183, FALSE),
+            (27, 3, 'Women We Haven''t Met Yet', 9, 244, FALSE),
+            (28, 3, 'Damn Bugs Whacked Him, Johnny', 10, 47, FALSE),
+            (29, 3, 'I Lost All My Money At The Cock Fights', 11, 294, FALSE),
+            (30, 3, 'Andy Wolff', 12, 129, FALSE),
+            (31, 3, 'Let''s Play Guitar In A Five Guitar Band', 13, 312, FALSE),
+            (32, 3, 'Booyah Achieved', 14, 50, FALSE),
+            (33, 4, 'The Game Needed Me', 1, 195, FALSE),
+            (34, 4, 'Memphis & 53rd', 2, 221, FALSE),
+            (35, 4, 'Drilling', 3, 303, FALSE),
+            (36, 4, 'The Fix', 4, 212, FALSE),
+            (37, 4, 'El Torrente', 5, 296, FALSE),
+            (38, 4, 'Pachuca Sunrise', 6, 215, FALSE),
+            (39, 4, 'Michio''s Death Drive', 7, 216, FALSE),
+            (40, 4, 'Hooray', 8, 257, FALSE),
+            (41, 4, 'Fulfill the Dream', 9, 204, FALSE),
+            (42, 4, 'The Pig War', 10, 278, FALSE),
+            (43, 4, 'This Ain''t A Surfin'' Movie', 11, 292, FALSE),
+            (44, 5, 'Prison Song', 1, 201, FALSE),
+            (45, 5, 'Needles', 2, 193, FALSE),
+            (46, 5, 'Deer Dance', 3, 175, FALSE),
+            (47, 5, 'Jet Pilot', 4, 126, FALSE),
+            (48, 5, 'X', 5, 118, FALSE),
+            (49, 5, 'Chop Suey!', 6, 210, FALSE),
+            (50, 5, 'Bounce', 7, 114, FALSE),
+            (51, 5, 'Forest', 8, 240, FALSE),
+            (52, 5, 'ATWA', 9, 176, FALSE),
+            (53, 5, 'Science', 10, 163, FALSE),
+            (54, 5, 'Shimmy', 11, 111, FALSE),
+            (55, 5, 'Toxicity', 12, 218, FALSE),
+            (56, 5, 'Psycho', 13, 225, FALSE),
+            (57, 5, 'Aerials', 14, 234, FALSE),
+            (58, 6, 'Soldier Side - Intro', 1, 63, FALSE),
+            (59, 6, 'B.Y.O.B.', 2, 255, FALSE),
+            (60, 6, 'Revenga', 3, 230, FALSE),
+            (61, 6, 'Cigaro', 4, 131, FALSE),
+            (62, 6, 'Radio/Video', 5, 249, FALSE),
+            (63, 6, 'This Cocaine Makes Me Feel Like I''m on This Song', 6, 128, FALSE),
+            (64, 6, 'Violent Pornography', 7, 211, FALSE),
+            (65, 6, 'Question!', 8, 200, FALSE),
+            (66, 6, 'Sad Statue', 9, 205, FALSE),
+            (67, 6, 'Old School Hollywood', 10, 176, FALSE),
+            (68, 6, 'Lost in Hollywood', 11, 320, FALSE),
+            (69, 7, 'Autobahn', 1, 1362, FALSE),
+            (70, 7, 'Kometenmelodie 1', 2, 380, FALSE),
+            (71, 7, 'Kometenmelodie 2', 3, 345, FALSE),
+            (72, 7, 'Mitternacht', 4, 220, FALSE),
+            (73, 7, 'Morgenspaziergang', 5, 240, FALSE),
+            (74, 8, 'Europe Endless', 1, 581, FALSE),
+            (75, 8, 'The Hall of Mirrors', 2, 476, FALSE),
+            (76, 8, 'Showroom Dummies', 3, 377, FALSE),
+            (77, 8, 'Trans-Europe Express', 4, 396, FALSE),
+            (78, 8, 'Metal on Metal', 5, 130, FALSE),
+            (79, 8, 'Abzug', 6, 293, FALSE),
+            (80, 8, 'Franz Schubert', 7, 266, FALSE),
+            (81, 8, 'Endless Endless', 8, 47, FALSE);
+
+        PERFORM setval(pg_get_serial_sequence('artist', 'id'), (SELECT COALESCE(MAX(id), 1) FROM artist));
+        PERFORM setval(pg_get_serial_sequence('album', 'id'), (SELECT COALESCE(MAX(id), 1) FROM album));
+        PERFORM setval(pg_get_serial_sequence('song', 'id'), (SELECT COALESCE(MAX(id), 1) FROM song));
+    END IF;
+END
+$$;
@@ -0,0 +1,3 @@
+ALTER TABLE song DROP COLUMN archived_at;
+ALTER TABLE album DROP COLUMN archived_at;
+ALTER TABLE artist DROP COLUMN archived_at;
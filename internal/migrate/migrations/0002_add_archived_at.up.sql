@@ -0,0 +1,8 @@
+ALTER TABLE artist ADD COLUMN archived_at timestamptz;
+ALTER TABLE album ADD COLUMN archived_at timestamptz;
+ALTER TABLE song ADD COLUMN archived_at timestamptz;
+
+-- Rows archived before this column existed start their retention window now.
+UPDATE artist SET archived_at = now() WHERE archived = TRUE;
+UPDATE album SET archived_at = now() WHERE archived = TRUE;
+UPDATE song SET archived_at = now() WHERE archived = TRUE;
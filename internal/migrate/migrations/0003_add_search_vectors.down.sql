@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS song_search_vector_idx;
+DROP INDEX IF EXISTS album_search_vector_idx;
+DROP INDEX IF EXISTS artist_search_vector_idx;
+
+ALTER TABLE song DROP COLUMN search_vector;
+ALTER TABLE album DROP COLUMN search_vector;
+ALTER TABLE artist DROP COLUMN search_vector;
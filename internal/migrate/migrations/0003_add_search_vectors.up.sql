@@ -0,0 +1,16 @@
+ALTER TABLE artist ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+ALTER TABLE album ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A')
+) STORED;
+
+ALTER TABLE song ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A')
+) STORED;
+
+CREATE INDEX artist_search_vector_idx ON artist USING GIN (search_vector);
+CREATE INDEX album_search_vector_idx ON album USING GIN (search_vector);
+CREATE INDEX song_search_vector_idx ON song USING GIN (search_vector);
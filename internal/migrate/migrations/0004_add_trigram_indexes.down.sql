@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS album_name_trgm_idx;
+DROP INDEX IF EXISTS artist_name_trgm_idx;
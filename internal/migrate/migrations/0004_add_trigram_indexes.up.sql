@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX artist_name_trgm_idx ON artist USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX album_name_trgm_idx ON album USING GIN (lower(name) gin_trgm_ops);
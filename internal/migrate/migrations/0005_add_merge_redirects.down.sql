@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS album_redirect;
+DROP TABLE IF EXISTS artist_redirect;
@@ -0,0 +1,20 @@
+-- Ids of artists and albums merged into another record. The old id has no
+-- foreign key so the redirect outlives the archived row being purged.
+CREATE TABLE artist_redirect (
+    artist_id bigint NOT NULL,
+    target_id bigint NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT artist_redirect_pkey PRIMARY KEY (artist_id),
+    CONSTRAINT artist_redirect_target_id_fkey FOREIGN KEY (target_id) REFERENCES artist(id) ON DELETE CASCADE
+);
+
+CREATE TABLE album_redirect (
+    album_id bigint NOT NULL,
+    target_id bigint NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT album_redirect_pkey PRIMARY KEY (album_id),
+    CONSTRAINT album_redirect_target_id_fkey FOREIGN KEY (target_id) REFERENCES album(id) ON DELETE CASCADE
+);
+
+CREATE INDEX artist_redirect_target_id_idx ON artist_redirect (target_id);
+CREATE INDEX album_redirect_target_id_idx ON album_redirect (target_id);
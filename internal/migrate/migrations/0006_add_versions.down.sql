@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS song_bump_version ON song;
+DROP TRIGGER IF EXISTS album_bump_version ON album;
+DROP TRIGGER IF EXISTS artist_bump_version ON artist;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE song DROP COLUMN version;
+ALTER TABLE album DROP COLUMN version;
+ALTER TABLE artist DROP COLUMN version;
@@ -0,0 +1,16 @@
+ALTER TABLE artist ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE album ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE song ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+-- Every update, including cascaded archives and merges, moves a row to a new
+-- version so ETags handed out before it stop matching.
+CREATE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER artist_bump_version BEFORE UPDATE ON artist FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER album_bump_version BEFORE UPDATE ON album FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER song_bump_version BEFORE UPDATE ON song FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS song_record_history ON song;
+DROP TRIGGER IF EXISTS album_record_history ON album;
+DROP TRIGGER IF EXISTS artist_record_history ON artist;
+DROP FUNCTION IF EXISTS record_history();
+
+DROP TABLE IF EXISTS history;
@@ -0,0 +1,73 @@
+-- Append-only change log for the catalogue. Rows are written by triggers in
+-- the same transaction as the change, tagged with the actor and, for reverts,
+-- the action the application sets with set_config.
+CREATE TABLE history (
+    id bigint GENERATED ALWAYS AS IDENTITY,
+    entity text NOT NULL,
+    entity_id bigint NOT NULL,
+    action text NOT NULL,
+    actor text NOT NULL,
+    before jsonb,
+    after jsonb,
+    changed_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT history_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX history_entity_idx ON history (entity, entity_id, id);
+
+CREATE FUNCTION record_history() RETURNS trigger AS $$
+DECLARE
+    old_row jsonb;
+    new_row jsonb;
+    before_diff jsonb;
+    after_diff jsonb;
+    change text := NULLIF(current_setting('music.action', true), '');
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - 'version' - 'search_vector';
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - 'version' - 'search_vector';
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        change := COALESCE(change, 'create');
+        after_diff := new_row;
+    ELSIF TG_OP = 'DELETE' THEN
+        change := 'purge';
+        before_diff := old_row;
+    ELSE
+        SELECT jsonb_object_agg(key, value) INTO before_diff
+        FROM jsonb_each(old_row) WHERE new_row -> key IS DISTINCT FROM value;
+
+        SELECT jsonb_object_agg(key, value) INTO after_diff
+        FROM jsonb_each(new_row) WHERE old_row -> key IS DISTINCT FROM value;
+
+        IF after_diff IS NULL THEN
+            RETURN NULL;
+        END IF;
+
+        change := COALESCE(change, CASE
+            WHEN NOT OLD.archived AND NEW.archived THEN 'delete'
+            WHEN OLD.archived AND NOT NEW.archived THEN 'restore'
+            ELSE 'update'
+        END);
+    END IF;
+
+    INSERT INTO history (entity, entity_id, action, actor, before, after)
+    VALUES (
+        TG_TABLE_NAME,
+        (COALESCE(new_row, old_row) ->> 'id')::bigint,
+        change,
+        COALESCE(NULLIF(current_setting('music.actor', true), ''), current_user),
+        before_diff,
+        after_diff
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER artist_record_history AFTER INSERT OR UPDATE OR DELETE ON artist FOR EACH ROW EXECUTE FUNCTION record_history();
+CREATE TRIGGER album_record_history AFTER INSERT OR UPDATE OR DELETE ON album FOR EACH ROW EXECUTE FUNCTION record_history();
+CREATE TRIGGER song_record_history AFTER INSERT OR UPDATE OR DELETE ON song FOR EACH ROW EXECUTE FUNCTION record_history();
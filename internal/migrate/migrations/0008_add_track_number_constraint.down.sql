@@ -0,0 +1 @@
+ALTER TABLE song DROP CONSTRAINT IF EXISTS song_track_number_unique;
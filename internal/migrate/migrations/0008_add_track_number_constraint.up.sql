@@ -0,0 +1,23 @@
+-- Albums that already repeat a track number among their live songs are
+-- renumbered in track order, so the constraint below can hold.
+WITH repeated AS (
+    SELECT album_id
+    FROM song
+    WHERE archived = FALSE
+    GROUP BY album_id, track_number
+    HAVING count(*) > 1
+), renumbered AS (
+    SELECT id, row_number() OVER (PARTITION BY album_id ORDER BY track_number, id) AS track_number
+    FROM song
+    WHERE archived = FALSE AND album_id IN (SELECT album_id FROM repeated)
+)
+UPDATE song SET track_number = renumbered.track_number
+FROM renumbered
+WHERE song.id = renumbered.id AND song.track_number <> renumbered.track_number;
+
+-- The live songs of an album have distinct track numbers. It is an exclusion
+-- constraint rather than a unique index so that it can be deferred while a
+-- tracklist is renumbered one song at a time.
+ALTER TABLE song ADD CONSTRAINT song_track_number_unique
+    EXCLUDE USING btree (album_id WITH =, track_number WITH =) WHERE (NOT archived)
+    DEFERRABLE INITIALLY IMMEDIATE;
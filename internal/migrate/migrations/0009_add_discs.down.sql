@@ -0,0 +1,10 @@
+-- Fails if two discs of an album share a track number, which one sequence
+-- can't hold.
+ALTER TABLE song DROP CONSTRAINT IF EXISTS song_track_number_unique;
+ALTER TABLE song ADD CONSTRAINT song_track_number_unique
+    EXCLUDE USING btree (album_id WITH =, track_number WITH =) WHERE (NOT archived)
+    DEFERRABLE INITIALLY IMMEDIATE;
+
+ALTER TABLE song DROP CONSTRAINT IF EXISTS song_disc_number_check;
+ALTER TABLE song DROP COLUMN IF EXISTS disc_title;
+ALTER TABLE song DROP COLUMN IF EXISTS disc_number;
@@ -0,0 +1,11 @@
+-- Songs are on a disc of their album, numbered from 1, and tracks are
+-- numbered per disc. Existing songs are all on disc 1. A disc's optional
+-- title is kept on its songs.
+ALTER TABLE song ADD COLUMN disc_number integer NOT NULL DEFAULT 1;
+ALTER TABLE song ADD COLUMN disc_title character varying(255) NOT NULL DEFAULT '';
+ALTER TABLE song ADD CONSTRAINT song_disc_number_check CHECK (disc_number >= 1);
+
+ALTER TABLE song DROP CONSTRAINT song_track_number_unique;
+ALTER TABLE song ADD CONSTRAINT song_track_number_unique
+    EXCLUDE USING btree (album_id WITH =, disc_number WITH =, track_number WITH =) WHERE (NOT archived)
+    DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS album_artist_release_idx;
+
+ALTER TABLE album DROP CONSTRAINT IF EXISTS album_release_date_check;
+ALTER TABLE album DROP COLUMN IF EXISTS release_date;
+
+ALTER TABLE album DROP CONSTRAINT IF EXISTS album_release_type_check;
+ALTER TABLE album DROP COLUMN IF EXISTS release_type;
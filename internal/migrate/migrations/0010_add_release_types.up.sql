@@ -0,0 +1,15 @@
+-- An album has a type of release, and a release date known to the year,
+-- month or day: '1999', '1999-09' or '1999-09-21'. Dates in that form sort
+-- in release order, and release_year is kept as the date's year. Existing
+-- albums are LPs known to their year.
+ALTER TABLE album ADD COLUMN release_type character varying(16) NOT NULL DEFAULT 'lp';
+ALTER TABLE album ADD CONSTRAINT album_release_type_check
+    CHECK (release_type IN ('lp', 'ep', 'single', 'live', 'compilation', 'reissue'));
+
+ALTER TABLE album ADD COLUMN release_date character varying(10);
+UPDATE album SET release_date = lpad(release_year::text, 4, '0');
+ALTER TABLE album ALTER COLUMN release_date SET NOT NULL;
+ALTER TABLE album ADD CONSTRAINT album_release_date_check
+    CHECK (release_date ~ '^\d{4}(-\d{2}(-\d{2})?)?$' AND left(release_date, 4)::integer = release_year);
+
+CREATE INDEX album_artist_release_idx ON album (artist_id, release_date DESC) WHERE NOT archived;
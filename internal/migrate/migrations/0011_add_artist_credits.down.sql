@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_artist;
+DROP TABLE IF EXISTS album_artist;
@@ -0,0 +1,33 @@
+-- Albums and songs can credit artists besides the album's own: other
+-- primary artists of a collaboration or split release, featured artists and
+-- remixers. The album's artist is always credited first and isn't listed
+-- here; a song is credited to its album's artists and then its own. A
+-- credit's join phrase is shown before the artist's name, as in
+-- "Artist feat. Guest". Credits go with a purged artist, album or song.
+CREATE TABLE album_artist (
+    album_id bigint NOT NULL,
+    position integer NOT NULL,
+    artist_id bigint NOT NULL,
+    role character varying(16) NOT NULL,
+    join_phrase character varying(255) NOT NULL,
+    CONSTRAINT album_artist_pkey PRIMARY KEY (album_id, position),
+    CONSTRAINT album_artist_album_fkey FOREIGN KEY (album_id) REFERENCES album(id) ON DELETE CASCADE,
+    CONSTRAINT album_artist_artist_fkey FOREIGN KEY (artist_id) REFERENCES artist(id) ON DELETE CASCADE,
+    CONSTRAINT album_artist_role_check CHECK (role IN ('primary', 'featured', 'remixer'))
+);
+
+CREATE TABLE song_artist (
+    song_id bigint NOT NULL,
+    position integer NOT NULL,
+    artist_id bigint NOT NULL,
+    role character varying(16) NOT NULL,
+    join_phrase character varying(255) NOT NULL,
+    CONSTRAINT song_artist_pkey PRIMARY KEY (song_id, position),
+    CONSTRAINT song_artist_song_fkey FOREIGN KEY (song_id) REFERENCES song(id) ON DELETE CASCADE,
+    CONSTRAINT song_artist_artist_fkey FOREIGN KEY (artist_id) REFERENCES artist(id) ON DELETE CASCADE,
+    CONSTRAINT song_artist_role_check CHECK (role IN ('primary', 'featured', 'remixer'))
+);
+
+-- Appearances are looked up by artist.
+CREATE INDEX album_artist_artist_idx ON album_artist (artist_id);
+CREATE INDEX song_artist_artist_idx ON song_artist (artist_id);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS album_compilation_release_idx;
+DROP INDEX IF EXISTS song_artist_id_idx;
+
+ALTER TABLE song DROP CONSTRAINT IF EXISTS song_artist_id_fkey;
+ALTER TABLE song DROP COLUMN IF EXISTS artist_id;
+
+-- Compilations can't exist without an artist, so they go with their songs.
+DELETE FROM album WHERE artist_id IS NULL;
+
+ALTER TABLE album DROP CONSTRAINT IF EXISTS album_compilation_check;
+ALTER TABLE album ALTER COLUMN artist_id SET NOT NULL;
+ALTER TABLE album DROP COLUMN IF EXISTS compilation;
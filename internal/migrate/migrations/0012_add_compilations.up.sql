@@ -0,0 +1,15 @@
+-- Compilations gather songs by various artists, so they have no artist of
+-- their own. Any song can name its own artist, who takes the place of its
+-- album's artist and credits; on a compilation every song does. A song
+-- whose artist is purged falls back to its album.
+ALTER TABLE album ADD COLUMN compilation boolean NOT NULL DEFAULT FALSE;
+ALTER TABLE album ALTER COLUMN artist_id DROP NOT NULL;
+ALTER TABLE album ADD CONSTRAINT album_compilation_check CHECK ((artist_id IS NULL) = compilation);
+
+ALTER TABLE song ADD COLUMN artist_id bigint;
+ALTER TABLE song ADD CONSTRAINT song_artist_id_fkey FOREIGN KEY (artist_id) REFERENCES artist(id) ON DELETE SET NULL;
+
+CREATE INDEX song_artist_id_idx ON song (artist_id);
+
+-- The various artists listing.
+CREATE INDEX album_compilation_release_idx ON album (release_date DESC) WHERE compilation AND NOT archived;
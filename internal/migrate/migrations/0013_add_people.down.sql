@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS person_credit;
+DROP TABLE IF EXISTS person;
+
+DELETE FROM history WHERE entity = 'person';
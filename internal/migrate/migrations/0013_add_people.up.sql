@@ -0,0 +1,38 @@
+-- People are who wrote, produced, mixed or engineered an album or song, as
+-- in liner notes. They aren't artists: a credit names a person in one role
+-- on one album or one song. Like the catalogue, people are archived rather
+-- than deleted, versioned and recorded in the history. Credits go with a
+-- purged person, album or song.
+CREATE TABLE person (
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    name character varying(255) NOT NULL,
+    description character varying(255) NOT NULL DEFAULT '',
+    version integer NOT NULL DEFAULT 1,
+    archived boolean NOT NULL DEFAULT FALSE,
+    archived_at timestamptz,
+    CONSTRAINT person_pkey PRIMARY KEY (id)
+);
+
+CREATE TRIGGER person_bump_version BEFORE UPDATE ON person FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER person_record_history AFTER INSERT OR UPDATE OR DELETE ON person FOR EACH ROW EXECUTE FUNCTION record_history();
+
+CREATE TABLE person_credit (
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    person_id bigint NOT NULL,
+    album_id bigint,
+    song_id bigint,
+    role character varying(16) NOT NULL,
+    CONSTRAINT person_credit_pkey PRIMARY KEY (id),
+    CONSTRAINT person_credit_person_fkey FOREIGN KEY (person_id) REFERENCES person(id) ON DELETE CASCADE,
+    CONSTRAINT person_credit_album_fkey FOREIGN KEY (album_id) REFERENCES album(id) ON DELETE CASCADE,
+    CONSTRAINT person_credit_song_fkey FOREIGN KEY (song_id) REFERENCES song(id) ON DELETE CASCADE,
+    CONSTRAINT person_credit_target_check CHECK ((album_id IS NULL) <> (song_id IS NULL)),
+    CONSTRAINT person_credit_role_check CHECK (role IN ('writer', 'composer', 'lyricist', 'producer', 'mixer', 'engineer', 'mastering', 'arranger'))
+);
+
+-- A person holds each role once per album or song.
+CREATE UNIQUE INDEX person_credit_album_key ON person_credit (album_id, person_id, role) WHERE album_id IS NOT NULL;
+CREATE UNIQUE INDEX person_credit_song_key ON person_credit (song_id, person_id, role) WHERE song_id IS NOT NULL;
+
+-- Discographies are looked up by person.
+CREATE INDEX person_credit_person_idx ON person_credit (person_id);
@@ -1,35 +1,145 @@
 package model
 
+import "encoding/json"
+
 type Album struct {
-	ID          int    `json:"id"`
-	ArtistName  string `json:"artist,omitempty"`
-	Name        string `json:"name"`
-	ReleaseYear int    `json:"release_year"`
+	ID          int         `json:"id"`
+	ArtistName  string      `json:"artist,omitempty"`
+	Name        string      `json:"name"`
+	ReleaseYear int         `json:"release_year"`
+	ReleaseDate ReleaseDate `json:"release_date"`
+	ReleaseType ReleaseType `json:"release_type"`
+	Compilation bool        `json:"compilation"`
+	Credits
+	Version int `json:"-"`
 }
 
 type AlbumResponse struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	ReleaseYear int    `json:"release_year"`
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	ReleaseYear int         `json:"release_year"`
+	ReleaseDate ReleaseDate `json:"release_date"`
+	ReleaseType ReleaseType `json:"release_type"`
+	Compilation bool        `json:"compilation"`
+	Credits
+	Version int `json:"-"`
 }
 
+// CreateAlbum is a new album. It needs a release year or date; given both,
+// they must agree. Artists lists who it credits besides its own artist. A
+// compilation has no artist and its songs each have their own.
 type CreateAlbum struct {
-	ArtistID    int    `json:"artist_id"`
-	Name        string `json:"name"`
-	ReleaseYear int    `json:"release_year"`
+	ArtistID    int            `json:"artist_id" validate:"required_unless=Compilation true,excluded_if=Compilation true"`
+	Compilation bool           `json:"compilation"`
+	Name        string         `json:"name" validate:"required,notblank,max=255"`
+	ReleaseYear int            `json:"release_year" validate:"required_without=ReleaseDate,omitempty,min=1,notfuture,matchesdate"`
+	ReleaseDate ReleaseDate    `json:"release_date" validate:"omitempty,releasedate,notfuture"`
+	ReleaseType ReleaseType    `json:"release_type" validate:"omitempty,releasetype"`
+	Artists     []CreditArtist `json:"artists" validate:"unique=ArtistID,dive"`
 }
 
 type UpdateAlbum struct {
-	Name        string `json:"name"`
-	ReleaseYear int    `json:"release_year"`
+	Name        string         `json:"name" validate:"required,notblank,max=255"`
+	ReleaseYear int            `json:"release_year" validate:"required_without=ReleaseDate,omitempty,min=1,notfuture,matchesdate"`
+	ReleaseDate ReleaseDate    `json:"release_date" validate:"omitempty,releasedate,notfuture"`
+	ReleaseType ReleaseType    `json:"release_type" validate:"omitempty,releasetype"`
+	Artists     []CreditArtist `json:"artists" validate:"unique=ArtistID,dive"`
 }
 
+// PatchAlbum changes the fields of an album that are set. A new release date
+// sets the year with it. A new year alone replaces the date with the year,
+// unless the date is already in that year.
 type PatchAlbum struct {
-	Name        *string `json:"name"`
-	ReleaseYear *int    `json:"release_year"`
+	Name        *string         `json:"name" validate:"omitnil,notblank,max=255"`
+	ReleaseYear *int            `json:"release_year" validate:"omitnil,min=1,notfuture,matchesdate"`
+	ReleaseDate *ReleaseDate    `json:"release_date" validate:"omitnil,releasedate,notfuture"`
+	ReleaseType *ReleaseType    `json:"release_type" validate:"omitnil,releasetype"`
+	Artists     *[]CreditArtist `json:"artists" validate:"omitnil,unique=ArtistID,dive"`
+}
+
+type ArchivedAlbum struct {
+	ID            int `json:"id"`
+	SongsArchived int `json:"songs_archived"`
+}
+
+type RestoredAlbum struct {
+	ID            int `json:"id"`
+	SongsRestored int `json:"songs_restored"`
+}
+
+type MergeAlbum struct {
+	TargetID int `json:"target_id" validate:"required"`
+}
+
+type MergedAlbum struct {
+	ID               int `json:"id"`
+	TargetID         int `json:"target_id"`
+	SongsMoved       int `json:"songs_moved"`
+	TracksRenumbered int `json:"tracks_renumbered"`
 }
 
+// AlbumWithSongs is an album and its live songs, in disc and track order,
+// with the people the album credits. It is written as JSON with the songs
+// grouped by disc.
 type AlbumWithSongs struct {
 	Album
-	Songs []Song
+	Personnel []Personnel
+	Songs     []Song
+}
+
+func (a AlbumWithSongs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Album
+		Personnel []Personnel `json:"personnel,omitempty"`
+		Discs     []Disc      `json:"discs"`
+	}{a.Album, a.Personnel, Discs(a.Songs)})
+}
+
+// Disc is one disc of an album, with its songs in track order.
+type Disc struct {
+	DiscNumber int    `json:"disc_number"`
+	Title      string `json:"title,omitempty"`
+	Songs      []Song `json:"songs"`
+}
+
+// Discs groups songs that are in disc and track order by disc. A disc has
+// the first title any of its songs gives it.
+func Discs(songs []Song) []Disc {
+	discs := []Disc{}
+
+	for _, song := range songs {
+		if len(discs) == 0 || discs[len(discs)-1].DiscNumber != song.DiscNumber {
+			discs = append(discs, Disc{DiscNumber: song.DiscNumber, Songs: []Song{}})
+		}
+
+		disc := &discs[len(discs)-1]
+		if disc.Title == "" {
+			disc.Title = song.DiscTitle
+		}
+		disc.Songs = append(disc.Songs, song)
+	}
+
+	return discs
+}
+
+// AlbumChanges is a change to an album and its track list, made in one go.
+type AlbumChanges struct {
+	Album PatchAlbum
+	// Songs holds the changes to existing songs, by id.
+	Songs   map[int]PatchSong
+	Added   []CreateSong
+	Removed []int
+}
+
+// AlbumTree is an album to create together with its songs.
+type AlbumTree struct {
+	CreateAlbum
+	Songs []TreeSong `json:"songs" validate:"dive"`
+}
+
+// TreeAlbum is an album created along with its artist. It has the fields
+// of an album as for PUT, and its songs.
+type TreeAlbum struct {
+	UpdateAlbum
+	Songs []TreeSong `json:"songs" validate:"dive"`
 }
@@ -0,0 +1,31 @@
+package model
+
+import "testing"
+
+func TestDiscs(t *testing.T) {
+	discs := Discs([]Song{
+		{ID: 1, DiscNumber: 1, TrackNumber: 1},
+		{ID: 2, DiscNumber: 1, DiscTitle: "Left", TrackNumber: 2},
+		{ID: 3, DiscNumber: 2, DiscTitle: "Right", TrackNumber: 1},
+		{ID: 4, DiscNumber: 2, DiscTitle: "Other", TrackNumber: 2},
+	})
+
+	want := []struct {
+		number int
+		title  string
+		songs  int
+	}{{1, "Left", 2}, {2, "Right", 2}}
+
+	if len(discs) != len(want) {
+		t.Fatalf("got %d discs, want %d", len(discs), len(want))
+	}
+	for i, disc := range discs {
+		if disc.DiscNumber != want[i].number || disc.Title != want[i].title || len(disc.Songs) != want[i].songs {
+			t.Errorf("disc %d: got %d %q with %d songs, want %d %q with %d", i, disc.DiscNumber, disc.Title, len(disc.Songs), want[i].number, want[i].title, want[i].songs)
+		}
+	}
+
+	if discs := Discs(nil); discs == nil || len(discs) != 0 {
+		t.Errorf("got %v for no songs, want an empty list", discs)
+	}
+}
@@ -4,24 +4,62 @@ type Artist struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     int    `json:"-"`
 }
 
+// ArtistWithAlbums is an artist with their albums, latest release first,
+// and their appearances on other artists' albums, in the same order.
 type ArtistWithAlbums struct {
 	Artist
-	Albums []Album
+	Albums      []Album
+	Appearances []Appearance `json:"appearances"`
 }
 
 type CreateArtist struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string `json:"name" validate:"required,notblank,max=255"`
+	Description string `json:"description" validate:"max=255"`
 }
 
 type UpdateArtist struct {
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty" validate:"required,notblank,max=255"`
+	Description string `json:"description,omitempty" validate:"max=255"`
+}
+
+// ArchivedArtist counts what was archived with an artist: their albums, the
+// songs on them, and their other songs, on compilations and other artists'
+// albums.
+type ArchivedArtist struct {
+	ID                 int `json:"id"`
+	AlbumsArchived     int `json:"albums_archived"`
+	SongsArchived      int `json:"songs_archived"`
+	OtherSongsArchived int `json:"other_songs_archived"`
+}
+
+type RestoredArtist struct {
+	ID                 int `json:"id"`
+	AlbumsRestored     int `json:"albums_restored"`
+	SongsRestored      int `json:"songs_restored"`
+	OtherSongsRestored int `json:"other_songs_restored"`
+}
+
+type MergeArtist struct {
+	TargetID int `json:"target_id" validate:"required"`
+}
+
+type MergedArtist struct {
+	ID          int `json:"id"`
+	TargetID    int `json:"target_id"`
+	AlbumsMoved int `json:"albums_moved"`
 }
 
 type PatchArtist struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name        *string `json:"name,omitempty" validate:"omitnil,notblank,max=255"`
+	Description *string `json:"description,omitempty" validate:"omitnil,max=255"`
+}
+
+// ArtistTree is an artist to create together with its albums and their
+// songs.
+type ArtistTree struct {
+	CreateArtist
+	Albums []TreeAlbum `json:"albums" validate:"dive"`
 }
@@ -0,0 +1,17 @@
+package model
+
+// BulkItem is one record of a bulk write: one to create, or when ID is set,
+// the new state of an existing record.
+type BulkItem[C, U any] struct {
+	ID     int
+	Create C
+	Update U
+}
+
+// BulkResult is what became of one item of a bulk write. Err is set when the
+// item couldn't be written.
+type BulkResult struct {
+	ID      int
+	Created bool
+	Err     error
+}
@@ -0,0 +1,96 @@
+package model
+
+// ArtistRole is the part a credited artist had in a release.
+type ArtistRole string
+
+const (
+	RolePrimary  ArtistRole = "primary"
+	RoleFeatured ArtistRole = "featured"
+	RoleRemixer  ArtistRole = "remixer"
+)
+
+// ArtistRoles lists every role, as the role check constraints do. Credits
+// given none are primary.
+var ArtistRoles = []ArtistRole{RolePrimary, RoleFeatured, RoleRemixer}
+
+// joinPhrases are put before the name of an artist credited without a join
+// phrase of their own.
+var joinPhrases = map[ArtistRole]string{
+	RolePrimary:  " & ",
+	RoleFeatured: " feat. ",
+	RoleRemixer:  " remixed by ",
+}
+
+// ArtistCredit is one of the artists an album or song is credited to.
+// JoinPhrase is shown before the name and is empty for the first artist.
+type ArtistCredit struct {
+	ArtistID   int        `json:"artist_id"`
+	Name       string     `json:"name"`
+	Role       ArtistRole `json:"role"`
+	JoinPhrase string     `json:"join_phrase,omitempty"`
+}
+
+// VariousArtists is who a compilation is shown as by, having no artist of
+// its own.
+const VariousArtists = "Various Artists"
+
+// Credits are the artists an album or song is credited to, in order, and the
+// line they are shown as, such as "Artist feat. Guest". An album's own artist
+// comes first and a song's credits follow its album's, or its own artist's.
+type Credits struct {
+	Artists      []ArtistCredit `json:"artists"`
+	ArtistCredit string         `json:"artist_credit"`
+}
+
+// NewCredits returns the credits of the artists. The first artist's join
+// phrase isn't shown, and a compilation crediting nobody is shown as by
+// VariousArtists.
+func NewCredits(artists []ArtistCredit) Credits {
+	if len(artists) == 0 {
+		return Credits{Artists: []ArtistCredit{}, ArtistCredit: VariousArtists}
+	}
+
+	line := artists[0].Name
+	for _, artist := range artists[1:] {
+		line += artist.JoinPhrase + artist.Name
+	}
+
+	return Credits{Artists: artists, ArtistCredit: line}
+}
+
+// CreditArtist credits an artist on an album or song besides the album's own
+// artist.
+type CreditArtist struct {
+	ArtistID   int        `json:"artist_id" validate:"required"`
+	Role       ArtistRole `json:"role" validate:"omitempty,artistrole"`
+	JoinPhrase string     `json:"join_phrase" validate:"max=255"`
+}
+
+// Settled returns the credit with its role and join phrase filled in where
+// the request left them out.
+func (c CreditArtist) Settled() CreditArtist {
+	if c.Role == "" {
+		c.Role = RolePrimary
+	}
+	if c.JoinPhrase == "" {
+		c.JoinPhrase = joinPhrases[c.Role]
+	}
+
+	return c
+}
+
+// Appearance is an album of another artist that credits an artist, on the
+// album itself or on some of its songs. Roles are the artist's roles on the
+// album, and empty when only songs credit them.
+type Appearance struct {
+	Album
+	Roles []ArtistRole     `json:"roles"`
+	Songs []SongAppearance `json:"songs,omitempty"`
+}
+
+// SongAppearance is a song that credits an artist its album doesn't.
+type SongAppearance struct {
+	ID    int          `json:"id"`
+	Title string       `json:"title"`
+	Roles []ArtistRole `json:"roles"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type Revision struct {
+	ID        int            `json:"id"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor"`
+	Before    map[string]any `json:"before"`
+	After     map[string]any `json:"after"`
+	ChangedAt time.Time      `json:"changed_at"`
+}
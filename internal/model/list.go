@@ -0,0 +1,15 @@
+package model
+
+type ListParams struct {
+	Archived bool
+	Limit    int
+	Cursor   string
+	Filters  []Filter
+	Sort     []SortField
+}
+
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+type MatchParams struct {
+	Name      string
+	Threshold float64
+	Limit     int
+}
+
+type ArtistMatch struct {
+	Artist
+	Score float64 `json:"score"`
+}
+
+type AlbumMatch struct {
+	Album
+	Score float64 `json:"score"`
+}
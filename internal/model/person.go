@@ -0,0 +1,94 @@
+package model
+
+// Person is someone credited in the liner notes of albums and songs, such
+// as a songwriter or producer. People aren't artists and aren't credited
+// as performers.
+type Person struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int    `json:"-"`
+}
+
+type CreatePerson struct {
+	Name        string `json:"name" validate:"required,notblank,max=255"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type UpdatePerson struct {
+	Name        string `json:"name" validate:"required,notblank,max=255"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type PatchPerson struct {
+	Name        *string `json:"name,omitempty" validate:"omitnil,notblank,max=255"`
+	Description *string `json:"description,omitempty" validate:"omitnil,max=255"`
+}
+
+// PersonRole is the part a person had in making an album or song.
+type PersonRole string
+
+const (
+	RoleWriter    PersonRole = "writer"
+	RoleComposer  PersonRole = "composer"
+	RoleLyricist  PersonRole = "lyricist"
+	RoleProducer  PersonRole = "producer"
+	RoleMixer     PersonRole = "mixer"
+	RoleEngineer  PersonRole = "engineer"
+	RoleMastering PersonRole = "mastering"
+	RoleArranger  PersonRole = "arranger"
+)
+
+// PersonRoles lists every role in the order credits are shown in, as the
+// role check constraint does.
+var PersonRoles = []PersonRole{RoleWriter, RoleComposer, RoleLyricist, RoleProducer, RoleMixer, RoleEngineer, RoleMastering, RoleArranger}
+
+// PersonCredit credits a person in a role on either an album or a song.
+type PersonCredit struct {
+	ID       int        `json:"id"`
+	PersonID int        `json:"person_id"`
+	AlbumID  int        `json:"album_id,omitempty"`
+	SongID   int        `json:"song_id,omitempty"`
+	Role     PersonRole `json:"role"`
+}
+
+// CreatePersonCredit is a new credit, for an album or for a song but not
+// both.
+type CreatePersonCredit struct {
+	PersonID int        `json:"person_id" validate:"required,min=1"`
+	AlbumID  int        `json:"album_id" validate:"required_without=SongID,omitempty,min=1"`
+	SongID   int        `json:"song_id" validate:"excluded_with=AlbumID,omitempty,min=1"`
+	Role     PersonRole `json:"role" validate:"required,personrole"`
+}
+
+// UpdatePersonCredit is the new person and role of a credit. A credit stays
+// on its album or song.
+type UpdatePersonCredit struct {
+	PersonID int        `json:"person_id" validate:"required,min=1"`
+	Role     PersonRole `json:"role" validate:"required,personrole"`
+}
+
+// Personnel is a person credited on an album or song, as the album or song
+// lists them.
+type Personnel struct {
+	CreditID int        `json:"credit_id"`
+	PersonID int        `json:"person_id"`
+	Name     string     `json:"name"`
+	Role     PersonRole `json:"role"`
+}
+
+// PersonAlbum is an album a person is credited on, on the album itself or on
+// some of its songs. Roles are the person's roles on the album, and empty
+// when only songs credit them.
+type PersonAlbum struct {
+	Album
+	Roles []PersonRole `json:"roles"`
+	Songs []PersonSong `json:"songs,omitempty"`
+}
+
+// PersonSong is a song that credits a person.
+type PersonSong struct {
+	ID    int          `json:"id"`
+	Title string       `json:"title"`
+	Roles []PersonRole `json:"roles"`
+}
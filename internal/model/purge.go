@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type PurgeResult struct {
+	ArchivedBefore time.Time `json:"archived_before"`
+	DryRun         bool      `json:"dry_run"`
+	ArtistIDs      []int     `json:"artist_ids"`
+	AlbumIDs       []int     `json:"album_ids"`
+	SongIDs        []int     `json:"song_ids"`
+	PersonIDs      []int     `json:"person_ids"`
+}
@@ -0,0 +1,89 @@
+package model
+
+type FieldType int
+
+const (
+	StringField FieldType = iota
+	IntField
+	// DateField holds release dates, compared as the strings they are.
+	DateField
+	BoolField
+)
+
+// FieldSpec describes a field that collection endpoints accept in filters
+// and, when Sortable, in the sort parameter. Only fields present in the
+// listed response can be sorted on, since cursors are built from them.
+type FieldSpec struct {
+	Type     FieldType
+	Sortable bool
+}
+
+var ArtistFields = map[string]FieldSpec{
+	"id":          {Type: IntField, Sortable: true},
+	"name":        {Type: StringField, Sortable: true},
+	"description": {Type: StringField},
+}
+
+var PersonFields = map[string]FieldSpec{
+	"id":          {Type: IntField, Sortable: true},
+	"name":        {Type: StringField, Sortable: true},
+	"description": {Type: StringField},
+}
+
+var AlbumFields = map[string]FieldSpec{
+	"id":           {Type: IntField, Sortable: true},
+	"name":         {Type: StringField, Sortable: true},
+	"release_year": {Type: IntField, Sortable: true},
+	"release_date": {Type: DateField, Sortable: true},
+	"release_type": {Type: StringField},
+	"artist":       {Type: StringField, Sortable: true},
+	"artist_id":    {Type: IntField},
+	"compilation":  {Type: BoolField},
+}
+
+var SongFields = map[string]FieldSpec{
+	"id":               {Type: IntField, Sortable: true},
+	"title":            {Type: StringField, Sortable: true},
+	"disc_number":      {Type: IntField, Sortable: true},
+	"track_number":     {Type: IntField, Sortable: true},
+	"duration_seconds": {Type: IntField, Sortable: true},
+	"album":            {Type: StringField, Sortable: true},
+	"album_id":         {Type: IntField},
+	"artist":           {Type: StringField, Sortable: true},
+	"artist_id":        {Type: IntField},
+	"release_year":     {Type: IntField},
+}
+
+type FilterOp string
+
+const (
+	OpEq       FilterOp = "eq"
+	OpNe       FilterOp = "ne"
+	OpGt       FilterOp = "gt"
+	OpGte      FilterOp = "gte"
+	OpLt       FilterOp = "lt"
+	OpLte      FilterOp = "lte"
+	OpIn       FilterOp = "in"
+	OpContains FilterOp = "contains"
+)
+
+// FilterOps lists the operators allowed for each field type.
+var FilterOps = map[FieldType][]FilterOp{
+	StringField: {OpEq, OpNe, OpIn, OpContains},
+	IntField:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn},
+	DateField:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn},
+	BoolField:   {OpEq, OpNe},
+}
+
+// Filter is one parsed `field[op]=value` condition. Value holds a string,
+// int64 or bool, or a slice of strings or int64s for OpIn.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value any
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ReleaseType is the kind of release an album is.
+type ReleaseType string
+
+const (
+	ReleaseLP          ReleaseType = "lp"
+	ReleaseEP          ReleaseType = "ep"
+	ReleaseSingle      ReleaseType = "single"
+	ReleaseLive        ReleaseType = "live"
+	ReleaseCompilation ReleaseType = "compilation"
+	ReleaseReissue     ReleaseType = "reissue"
+)
+
+// ReleaseTypes lists every release type, as the album_release_type_check
+// constraint does. Albums given none are LPs.
+var ReleaseTypes = []ReleaseType{ReleaseLP, ReleaseEP, ReleaseSingle, ReleaseLive, ReleaseCompilation, ReleaseReissue}
+
+// ReleaseDate is when an album came out, known to the year, month or day:
+// "1999", "1999-09" or "1999-09-21". Dates in this form sort in release
+// order as strings, a date known to the day after its month and year.
+type ReleaseDate string
+
+var releaseDateLayouts = []string{"2006", "2006-01", "2006-01-02"}
+
+// YearDate is the release date of an album known only to its year.
+func YearDate(year int) ReleaseDate {
+	return ReleaseDate(fmt.Sprintf("%04d", year))
+}
+
+// Year returns the year of a valid date.
+func (d ReleaseDate) Year() int {
+	if len(d) < 4 {
+		return 0
+	}
+
+	year, _ := strconv.Atoi(string(d[:4]))
+	return year
+}
+
+// Valid reports whether d is a date in one of the three forms.
+func (d ReleaseDate) Valid() bool {
+	_, ok := d.start()
+	return ok
+}
+
+// start returns the first moment of the year, month or day d stands for, and
+// whether d is a valid date.
+func (d ReleaseDate) start() (time.Time, bool) {
+	for _, layout := range releaseDateLayouts {
+		if len(d) != len(layout) {
+			continue
+		}
+
+		t, err := time.Parse(layout, string(d))
+		return t, err == nil && t.Year() >= 1
+	}
+
+	return time.Time{}, false
+}
+
+// Release settles the year and date of an album from a request that gives
+// either or both, which validation has made agree. A year on its own is the
+// date.
+func Release(year int, date ReleaseDate) (int, ReleaseDate) {
+	if date == "" {
+		return year, YearDate(year)
+	}
+
+	return date.Year(), date
+}
+
+// CompareReleases orders albums as a discography is listed: latest release
+// first, then by id.
+func CompareReleases(a, b Album) int {
+	return cmp.Or(cmp.Compare(b.ReleaseDate, a.ReleaseDate), cmp.Compare(a.ID, b.ID))
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReleaseDateValid(t *testing.T) {
+	for date, want := range map[ReleaseDate]bool{
+		"1999":       true,
+		"1999-09":    true,
+		"1999-09-21": true,
+		"0000":       false,
+		"99":         false,
+		"1999-13":    false,
+		"1999-02-30": false,
+		"1999-9-21":  false,
+		"1999/09/21": false,
+	} {
+		if got := date.Valid(); got != want {
+			t.Errorf("ReleaseDate(%q).Valid(): got %t, want %t", date, got, want)
+		}
+	}
+}
+
+func TestRelease(t *testing.T) {
+	if year, date := Release(1999, ""); year != 1999 || date != "1999" {
+		t.Errorf("got %d %q for a year alone, want 1999 \"1999\"", year, date)
+	}
+	if year, date := Release(0, "1999-09-21"); year != 1999 || date != "1999-09-21" {
+		t.Errorf("got %d %q for a date alone, want 1999 \"1999-09-21\"", year, date)
+	}
+	if date := YearDate(802); date != "0802" {
+		t.Errorf("got %q, want the year padded to four digits", date)
+	}
+}
+
+func TestCompareReleases(t *testing.T) {
+	albums := []Album{
+		{ID: 1, ReleaseDate: "1999"},
+		{ID: 2, ReleaseDate: "1994-03-08"},
+		{ID: 3, ReleaseDate: "1999-09-21"},
+		{ID: 4, ReleaseDate: "1999-09"},
+		{ID: 5, ReleaseDate: "1999"},
+	}
+	slices.SortFunc(albums, CompareReleases)
+
+	ids := []int{}
+	for _, album := range albums {
+		ids = append(ids, album.ID)
+	}
+	if want := []int{3, 4, 1, 5, 2}; !slices.Equal(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
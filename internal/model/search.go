@@ -0,0 +1,18 @@
+package model
+
+type SearchParams struct {
+	Query    string
+	Types    []string
+	Archived bool
+	Limit    int
+}
+
+type SearchResult struct {
+	Type       string  `json:"type"`
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	ArtistName string  `json:"artist,omitempty"`
+	AlbumName  string  `json:"album,omitempty"`
+	Rank       float64 `json:"rank"`
+	Snippet    string  `json:"snippet"`
+}
@@ -1,36 +1,118 @@
 package model
 
+// Song is a song as listed. ArtistName is the song's own artist, if it has
+// one, and otherwise its album's; ArtistID is only set for the former.
 type Song struct {
 	ID              int    `json:"id"`
+	ArtistID        int    `json:"artist_id,omitempty"`
 	ArtistName      string `json:"artist,omitempty"`
 	AlbumName       string `json:"album,omitempty"`
 	Title           string `json:"title"`
+	DiscNumber      int    `json:"disc_number"`
+	DiscTitle       string `json:"disc_title,omitempty"`
 	TrackNumber     int    `json:"track_number"`
 	DurationSeconds int    `json:"duration_seconds"`
+	Credits
+	// Personnel is only filled in for a single song, or the songs of a
+	// single album.
+	Personnel []Personnel `json:"personnel,omitempty"`
+	Version   int         `json:"-"`
 }
 
 type SongResponse struct {
 	ID              int    `json:"id"`
+	ArtistID        int    `json:"artist_id,omitempty"`
 	Title           string `json:"title"`
+	DiscNumber      int    `json:"disc_number"`
+	DiscTitle       string `json:"disc_title,omitempty"`
 	TrackNumber     int    `json:"track_number"`
 	DurationSeconds int    `json:"duration_seconds"`
+	Credits
+	Version int `json:"-"`
 }
 
+// CreateSong is a new song. Without a disc number it is on disc 1, and
+// without a track number it goes after the last track of its disc. ArtistID
+// is the song's own artist, in place of its album's artists, and is needed on
+// a compilation. Artists lists who it credits besides those.
 type CreateSong struct {
-	AlbumID         int    `json:"album_id"`
-	Title           string `json:"title"`
-	TrackNumber     int    `json:"track_number"`
-	DurationSeconds int    `json:"duration_seconds"`
+	AlbumID         int            `json:"album_id" validate:"required"`
+	ArtistID        int            `json:"artist_id" validate:"omitempty,min=1"`
+	Title           string         `json:"title" validate:"required,notblank,max=255"`
+	DiscNumber      int            `json:"disc_number" validate:"omitempty,min=1"`
+	DiscTitle       string         `json:"disc_title" validate:"max=255"`
+	TrackNumber     int            `json:"track_number" validate:"omitempty,min=1"`
+	DurationSeconds int            `json:"duration_seconds" validate:"min=0"`
+	Artists         []CreditArtist `json:"artists" validate:"unique=ArtistID,dive"`
 }
 
+// UpdateSong is the new state of a song. Without a disc number it is on
+// disc 1.
 type UpdateSong struct {
-	Title           string `json:"title"`
-	TrackNumber     int    `json:"track_number"`
-	DurationSeconds int    `json:"duration_seconds"`
+	ArtistID        int            `json:"artist_id" validate:"omitempty,min=1"`
+	Title           string         `json:"title" validate:"required,notblank,max=255"`
+	DiscNumber      int            `json:"disc_number" validate:"omitempty,min=1"`
+	DiscTitle       string         `json:"disc_title" validate:"max=255"`
+	TrackNumber     int            `json:"track_number" validate:"min=1"`
+	DurationSeconds int            `json:"duration_seconds" validate:"min=0"`
+	Artists         []CreditArtist `json:"artists" validate:"unique=ArtistID,dive"`
 }
 
 type PatchSong struct {
-	Title           *string `json:"title"`
-	TrackNumber     *int    `json:"track_number"`
-	DurationSeconds *int    `json:"duration_seconds"`
+	ArtistID        *int            `json:"artist_id" validate:"omitnil,min=1"`
+	Title           *string         `json:"title" validate:"omitnil,notblank,max=255"`
+	DiscNumber      *int            `json:"disc_number" validate:"omitnil,min=1"`
+	DiscTitle       *string         `json:"disc_title" validate:"omitnil,max=255"`
+	TrackNumber     *int            `json:"track_number" validate:"omitnil,min=1"`
+	DurationSeconds *int            `json:"duration_seconds" validate:"omitnil,min=0"`
+	Artists         *[]CreditArtist `json:"artists" validate:"omitnil,unique=ArtistID,dive"`
+}
+
+// DiscOrFirst returns disc, or 1 for a song that doesn't give one.
+func DiscOrFirst(disc int) int {
+	return max(disc, 1)
+}
+
+// TreeSong is a song created along with its album. Songs without a track
+// number are numbered by their place among the songs of their disc in the
+// album's list.
+type TreeSong struct {
+	ArtistID        int            `json:"artist_id" validate:"omitempty,min=1"`
+	Title           string         `json:"title" validate:"required,notblank,max=255"`
+	DiscNumber      int            `json:"disc_number" validate:"omitempty,min=1"`
+	DiscTitle       string         `json:"disc_title" validate:"max=255"`
+	TrackNumber     *int           `json:"track_number" validate:"omitnil,min=1"`
+	DurationSeconds int            `json:"duration_seconds" validate:"min=0"`
+	Artists         []CreditArtist `json:"artists" validate:"unique=ArtistID,dive"`
+}
+
+// DiscPositions counts the songs of a list disc by disc.
+type DiscPositions map[int]int
+
+// Next returns the place of the next song of the list among those on its
+// disc, counting from 1.
+func (p DiscPositions) Next(disc int) int {
+	p[disc]++
+	return p[disc]
+}
+
+// TrackAt returns the song's track number, or position when it has none.
+func (s TreeSong) TrackAt(position int) int {
+	if s.TrackNumber == nil {
+		return position
+	}
+
+	return *s.TrackNumber
+}
+
+// TracklistSong is one entry of the tracklist that replaces an album's songs.
+// An entry with an id updates that song and one without adds a new song.
+type TracklistSong struct {
+	ID *int `json:"id"`
+	TreeSong
+}
+
+// ReorderSongs lists every song of an album in its new order.
+type ReorderSongs struct {
+	SongIDs []int `json:"song_ids" validate:"required"`
 }
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxTextLength matches the varchar(255) name, description and title
+// columns. The max=255 rules in the validate tags are kept in step with it.
+const MaxTextLength = 255
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by the name clients send them under.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// notfuture rejects years after the current one, and release dates that
+	// start after now.
+	v.RegisterValidation("notfuture", func(fl validator.FieldLevel) bool {
+		if fl.Field().Kind() == reflect.String {
+			start, _ := ReleaseDate(fl.Field().String()).start()
+			return !start.After(time.Now())
+		}
+
+		return fl.Field().Int() <= int64(time.Now().Year())
+	})
+
+	v.RegisterValidation("releasedate", func(fl validator.FieldLevel) bool {
+		return ReleaseDate(fl.Field().String()).Valid()
+	})
+
+	v.RegisterValidation("releasetype", func(fl validator.FieldLevel) bool {
+		return slices.Contains(ReleaseTypes, ReleaseType(fl.Field().String()))
+	})
+
+	v.RegisterValidation("artistrole", func(fl validator.FieldLevel) bool {
+		return slices.Contains(ArtistRoles, ArtistRole(fl.Field().String()))
+	})
+
+	v.RegisterValidation("personrole", func(fl validator.FieldLevel) bool {
+		return slices.Contains(PersonRoles, PersonRole(fl.Field().String()))
+	})
+
+	// matchesdate checks a release year against the release date given with
+	// it, if there is one.
+	v.RegisterValidation("matchesdate", func(fl validator.FieldLevel) bool {
+		date := reflect.Indirect(reflect.Indirect(fl.Parent()).FieldByName("ReleaseDate"))
+		if !date.IsValid() || date.String() == "" {
+			return true
+		}
+
+		return ReleaseDate(date.String()).Year() == int(fl.Field().Int())
+	})
+
+	return v
+}
+
+// Validate checks v against the rules in its validate tags and lists every
+// field that breaks one. For a list, each element is checked and its fields
+// are named after their index, as in "0/title".
+func Validate(v any) []FieldError {
+	if list := reflect.Indirect(reflect.ValueOf(v)); list.Kind() == reflect.Slice {
+		fields := []FieldError{}
+		for i := range list.Len() {
+			for _, field := range Validate(list.Index(i).Interface()) {
+				field.Field = strconv.Itoa(i) + "/" + field.Field
+				fields = append(fields, field)
+			}
+		}
+
+		return fields
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(validate.Struct(v), &errs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, FieldError{Field: fieldPath(e, reflect.TypeOf(v)), Message: message(e)})
+	}
+
+	return fields
+}
+
+// fieldPath names the field of e the way clients see it in the JSON body
+// of type t, as in "songs/0/title". Embedded structs don't add to the path,
+// since their fields are flattened into the body.
+func fieldPath(e validator.FieldError, t reflect.Type) string {
+	names := strings.Split(e.Namespace(), ".")[1:]
+	path := []string{}
+
+	for i, goName := range strings.Split(e.StructNamespace(), ".")[1:] {
+		goName, index, indexed := strings.Cut(goName, "[")
+
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		field, ok := t.FieldByName(goName)
+		if !ok {
+			return e.Field()
+		}
+		t = field.Type
+
+		if !field.Anonymous {
+			name, _, _ := strings.Cut(names[i], "[")
+			path = append(path, name)
+		}
+
+		if indexed {
+			path = append(path, strings.TrimSuffix(index, "]"))
+			t = t.Elem()
+		}
+	}
+
+	return strings.Join(path, "/")
+}
+
+func message(e validator.FieldError) string {
+	unit := ""
+	if e.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch e.Tag() {
+	case "required", "required_without", "required_unless":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "notfuture":
+		return "must not be in the future"
+	case "releasedate":
+		return "must be a date as YYYY, YYYY-MM or YYYY-MM-DD"
+	case "releasetype":
+		return "must be one of " + joinValues(ReleaseTypes)
+	case "artistrole":
+		return "must be one of " + joinValues(ArtistRoles)
+	case "personrole":
+		return "must be one of " + joinValues(PersonRoles)
+	case "unique":
+		return "must not list an artist more than once"
+	case "excluded_if":
+		return "must not be set on a compilation"
+	case "excluded_with":
+		return "must not be set along with album_id"
+	case "matchesdate":
+		return "must be the year of release_date"
+	case "min":
+		return "must be at least " + e.Param() + unit
+	case "max":
+		return "must be at most " + e.Param() + unit
+	}
+
+	return "is invalid"
+}
+
+// joinValues lists the values of an enumeration for a message.
+func joinValues[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+
+	return strings.Join(names, ", ")
+}
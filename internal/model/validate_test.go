@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	name := strings.Repeat("a", MaxTextLength+1)
+	nextYear := time.Now().Year() + 1
+
+	tests := []struct {
+		name string
+		v    any
+		want []FieldError
+	}{
+		{
+			name: "valid song",
+			v:    CreateSong{AlbumID: 1, Title: "The Frail", DurationSeconds: 114},
+		},
+		{
+			name: "song",
+			v:    CreateSong{Title: " ", TrackNumber: -1, DurationSeconds: -1},
+			want: []FieldError{
+				{Field: "album_id", Message: "is required"},
+				{Field: "title", Message: "must not be blank"},
+				{Field: "track_number", Message: "must be at least 1"},
+				{Field: "duration_seconds", Message: "must be at least 0"},
+			},
+		},
+		{
+			name: "artist",
+			v:    CreateArtist{Name: name},
+			want: []FieldError{{Field: "name", Message: "must be at most 255 characters"}},
+		},
+		{
+			name: "album",
+			v:    CreateAlbum{ArtistID: 1, Name: "The Fragile", ReleaseYear: nextYear},
+			want: []FieldError{{Field: "release_year", Message: "must not be in the future"}},
+		},
+		{
+			name: "album year and date",
+			v:    CreateAlbum{ArtistID: 1, Name: "The Fragile", ReleaseYear: 1998, ReleaseDate: "1999-09-21"},
+			want: []FieldError{{Field: "release_year", Message: "must be the year of release_date"}},
+		},
+		{
+			name: "album tree",
+			v: AlbumTree{
+				CreateAlbum: CreateAlbum{ArtistID: 1, Name: "The Fragile", ReleaseYear: 1999},
+				Songs:       []TreeSong{{Title: "Somewhat Damaged"}, {Title: ""}},
+			},
+			want: []FieldError{{Field: "songs/1/title", Message: "is required"}},
+		},
+		{
+			name: "list",
+			v:    []CreateArtist{{Name: "Nine Inch Nails"}, {Name: ""}},
+			want: []FieldError{{Field: "1/name", Message: "is required"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Validate(test.v)
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+			for i := range test.want {
+				if got[i] != test.want[i] {
+					t.Errorf("error %d: got %+v, want %+v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package pgtest connects tests to the Postgres database in
+// TEST_DATABASE_URL. Tests that need it are skipped when it isn't set.
+package pgtest
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var schemas atomic.Int64
+
+// Pool returns a pool whose connections use an empty schema of their own,
+// dropped with everything in it when the test ends. Extensions installed in
+// public stay visible.
+func Pool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+
+	// Installed once in public rather than by the migrations of each test
+	// schema, where only that schema would see it.
+	if _, err := admin.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public"); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("installing pg_trgm: %v", err)
+	}
+
+	schema := "test_" + strconv.FormatInt(time.Now().UnixNano(), 36) + "_" + strconv.FormatInt(schemas.Add(1), 10)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("creating schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("dropping schema %s: %v", schema, err)
+		}
+		admin.Close(ctx)
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parsing TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("creating the test pool: %v", err)
+	}
+	t.Cleanup(dbPool.Close)
+
+	return dbPool
+}
@@ -0,0 +1,55 @@
+package purge
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+	"github.com/liamcoleman/music-go/internal/repository"
+)
+
+const DefaultRetention = 30 * 24 * time.Hour
+
+// RetentionFromEnv reads PURGE_RETENTION as a Go duration such as "720h",
+// falling back to DefaultRetention when it is unset.
+func RetentionFromEnv() (time.Duration, error) {
+	value := os.Getenv("PURGE_RETENTION")
+	if value == "" {
+		return DefaultRetention, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// Actor is who purges are recorded as in the change history.
+const Actor = "purge"
+
+// Run purges everything that has been archived for longer than retention.
+func Run(ctx context.Context, store repository.PurgeStore, retention time.Duration, dryRun bool) (*model.PurgeResult, error) {
+	return store.Purge(repository.WithActor(ctx, Actor), time.Now().Add(-retention), dryRun)
+}
+
+// Schedule runs a purge immediately and then once every interval until ctx
+// is cancelled. Failures are logged and retried on the next tick.
+func Schedule(ctx context.Context, store repository.PurgeStore, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := Run(ctx, store, retention, false)
+		if err != nil {
+			log.Printf("Error purging archived records: %v", err)
+		} else if len(result.ArtistIDs)+len(result.AlbumIDs)+len(result.SongIDs)+len(result.PersonIDs) > 0 {
+			log.Printf("Purged %d artists, %d albums, %d songs and %d people archived before %s",
+				len(result.ArtistIDs), len(result.AlbumIDs), len(result.SongIDs), len(result.PersonIDs), result.ArchivedBefore.Format(time.RFC3339))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package purge
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+	"github.com/liamcoleman/music-go/internal/repository"
+)
+
+func TestRetentionFromEnv(t *testing.T) {
+	t.Setenv("PURGE_RETENTION", "")
+	if retention, err := RetentionFromEnv(); err != nil || retention != DefaultRetention {
+		t.Errorf("got %v, %v, want the default retention", retention, err)
+	}
+
+	t.Setenv("PURGE_RETENTION", "2160h")
+	if retention, err := RetentionFromEnv(); err != nil || retention != 2160*time.Hour {
+		t.Errorf("got %v, %v, want 2160h", retention, err)
+	}
+
+	t.Setenv("PURGE_RETENTION", "a month")
+	if _, err := RetentionFromEnv(); err == nil {
+		t.Error("got no error for an invalid retention")
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	db := repository.NewMemoryDB()
+	artists := repository.NewMemoryArtistRepository(db)
+	albums := repository.NewMemoryAlbumRepository(db)
+	history := repository.NewMemoryHistoryRepository(db)
+	store := repository.NewMemoryPurgeRepository(db)
+
+	archived, err := artists.CreateArtist(ctx, model.CreateArtist{Name: "Nine Inch Nails"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := artists.CreateArtist(ctx, model.CreateArtist{Name: "Tame Impala"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	album, err := albums.CreateAlbum(ctx, model.CreateAlbum{ArtistID: archived.ID, Name: "The Fragile", ReleaseYear: 1999})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := artists.DeleteArtist(ctx, strconv.Itoa(archived.ID), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Run(ctx, store, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ArtistIDs)+len(result.AlbumIDs) != 0 {
+		t.Errorf("got %+v, want nothing purged within the retention", result)
+	}
+
+	result, err = Run(ctx, store, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || !slices.Equal(result.ArtistIDs, []int{archived.ID}) || !slices.Equal(result.AlbumIDs, []int{album.ID}) {
+		t.Errorf("got %+v, want artist %d and album %d in the dry run", result, archived.ID, album.ID)
+	}
+
+	if _, err := Run(ctx, store, 0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := artists.RestoreArtist(ctx, strconv.Itoa(archived.ID), false); err == nil {
+		t.Error("restored a purged artist")
+	}
+	if _, err := artists.GetArtist(ctx, strconv.Itoa(kept.ID)); err != nil {
+		t.Errorf("getting a live artist after the purge: %v", err)
+	}
+
+	revisions, err := history.History(ctx, "artist", strconv.Itoa(archived.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) == 0 || revisions[0].Actor != Actor {
+		t.Errorf("got revisions %+v, want the purge recorded as by %q", revisions, Actor)
+	}
+}
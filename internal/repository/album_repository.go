@@ -1,10 +1,16 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
 
 	"github.com/liamcoleman/music-go/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,14 +24,63 @@ func NewAlbumRepository(dbPool *pgxpool.Pool) *AlbumRepository {
 	}
 }
 
-func (r *AlbumRepository) GetAlbums(ctx context.Context) ([]model.Album, error) {
+// artistName is the name of the artist an album or song is by, which for a
+// compilation without one is model.VariousArtists.
+const artistName = "COALESCE(artist.name, '" + model.VariousArtists + "')"
+
+var albumColumns = map[string]string{
+	"id":           "album.id",
+	"name":         "COALESCE(album.name, '')",
+	"release_year": "album.release_year",
+	"release_date": "album.release_date",
+	"release_type": "album.release_type",
+	"compilation":  "album.compilation",
+	"artist":       artistName,
+	"artist_id":    "album.artist_id",
+}
+
+var albumOrder = []orderColumn{
+	{field: "artist", expr: albumColumns["artist"]},
+	{field: "name", expr: albumColumns["name"]},
+	{field: "id", expr: albumColumns["id"]},
+}
+
+func albumValue(album model.Album, field string) any {
+	switch field {
+	case "id":
+		return album.ID
+	case "name":
+		return album.Name
+	case "release_year":
+		return album.ReleaseYear
+	case "release_date":
+		return string(album.ReleaseDate)
+	case "compilation":
+		return album.Compilation
+	case "artist":
+		return album.ArtistName
+	}
+
+	return nil
+}
+
+func (r *AlbumRepository) GetAlbums(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error) {
+
+	l, err := newListing(params, albumColumns, albumOrder)
+	if err != nil {
+		return nil, err
+	}
 
-	query := `SELECT album.id, album.name, album.release_year, artist.name as artist 
+	args := []any{}
+	query, err := l.query(`SELECT album.id, album.name, album.release_year, album.release_date, album.release_type, album.compilation, `+artistName+` as artist 
 			FROM album 
-			JOIN artist ON album.artist_id = artist.id 
-			WHERE album.archived = FALSE
-			ORDER BY artist.name, album.name`
-	rows, err := r.dbPool.Query(ctx, query)
+			LEFT JOIN artist ON album.artist_id = artist.id 
+			WHERE album.archived = `+addArg(&args, params.Archived), &args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbPool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +90,7 @@ func (r *AlbumRepository) GetAlbums(ctx context.Context) ([]model.Album, error)
 
 	for rows.Next() {
 		var album model.Album
-		if err := rows.Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ArtistName); err != nil {
+		if err := rows.Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType, &album.Compilation, &album.ArtistName); err != nil {
 			return nil, err
 		}
 
@@ -46,28 +101,60 @@ func (r *AlbumRepository) GetAlbums(ctx context.Context) ([]model.Album, error)
 		return nil, err
 	}
 
-	return albums, nil
+	if err := loadAlbumCredits(ctx, r.dbPool, albumCreditsByID(albums)); err != nil {
+		return nil, err
+	}
+
+	return page(l, albums, albumValue), nil
+}
+
+// GetArtistAlbums lists the albums of a live artist.
+func (r *AlbumRepository) GetArtistAlbums(ctx context.Context, artistID string, params model.ListParams) (*model.Page[model.Album], error) {
+
+	id, err := liveParent(ctx, r.dbPool, "artist", artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetAlbums(ctx, childParams(params, "artist_id", id))
+}
+
+// GetCompilations lists the albums with no artist of their own.
+func (r *AlbumRepository) GetCompilations(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error) {
+	return r.GetAlbums(ctx, compilationParams(params))
 }
 
 func (r *AlbumRepository) GetAlbum(ctx context.Context, id string) (*model.AlbumWithSongs, error) {
 
 	var album model.AlbumWithSongs
 
-	query := `SELECT album.id, album.name, album.release_year, artist.name as artist 
+	query := `SELECT album.id, album.name, album.release_year, album.release_date, album.release_type, album.compilation, ` + artistName + ` as artist, album.version 
 		FROM album 
-		JOIN artist ON album.artist_id = artist.id 
+		LEFT JOIN artist ON album.artist_id = artist.id 
 		WHERE album.id = $1 AND album.archived = FALSE`
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ArtistName)
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType, &album.Compilation, &album.ArtistName, &album.Version)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := loadAlbumCredits(ctx, r.dbPool, map[int]*model.Credits{album.ID: &album.Credits}); err != nil {
+		return nil, err
+	}
+
 	album.Songs, err = r.GetSongsForAlbum(ctx, album.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := loadPersonnel(ctx, r.dbPool, "album_id", map[int]*[]model.Personnel{album.ID: &album.Personnel}); err != nil {
+		return nil, err
+	}
+
+	if err := loadPersonnel(ctx, r.dbPool, "song_id", songPersonnelByID(album.Songs)); err != nil {
+		return nil, err
+	}
+
 	return &album, nil
 
 }
@@ -76,8 +163,27 @@ func (r *AlbumRepository) CreateAlbum(ctx context.Context, album model.CreateAlb
 
 	var albumCreated model.AlbumResponse
 
-	query := `INSERT INTO album (artist_id, name, release_year, archived) VALUES ($1, $2, $3, FALSE) RETURNING id, name, release_year`
-	err := r.dbPool.QueryRow(ctx, query, album.ArtistID, album.Name, album.ReleaseYear).Scan(&albumCreated.ID, &albumCreated.Name, &albumCreated.ReleaseYear)
+	year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+
+	query := `INSERT INTO album (artist_id, compilation, name, release_year, release_date, release_type, archived) VALUES ($1, $2, $3, $4, $5, $6, FALSE) RETURNING id, name, release_year, release_date, release_type, compilation, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockAlbumArtist(ctx, tx, album); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, query, nullID(album.ArtistID), album.Compilation, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)).Scan(&albumCreated.ID, &albumCreated.Name, &albumCreated.ReleaseYear, &albumCreated.ReleaseDate, &albumCreated.ReleaseType, &albumCreated.Compilation, &albumCreated.Version)
+		if err != nil {
+			return err
+		}
+
+		if len(album.Artists) > 0 {
+			if err := replaceCredits(ctx, tx, albumCredits, map[int][]model.CreditArtist{albumCreated.ID: album.Artists}); err != nil {
+				return err
+			}
+		}
+
+		return loadAlbumCredits(ctx, tx, map[int]*model.Credits{albumCreated.ID: &albumCreated.Credits})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -86,12 +192,131 @@ func (r *AlbumRepository) CreateAlbum(ctx context.Context, album model.CreateAlb
 
 }
 
-func (r *AlbumRepository) UpdateAlbum(ctx context.Context, album model.UpdateAlbum, id string) (*model.AlbumResponse, error) {
+// lockAlbumArtist locks the artist a new album is by, unless it is a
+// compilation, which has none.
+func lockAlbumArtist(ctx context.Context, tx pgx.Tx, album model.CreateAlbum) error {
+	if album.Compilation {
+		return nil
+	}
+
+	return lockParent(ctx, tx, "artist", album.ArtistID)
+}
+
+// CreateAlbumTree creates an album and its songs in one transaction.
+func (r *AlbumRepository) CreateAlbumTree(ctx context.Context, album model.AlbumTree) (*model.AlbumWithSongs, error) {
+
+	var createdAlbum model.AlbumWithSongs
+
+	year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+
+	query := `INSERT INTO album (artist_id, compilation, name, release_year, release_date, release_type, archived) VALUES ($1, $2, $3, $4, $5, $6, FALSE) RETURNING id`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockAlbumArtist(ctx, tx, album.CreateAlbum); err != nil {
+			return err
+		}
+
+		var id int
+		if err := tx.QueryRow(ctx, query, nullID(album.ArtistID), album.Compilation, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)).Scan(&id); err != nil {
+			return err
+		}
+
+		if len(album.Artists) > 0 {
+			if err := replaceCredits(ctx, tx, albumCredits, map[int][]model.CreditArtist{id: album.Artists}); err != nil {
+				return err
+			}
+		}
+
+		if err := insertTreeSongs(ctx, tx, id, album.Compilation, album.Songs); err != nil {
+			return err
+		}
+
+		created, err := lockedAlbum(ctx, tx, strconv.Itoa(id))
+		if err != nil {
+			return err
+		}
+		createdAlbum = *created
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdAlbum, nil
+}
+
+// insertTreeSongs copies the songs of a new album in, with ids drawn from the
+// sequence first so their credits can follow.
+func insertTreeSongs(ctx context.Context, tx pgx.Tx, albumID int, compilation bool, songs []model.TreeSong) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	artistIDs := make([]int, len(songs))
+	for i, song := range songs {
+		artistIDs[i] = song.ArtistID
+	}
+
+	if err := checkSongArtists(ctx, tx, compilation, artistIDs...); err != nil {
+		return err
+	}
+
+	query := `SELECT nextval(pg_get_serial_sequence('song', 'id')) FROM generate_series(1, $1)`
+
+	idRows, err := tx.Query(ctx, query, len(songs))
+	if err != nil {
+		return err
+	}
+
+	ids, err := pgx.CollectRows(idRows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+
+	positions := model.DiscPositions{}
+	rows := make([][]any, len(songs))
+	artists := map[int][]model.CreditArtist{}
+	for i, song := range songs {
+		disc := model.DiscOrFirst(song.DiscNumber)
+		rows[i] = []any{ids[i], albumID, nullID(song.ArtistID), song.Title, disc, song.DiscTitle, song.TrackAt(positions.Next(disc)), song.DurationSeconds, false}
+
+		if len(song.Artists) > 0 {
+			artists[ids[i]] = song.Artists
+		}
+	}
+
+	columns := []string{"id", "album_id", "artist_id", "title", "disc_number", "disc_title", "track_number", "duration_seconds", "archived"}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"song"}, columns, pgx.CopyFromRows(rows))
+	if err != nil {
+		return err
+	}
+
+	return replaceCredits(ctx, tx, songCredits, artists)
+}
+
+func (r *AlbumRepository) UpdateAlbum(ctx context.Context, album model.UpdateAlbum, id string, ifMatch []int) (*model.AlbumResponse, error) {
 	var updatedAlbum model.AlbumResponse
 
-	query := `UPDATE album SET name = $2, release_year = $3 WHERE id = $1 AND archived = FALSE RETURNING id, name, release_year`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "album", id, ifMatch); err != nil {
+			return err
+		}
+
+		year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+
+		query := `UPDATE album SET name = $2, release_year = $3, release_date = $4, release_type = $5 WHERE id = $1 RETURNING id, name, release_year, release_date, release_type, compilation, version`
 
-	err := r.dbPool.QueryRow(ctx, query, id, album.Name, album.ReleaseYear).Scan(&updatedAlbum.ID, &updatedAlbum.Name, &updatedAlbum.ReleaseYear)
+		err := tx.QueryRow(ctx, query, id, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)).Scan(&updatedAlbum.ID, &updatedAlbum.Name, &updatedAlbum.ReleaseYear, &updatedAlbum.ReleaseDate, &updatedAlbum.ReleaseType, &updatedAlbum.Compilation, &updatedAlbum.Version)
+		if err != nil {
+			return err
+		}
+
+		if err := replaceCredits(ctx, tx, albumCredits, map[int][]model.CreditArtist{updatedAlbum.ID: album.Artists}); err != nil {
+			return err
+		}
+
+		return loadAlbumCredits(ctx, tx, map[int]*model.Credits{updatedAlbum.ID: &updatedAlbum.Credits})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -99,39 +324,240 @@ func (r *AlbumRepository) UpdateAlbum(ctx context.Context, album model.UpdateAlb
 	return &updatedAlbum, nil
 }
 
-func (r *AlbumRepository) PatchAlbum(ctx context.Context, album model.PatchAlbum, id string) (*model.AlbumResponse, error) {
+func (r *AlbumRepository) PatchAlbum(ctx context.Context, album model.PatchAlbum, id string, ifMatch []int) (*model.AlbumResponse, error) {
 
 	var patchedAlbum model.AlbumResponse
 
-	if album.Name != nil {
-		queryName := `UPDATE album SET name = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryName, id, album.Name)
+	args := []any{id}
+	sets := albumSets(album, &args)
+
+	query := patchQuery("album", sets, "id = $1 AND archived = FALSE"+versionCondition(&args, ifMatch), "id, name, release_year, release_date, release_type, compilation, version")
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, args...).Scan(&patchedAlbum.ID, &patchedAlbum.Name, &patchedAlbum.ReleaseYear, &patchedAlbum.ReleaseDate, &patchedAlbum.ReleaseType, &patchedAlbum.Compilation, &patchedAlbum.Version)
+		if errors.Is(err, pgx.ErrNoRows) && ifMatch != nil {
+			return missingOrModified(ctx, tx, "album", id)
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
+
+		if album.Artists != nil {
+			if err := replaceCredits(ctx, tx, albumCredits, map[int][]model.CreditArtist{patchedAlbum.ID: *album.Artists}); err != nil {
+				return err
+			}
+		}
+
+		return loadAlbumCredits(ctx, tx, map[int]*model.Credits{patchedAlbum.ID: &patchedAlbum.Credits})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if album.ReleaseYear != nil {
-		queryReleaseYear := `UPDATE album SET release_year = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryReleaseYear, id, album.ReleaseYear)
+	return &patchedAlbum, nil
+}
+
+// albumSets lists the column assignments for the fields of a patch that are
+// set.
+func albumSets(album model.PatchAlbum, args *[]any) []string {
+	sets := []string{}
+
+	if album.Name != nil {
+		sets = append(sets, "name = "+addArg(args, *album.Name))
+	}
+
+	switch {
+	case album.ReleaseDate != nil:
+		year, date := model.Release(0, *album.ReleaseDate)
+		sets = append(sets, "release_year = "+addArg(args, year), "release_date = "+addArg(args, date))
+	case album.ReleaseYear != nil:
+		// The date keeps its month and day while its year stands.
+		year := addArg(args, *album.ReleaseYear)
+		sets = append(sets, "release_year = "+year, "release_date = CASE WHEN release_year = "+year+" THEN release_date ELSE "+addArg(args, model.YearDate(*album.ReleaseYear))+" END")
+	}
+
+	if album.ReleaseType != nil {
+		sets = append(sets, "release_type = "+addArg(args, *album.ReleaseType))
+	}
+
+	// Credits are kept in album_artist, so the row is only touched to move
+	// it to a new version.
+	if album.Artists != nil {
+		sets = append(sets, "version = version")
+	}
+
+	return sets
+}
+
+// ChangeAlbum locks the album, works out the changes to it and its songs from
+// their current state with change and applies them, all in one transaction.
+func (r *AlbumRepository) ChangeAlbum(ctx context.Context, id string, ifMatch []int, change func(model.AlbumWithSongs) (model.AlbumChanges, error)) (*model.AlbumWithSongs, error) {
+
+	var changedAlbum model.AlbumWithSongs
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		album, err := lockedAlbum(ctx, tx, id)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if !matchesVersion(ifMatch, album.Version) {
+			return ErrVersionMismatch
+		}
+
+		changes, err := change(*album)
+		if err != nil {
+			return err
+		}
+
+		// Songs are renumbered one at a time, so track numbers may clash
+		// until the last of them is written.
+		if err := deferTrackNumbers(ctx, tx); err != nil {
+			return err
+		}
+
+		args := []any{album.ID}
+		if sets := albumSets(changes.Album, &args); len(sets) > 0 {
+			_, err := tx.Exec(ctx, patchQuery("album", sets, "id = $1", "id"), args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		artists := map[int][]model.CreditArtist{}
+		if changes.Album.Artists != nil {
+			if err := replaceCredits(ctx, tx, albumCredits, map[int][]model.CreditArtist{album.ID: *changes.Album.Artists}); err != nil {
+				return err
+			}
+		}
+
+		if len(changes.Removed) > 0 {
+			query := `UPDATE song SET archived = TRUE, archived_at = now() WHERE album_id = $1 AND id = ANY($2) AND archived = FALSE`
+
+			_, err := tx.Exec(ctx, query, album.ID, changes.Removed)
+			if err != nil {
+				return err
+			}
+		}
+
+		artistIDs := []int{}
+		for _, patch := range changes.Songs {
+			if patch.ArtistID != nil {
+				artistIDs = append(artistIDs, *patch.ArtistID)
+			}
+		}
+		for _, song := range changes.Added {
+			artistIDs = append(artistIDs, song.ArtistID)
+		}
+
+		if err := checkSongArtists(ctx, tx, album.Compilation, artistIDs...); err != nil {
+			return err
+		}
+
+		for _, songID := range slices.Sorted(maps.Keys(changes.Songs)) {
+			args := []any{songID, album.ID}
+			sets := songSets(changes.Songs[songID], &args)
+			if len(sets) == 0 {
+				continue
+			}
+
+			_, err := tx.Exec(ctx, patchQuery("song", sets, "id = $1 AND album_id = $2 AND archived = FALSE", "id"), args...)
+			if err != nil {
+				return err
+			}
+
+			if patch := changes.Songs[songID]; patch.Artists != nil {
+				artists[songID] = *patch.Artists
+			}
+		}
+
+		for _, song := range changes.Added {
+			query := `INSERT INTO song (album_id, artist_id, title, disc_number, disc_title, track_number, duration_seconds, archived) VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE) RETURNING id`
+
+			var songID int
+			err := tx.QueryRow(ctx, query, album.ID, nullID(song.ArtistID), song.Title, model.DiscOrFirst(song.DiscNumber), song.DiscTitle, song.TrackNumber, song.DurationSeconds).Scan(&songID)
+			if err != nil {
+				return err
+			}
+
+			if len(song.Artists) > 0 {
+				artists[songID] = song.Artists
+			}
+		}
+
+		if err := replaceCredits(ctx, tx, songCredits, artists); err != nil {
+			return err
 		}
+
+		changed, err := lockedAlbum(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		changedAlbum = *changed
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &changedAlbum, nil
+}
+
+// lockedAlbum locks the live album for the rest of the transaction and reads
+// it with its songs.
+func lockedAlbum(ctx context.Context, tx pgx.Tx, id string) (*model.AlbumWithSongs, error) {
+	var album model.AlbumWithSongs
+
+	query := `SELECT album.id, album.name, album.release_year, album.release_date, album.release_type, album.compilation, ` + artistName + ` as artist, album.version
+				FROM album
+				LEFT JOIN artist ON album.artist_id = artist.id
+				WHERE album.id = $1 AND album.archived = FALSE
+				FOR UPDATE OF album`
+
+	err := tx.QueryRow(ctx, query, id).Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType, &album.Compilation, &album.ArtistName, &album.Version)
+	if err != nil {
+		return nil, err
 	}
 
-	query := `SELECT id, name, release_year FROM album WHERE id = $1 AND archived = FALSE`
+	query2 := `SELECT song.id, COALESCE(song.artist_id, 0), COALESCE(artist.name, ''), song.title, song.disc_number, song.disc_title, song.track_number, song.duration_seconds, song.version
+				FROM song
+				LEFT JOIN artist ON artist.id = song.artist_id
+				WHERE song.album_id = $1 AND song.archived = FALSE
+				ORDER BY song.disc_number, song.track_number, song.id`
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&patchedAlbum.ID, &patchedAlbum.Name, &patchedAlbum.ReleaseYear)
+	rows, err := tx.Query(ctx, query2, album.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &patchedAlbum, nil
+	album.Songs, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Song, error) {
+		var song model.Song
+		err := row.Scan(&song.ID, &song.ArtistID, &song.ArtistName, &song.Title, &song.DiscNumber, &song.DiscTitle, &song.TrackNumber, &song.DurationSeconds, &song.Version)
+		return song, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadAlbumCredits(ctx, tx, map[int]*model.Credits{album.ID: &album.Credits}); err != nil {
+		return nil, err
+	}
+
+	if err := loadSongCredits(ctx, tx, songCreditsByID(album.Songs)); err != nil {
+		return nil, err
+	}
+
+	return &album, nil
 }
 
 func (r *AlbumRepository) GetSongsForAlbum(ctx context.Context, albumID int) ([]model.Song, error) {
 
-	query := `SELECT id, title, track_number, duration_seconds FROM song WHERE album_id = $1 AND archived = FALSE ORDER BY track_number`
+	query := `SELECT song.id, COALESCE(song.artist_id, 0), COALESCE(artist.name, ''), song.title, song.disc_number, song.disc_title, song.track_number, song.duration_seconds
+				FROM song
+				LEFT JOIN artist ON artist.id = song.artist_id
+				WHERE song.album_id = $1 AND song.archived = FALSE
+				ORDER BY song.disc_number, song.track_number`
 	rows, err := r.dbPool.Query(ctx, query, albumID)
 	if err != nil {
 		return nil, err
@@ -142,7 +568,7 @@ func (r *AlbumRepository) GetSongsForAlbum(ctx context.Context, albumID int) ([]
 
 	for rows.Next() {
 		var song model.Song
-		if err := rows.Scan(&song.ID, &song.Title, &song.TrackNumber, &song.DurationSeconds); err != nil {
+		if err := rows.Scan(&song.ID, &song.ArtistID, &song.ArtistName, &song.Title, &song.DiscNumber, &song.DiscTitle, &song.TrackNumber, &song.DurationSeconds); err != nil {
 			return nil, err
 		}
 
@@ -153,26 +579,353 @@ func (r *AlbumRepository) GetSongsForAlbum(ctx context.Context, albumID int) ([]
 		return nil, err
 	}
 
+	if err := loadSongCredits(ctx, r.dbPool, songCreditsByID(songs)); err != nil {
+		return nil, err
+	}
+
 	return songs, nil
 }
 
-func (r *AlbumRepository) DeleteAlbum(ctx context.Context, id string) error {
+func (r *AlbumRepository) DeleteAlbum(ctx context.Context, id string, ifMatch []int) (*model.ArchivedAlbum, error) {
+
+	var archivedAlbum model.ArchivedAlbum
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "album", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `UPDATE album SET archived = TRUE, archived_at = now() WHERE id = $1 AND archived = FALSE RETURNING id`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&archivedAlbum.ID)
+		if err != nil {
+			return err
+		}
+
+		query2 := `UPDATE song SET archived = TRUE, archived_at = now() WHERE album_id = $1 AND archived = FALSE`
+
+		tag, err := tx.Exec(ctx, query2, id)
+		if err != nil {
+			return err
+		}
+		archivedAlbum.SongsArchived = int(tag.RowsAffected())
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &archivedAlbum, nil
+}
+
+func (r *AlbumRepository) RestoreAlbum(ctx context.Context, id string, cascade bool) (*model.RestoredAlbum, error) {
+
+	var restoredAlbum model.RestoredAlbum
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var artistArchived bool
+
+		query := `SELECT album.id, COALESCE(artist.archived, FALSE)
+				FROM album
+				LEFT JOIN artist ON album.artist_id = artist.id
+				WHERE album.id = $1 AND album.archived = TRUE
+				FOR UPDATE OF album`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&restoredAlbum.ID, &artistArchived)
+		if err != nil {
+			return err
+		}
+
+		if artistArchived {
+			return ErrParentArchived
+		}
+
+		query2 := `UPDATE album SET archived = FALSE, archived_at = NULL WHERE id = $1`
+
+		_, err = tx.Exec(ctx, query2, id)
+		if err != nil {
+			return err
+		}
+
+		if !cascade {
+			return nil
+		}
+
+		if err := deferTrackNumbers(ctx, tx); err != nil {
+			return err
+		}
+
+		query3 := `UPDATE song SET archived = FALSE, archived_at = NULL WHERE album_id = $1 AND archived = TRUE RETURNING id`
+
+		rows, err := tx.Query(ctx, query3, id)
+		if err != nil {
+			return err
+		}
 
-	// TODO: Cascade and set songs as well
-	// query := `DELETE FROM album where id = $1`
-	query := `UPDATE album SET archived = TRUE where id = $1`
+		songIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+		restoredAlbum.SongsRestored = len(songIDs)
 
-	_, err := r.dbPool.Exec(ctx, query, id)
+		return placeRestoredSongs(ctx, tx, songIDs)
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	return &restoredAlbum, nil
+}
+
+func (r *AlbumRepository) MatchAlbums(ctx context.Context, artistID int, params model.MatchParams) ([]model.AlbumMatch, error) {
+
+	albums := []model.AlbumMatch{}
+
+	err := pgx.BeginFunc(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := setSimilarityThreshold(ctx, tx, params.Threshold); err != nil {
+			return err
+		}
+
+		query := `SELECT album.id, artist.name, album.name, album.release_year, similarity(lower(album.name), lower($2)) AS score
+					FROM album
+					JOIN artist ON artist.id = album.artist_id
+					WHERE album.artist_id = $1 AND album.archived = FALSE AND lower(album.name) % lower($2)
+					ORDER BY score DESC, album.id
+					LIMIT $3`
+
+		rows, err := tx.Query(ctx, query, artistID, params.Name, params.Limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var album model.AlbumMatch
+			if err := rows.Scan(&album.ID, &album.ArtistName, &album.Name, &album.ReleaseYear, &album.Score); err != nil {
+				return err
+			}
+
+			albums = append(albums, album)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		credits := map[int]*model.Credits{}
+		for i := range albums {
+			credits[albums[i].ID] = &albums[i].Credits
+		}
+
+		return loadAlbumCredits(ctx, tx, credits)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return albums, nil
+}
+
+func (r *AlbumRepository) MergeAlbum(ctx context.Context, id string, targetID int) (*model.MergedAlbum, error) {
+
+	mergedAlbum := model.MergedAlbum{TargetID: targetID}
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		sourceID, err := lockForMerge(ctx, tx, "album", id, targetID)
+		if err != nil {
+			return err
+		}
+		mergedAlbum.ID = sourceID
+
+		// Source tracks clashing with a target track on the same disc keep
+		// their relative order but move past the last track of that disc on
+		// either album.
+		query := `WITH last_track AS (
+					SELECT disc_number, MAX(track_number) AS track_number
+					FROM song
+					WHERE album_id IN ($1, $2) AND archived = FALSE
+					GROUP BY disc_number
+				), clashes AS (
+					SELECT source.id, source.disc_number,
+						row_number() OVER (PARTITION BY source.disc_number ORDER BY source.track_number, source.id) AS n
+					FROM song source
+					WHERE source.album_id = $1 AND source.archived = FALSE AND EXISTS (
+						SELECT 1 FROM song target
+						WHERE target.album_id = $2 AND target.archived = FALSE
+							AND target.disc_number = source.disc_number AND target.track_number = source.track_number
+					)
+				)
+				UPDATE song SET track_number = last_track.track_number + clashes.n
+				FROM clashes
+				JOIN last_track ON last_track.disc_number = clashes.disc_number
+				WHERE song.id = clashes.id`
+
+		tag, err := tx.Exec(ctx, query, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		mergedAlbum.TracksRenumbered = int(tag.RowsAffected())
+
+		if err := mergeAlbumCredits(ctx, tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		if err := mergePersonCredits(ctx, tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		// Songs moving onto an album of another artist, or a compilation,
+		// take their album's artist along.
+		query2 := `UPDATE song SET album_id = $2,
+						artist_id = CASE WHEN source.artist_id IS DISTINCT FROM target.artist_id THEN COALESCE(song.artist_id, source.artist_id) ELSE song.artist_id END
+					FROM album source, album target
+					WHERE source.id = $1 AND target.id = $2 AND song.album_id = $1`
+
+		tag, err = tx.Exec(ctx, query2, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		mergedAlbum.SongsMoved = int(tag.RowsAffected())
+
+		query3 := `UPDATE album SET archived = TRUE, archived_at = now() WHERE id = $1`
+
+		_, err = tx.Exec(ctx, query3, sourceID)
+		if err != nil {
+			return err
+		}
+
+		return recordRedirect(ctx, tx, "album", sourceID, targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergedAlbum, nil
+}
+
+// mergeAlbumCredits reconciles the artists credited on an album being merged
+// with the target's before its songs move. When the albums share an artist
+// the target takes on the source's credits, dropping those it already has,
+// and the songs that follow their album drop credits the target now gives
+// them. Otherwise those songs keep their album's credits as their own.
+func mergeAlbumCredits(ctx context.Context, tx pgx.Tx, sourceID, targetID int) error {
+	var shared bool
+	query := `SELECT source.artist_id IS NOT DISTINCT FROM target.artist_id FROM album source, album target WHERE source.id = $1 AND target.id = $2`
+
+	err := tx.QueryRow(ctx, query, sourceID, targetID).Scan(&shared)
+	if err != nil {
+		return err
+	}
+
+	if !shared {
+		// The songs' own credits make way for their album's, which are
+		// shown first: negating their positions keeps them apart until
+		// they are put back after.
+		query2 := `UPDATE song_artist SET position = -position
+					FROM song
+					WHERE song.id = song_artist.song_id AND song.album_id = $1 AND song.artist_id IS NULL`
+
+		_, err := tx.Exec(ctx, query2, sourceID)
+		if err != nil {
+			return err
+		}
+
+		query3 := `INSERT INTO song_artist (song_id, position, artist_id, role, join_phrase)
+					SELECT song.id, row_number() OVER (PARTITION BY song.id ORDER BY credit.position), credit.artist_id, credit.role, credit.join_phrase
+					FROM song JOIN album_artist credit ON credit.album_id = song.album_id
+					WHERE song.album_id = $1 AND song.artist_id IS NULL
+						AND NOT EXISTS (SELECT 1 FROM song_artist own WHERE own.song_id = song.id AND own.artist_id = credit.artist_id)`
+
+		_, err = tx.Exec(ctx, query3, sourceID)
+		if err != nil {
+			return err
+		}
+
+		query4 := `UPDATE song_artist SET position = -song_artist.position + (SELECT COUNT(*) FROM song_artist album_credit WHERE album_credit.song_id = song_artist.song_id AND album_credit.position > 0)
+					FROM song
+					WHERE song.id = song_artist.song_id AND song.album_id = $1 AND song.artist_id IS NULL AND song_artist.position < 0`
+
+		_, err = tx.Exec(ctx, query4, sourceID)
 		return err
 	}
 
-	query2 := `UPDATE song SET archived = TRUE where album_id = $1`
+	query2 := `DELETE FROM album_artist credit USING album target
+				WHERE credit.album_id = $1 AND target.id = $2
+				AND (target.artist_id = credit.artist_id OR EXISTS (SELECT 1 FROM album_artist other WHERE other.album_id = $2 AND other.artist_id = credit.artist_id))`
 
-	_, err = r.dbPool.Exec(ctx, query2, id)
+	_, err = tx.Exec(ctx, query2, sourceID, targetID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	query3 := `UPDATE album_artist SET album_id = $2, position = moved.position
+				FROM (
+					SELECT credit.position AS source_position,
+						(SELECT COALESCE(MAX(position), 0) FROM album_artist WHERE album_id = $2) + row_number() OVER (ORDER BY credit.position) AS position
+					FROM album_artist credit
+					WHERE credit.album_id = $1
+				) moved
+				WHERE album_artist.album_id = $1 AND album_artist.position = moved.source_position`
+
+	tag, err := tx.Exec(ctx, query3, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() > 0 {
+		if err := touch(ctx, tx, "album", targetID); err != nil {
+			return err
+		}
+	}
+
+	query4 := `DELETE FROM song_artist credit USING song
+				WHERE song.id = credit.song_id AND song.album_id = $1 AND song.artist_id IS NULL
+				AND EXISTS (SELECT 1 FROM album_artist other WHERE other.album_id = $2 AND other.artist_id = credit.artist_id)`
+
+	_, err = tx.Exec(ctx, query4, sourceID, targetID)
+	return err
+}
+
+func (r *AlbumRepository) AlbumRedirect(ctx context.Context, id string) (int, error) {
+	var targetID int
+	query := `SELECT target_id FROM album_redirect WHERE album_id = $1`
+
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	return targetID, nil
+}
+
+var albumBulk = bulkTable[model.CreateAlbum, model.UpdateAlbum]{
+	table:   "album",
+	columns: []string{"artist_id", "compilation", "name", "release_year", "release_date", "release_type"},
+	values: func(album model.CreateAlbum) []any {
+		year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+		return []any{nullID(album.ArtistID), album.Compilation, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)}
+	},
+	parentTable: "artist",
+	parent: func(album model.CreateAlbum) int {
+		return album.ArtistID
+	},
+	update: func(id int, album model.UpdateAlbum) (string, []any) {
+		year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+
+		query := `UPDATE album SET name = $2, release_year = $3, release_date = $4, release_type = $5 WHERE id = $1 AND archived = FALSE RETURNING id`
+		return query, []any{id, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)}
+	},
+	credits: func(item model.BulkItem[model.CreateAlbum, model.UpdateAlbum]) []model.CreditArtist {
+		if item.ID == 0 {
+			return item.Create.Artists
+		}
+		return item.Update.Artists
+	},
+	creditTable: albumCredits,
+}
+
+// BulkAlbums creates and replaces albums in one transaction.
+func (r *AlbumRepository) BulkAlbums(ctx context.Context, items []model.BulkItem[model.CreateAlbum, model.UpdateAlbum], atomic bool) ([]model.BulkResult, error) {
+	return bulkWrite(ctx, r.dbPool, albumBulk, items, atomic)
 }
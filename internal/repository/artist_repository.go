@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"slices"
 
 	"github.com/liamcoleman/music-go/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,9 +22,41 @@ func NewArtistRepository(dbPool *pgxpool.Pool) *ArtistRepository {
 	}
 }
 
-func (r *ArtistRepository) GetArtists(ctx context.Context) ([]model.Artist, error) {
-	query := `SELECT id, name, description FROM artist WHERE archived = FALSE`
-	rows, err := r.dbPool.Query(ctx, query)
+var artistColumns = map[string]string{
+	"id":          "id",
+	"name":        "COALESCE(name, '')",
+	"description": "COALESCE(description, '')",
+}
+
+var artistOrder = []orderColumn{
+	{field: "name", expr: artistColumns["name"]},
+	{field: "id", expr: artistColumns["id"]},
+}
+
+func artistValue(artist model.Artist, field string) any {
+	switch field {
+	case "id":
+		return artist.ID
+	case "name":
+		return artist.Name
+	}
+
+	return nil
+}
+
+func (r *ArtistRepository) GetArtists(ctx context.Context, params model.ListParams) (*model.Page[model.Artist], error) {
+	l, err := newListing(params, artistColumns, artistOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{}
+	query, err := l.query(`SELECT id, name, description FROM artist WHERE archived = `+addArg(&args, params.Archived), &args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbPool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,14 +77,14 @@ func (r *ArtistRepository) GetArtists(ctx context.Context) ([]model.Artist, erro
 		return nil, err
 	}
 
-	return artists, nil
+	return page(l, artists, artistValue), nil
 }
 
 func (r *ArtistRepository) GetArtist(ctx context.Context, id string) (*model.ArtistWithAlbums, error) {
 	var artist model.ArtistWithAlbums
-	query := `SELECT id, name, description FROM artist WHERE id = $1 AND archived = FALSE`
+	query := `SELECT id, name, description, version FROM artist WHERE id = $1 AND archived = FALSE`
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&artist.ID, &artist.Name, &artist.Description)
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&artist.ID, &artist.Name, &artist.Description, &artist.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +94,20 @@ func (r *ArtistRepository) GetArtist(ctx context.Context, id string) (*model.Art
 		return nil, err
 	}
 
+	artist.Appearances, err = r.GetAppearancesForArtist(ctx, artist.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &artist, nil
 
 }
 
+// GetAlbumsForArtist lists the live albums of an artist, latest release
+// first.
 func (r *ArtistRepository) GetAlbumsForArtist(ctx context.Context, artistID int) ([]model.Album, error) {
 
-	query := `SELECT id, name, release_year FROM album WHERE artist_id = $1 AND archived = FALSE ORDER BY release_year DESC`
+	query := `SELECT id, name, release_year, release_date, release_type FROM album WHERE artist_id = $1 AND archived = FALSE ORDER BY release_date DESC, id`
 	rows, err := r.dbPool.Query(ctx, query, artistID)
 	if err != nil {
 		return nil, err
@@ -75,7 +118,7 @@ func (r *ArtistRepository) GetAlbumsForArtist(ctx context.Context, artistID int)
 
 	for rows.Next() {
 		var album model.Album
-		if err := rows.Scan(&album.ID, &album.Name, &album.ReleaseYear); err != nil {
+		if err := rows.Scan(&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType); err != nil {
 			return nil, err
 		}
 
@@ -86,14 +129,89 @@ func (r *ArtistRepository) GetAlbumsForArtist(ctx context.Context, artistID int)
 		return nil, err
 	}
 
+	if err := loadAlbumCredits(ctx, r.dbPool, albumCreditsByID(albums)); err != nil {
+		return nil, err
+	}
+
 	return albums, nil
 }
 
+// GetAppearancesForArtist lists the live albums of other artists, or
+// compilations, that credit an artist, on the album or on its live songs,
+// latest release first. A song the artist is the own artist of credits them
+// as its primary artist.
+func (r *ArtistRepository) GetAppearancesForArtist(ctx context.Context, artistID int) ([]model.Appearance, error) {
+
+	query := `SELECT album.id, album.name, album.release_year, album.release_date, album.release_type, album.compilation, COALESCE(owner.name, '` + model.VariousArtists + `'), song.id, song.title, credit.role
+				FROM (
+					SELECT album_id, NULL::bigint AS song_id, position, role
+					FROM album_artist WHERE artist_id = $1
+					UNION ALL
+					SELECT album_id, id, 0, 'primary'
+					FROM song WHERE artist_id = $1 AND archived = FALSE
+					UNION ALL
+					SELECT song.album_id, song.id, song_artist.position, song_artist.role
+					FROM song_artist JOIN song ON song.id = song_artist.song_id
+					WHERE song_artist.artist_id = $1 AND song.archived = FALSE
+				) credit
+				JOIN album ON album.id = credit.album_id
+				LEFT JOIN artist owner ON owner.id = album.artist_id
+				LEFT JOIN song ON song.id = credit.song_id
+				WHERE album.artist_id IS DISTINCT FROM $1 AND album.archived = FALSE
+				ORDER BY album.release_date DESC, album.id, song.disc_number NULLS FIRST, song.track_number, song.id, credit.position`
+	rows, err := r.dbPool.Query(ctx, query, artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	appearances := []model.Appearance{}
+
+	var album model.Album
+	var songID *int
+	var songTitle *string
+	var role model.ArtistRole
+	_, err = pgx.ForEachRow(rows, []any{&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType, &album.Compilation, &album.ArtistName, &songID, &songTitle, &role}, func() error {
+		if n := len(appearances); n == 0 || appearances[n-1].ID != album.ID {
+			appearances = append(appearances, model.Appearance{Album: album, Roles: []model.ArtistRole{}})
+		}
+		appearance := &appearances[len(appearances)-1]
+
+		if songID == nil {
+			appearance.Roles = append(appearance.Roles, role)
+			return nil
+		}
+
+		if n := len(appearance.Songs); n == 0 || appearance.Songs[n-1].ID != *songID {
+			appearance.Songs = append(appearance.Songs, model.SongAppearance{ID: *songID, Title: *songTitle})
+		}
+		song := &appearance.Songs[len(appearance.Songs)-1]
+		song.Roles = append(song.Roles, role)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make(map[int]*model.Credits, len(appearances))
+	for i := range appearances {
+		credits[appearances[i].ID] = &appearances[i].Credits
+	}
+
+	if err := loadAlbumCredits(ctx, r.dbPool, credits); err != nil {
+		return nil, err
+	}
+
+	return appearances, nil
+}
+
 func (r *ArtistRepository) CreateArtist(ctx context.Context, artist model.CreateArtist) (*model.Artist, error) {
 
 	var createdArtist model.Artist
-	query := `INSERT INTO artist (name, description, archived) VALUES ($1, $2, FALSE) RETURNING id, name, description`
-	err := r.dbPool.QueryRow(ctx, query, artist.Name, artist.Description).Scan(&createdArtist.ID, &createdArtist.Name, &createdArtist.Description)
+	query := `INSERT INTO artist (name, description, archived) VALUES ($1, $2, FALSE) RETURNING id, name, description, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, artist.Name, artist.Description).Scan(&createdArtist.ID, &createdArtist.Name, &createdArtist.Description, &createdArtist.Version)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -102,12 +220,69 @@ func (r *ArtistRepository) CreateArtist(ctx context.Context, artist model.Create
 
 }
 
-func (r *ArtistRepository) UpdateArtist(ctx context.Context, artist model.UpdateArtist, id string) (*model.Artist, error) {
+// CreateArtistTree creates an artist with its albums and their songs in one
+// transaction.
+func (r *ArtistRepository) CreateArtistTree(ctx context.Context, artist model.ArtistTree) (*model.ArtistWithAlbums, error) {
+
+	createdArtist := model.ArtistWithAlbums{Albums: []model.Album{}, Appearances: []model.Appearance{}}
+	artists := map[int][]model.CreditArtist{}
+
+	query := `INSERT INTO artist (name, description, archived) VALUES ($1, $2, FALSE) RETURNING id, name, description, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, artist.Name, artist.Description).Scan(&createdArtist.ID, &createdArtist.Name, &createdArtist.Description, &createdArtist.Version)
+		if err != nil {
+			return err
+		}
+
+		for _, album := range artist.Albums {
+			var createdAlbum model.Album
+
+			year, date := model.Release(album.ReleaseYear, album.ReleaseDate)
+
+			query2 := `INSERT INTO album (artist_id, name, release_year, release_date, release_type, archived) VALUES ($1, $2, $3, $4, $5, FALSE) RETURNING id, name, release_year, release_date, release_type`
+			err := tx.QueryRow(ctx, query2, createdArtist.ID, album.Name, year, date, cmp.Or(album.ReleaseType, model.ReleaseLP)).Scan(&createdAlbum.ID, &createdAlbum.Name, &createdAlbum.ReleaseYear, &createdAlbum.ReleaseDate, &createdAlbum.ReleaseType)
+			if err != nil {
+				return err
+			}
+
+			if err := insertTreeSongs(ctx, tx, createdAlbum.ID, false, album.Songs); err != nil {
+				return err
+			}
+
+			createdArtist.Albums = append(createdArtist.Albums, createdAlbum)
+			if len(album.Artists) > 0 {
+				artists[createdAlbum.ID] = album.Artists
+			}
+		}
+
+		if err := replaceCredits(ctx, tx, albumCredits, artists); err != nil {
+			return err
+		}
+
+		return loadAlbumCredits(ctx, tx, albumCreditsByID(createdArtist.Albums))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// List the albums the way GetArtist does.
+	slices.SortFunc(createdArtist.Albums, model.CompareReleases)
+
+	return &createdArtist, nil
+}
+
+func (r *ArtistRepository) UpdateArtist(ctx context.Context, artist model.UpdateArtist, id string, ifMatch []int) (*model.Artist, error) {
 	var updatedArtist model.Artist
 
-	query := `UPDATE artist SET name = $2, description = $3 WHERE id = $1 AND archived = FALSE RETURNING id, name, description`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "artist", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `UPDATE artist SET name = $2, description = $3 WHERE id = $1 RETURNING id, name, description, version`
 
-	err := r.dbPool.QueryRow(ctx, query, id, artist.Name, artist.Description).Scan(&updatedArtist.ID, &updatedArtist.Name, &updatedArtist.Description)
+		return tx.QueryRow(ctx, query, id, artist.Name, artist.Description).Scan(&updatedArtist.ID, &updatedArtist.Name, &updatedArtist.Description, &updatedArtist.Version)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -116,60 +291,412 @@ func (r *ArtistRepository) UpdateArtist(ctx context.Context, artist model.Update
 
 }
 
-func (r *ArtistRepository) PatchArtist(ctx context.Context, artist model.PatchArtist, id string) (*model.Artist, error) {
+func (r *ArtistRepository) PatchArtist(ctx context.Context, artist model.PatchArtist, id string, ifMatch []int) (*model.Artist, error) {
 
 	var patchedArtist model.Artist
 
+	args := []any{id}
+	sets := artistSets(artist, &args)
+
+	query := patchQuery("artist", sets, "id = $1 AND archived = FALSE"+versionCondition(&args, ifMatch), "id, name, description, version")
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, args...).Scan(&patchedArtist.ID, &patchedArtist.Name, &patchedArtist.Description, &patchedArtist.Version)
+		if errors.Is(err, pgx.ErrNoRows) && ifMatch != nil {
+			return missingOrModified(ctx, tx, "artist", id)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchedArtist, nil
+}
+
+// artistSets lists the column assignments for the fields of a patch that are
+// set.
+func artistSets(artist model.PatchArtist, args *[]any) []string {
+	sets := []string{}
+
 	if artist.Name != nil {
-		queryName := `UPDATE artist SET name = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryName, id, artist.Name)
+		sets = append(sets, "name = "+addArg(args, *artist.Name))
+	}
+
+	if artist.Description != nil {
+		sets = append(sets, "description = "+addArg(args, *artist.Description))
+	}
+
+	return sets
+}
+
+// ChangeArtist locks the artist, works out a patch from its current state
+// with change and applies it, all in one transaction.
+func (r *ArtistRepository) ChangeArtist(ctx context.Context, id string, ifMatch []int, change func(model.Artist) (model.PatchArtist, error)) (*model.Artist, error) {
+
+	var changedArtist model.Artist
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var artist model.Artist
+		query := `SELECT id, name, description, version FROM artist WHERE id = $1 AND archived = FALSE FOR UPDATE`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&artist.ID, &artist.Name, &artist.Description, &artist.Version)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if !matchesVersion(ifMatch, artist.Version) {
+			return ErrVersionMismatch
 		}
+
+		patch, err := change(artist)
+		if err != nil {
+			return err
+		}
+
+		args := []any{artist.ID}
+		query2 := patchQuery("artist", artistSets(patch, &args), "id = $1", "id, name, description, version")
+
+		return tx.QueryRow(ctx, query2, args...).Scan(&changedArtist.ID, &changedArtist.Name, &changedArtist.Description, &changedArtist.Version)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if artist.Description != nil {
-		queryDescription := `UPDATE artist SET description = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryDescription, id, artist.Description)
+	return &changedArtist, nil
+}
+
+func (r *ArtistRepository) DeleteArtist(ctx context.Context, id string, ifMatch []int) (*model.ArchivedArtist, error) {
+
+	var archivedArtist model.ArchivedArtist
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "artist", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `UPDATE artist SET archived = TRUE, archived_at = now() WHERE id = $1 AND archived = FALSE RETURNING id`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&archivedArtist.ID)
 		if err != nil {
-			return nil, err
+			return err
 		}
+
+		query2 := `UPDATE song SET archived = TRUE, archived_at = now()
+					FROM album
+					WHERE song.album_id = album.id AND album.artist_id = $1 AND song.archived = FALSE`
+
+		tag, err := tx.Exec(ctx, query2, id)
+		if err != nil {
+			return err
+		}
+		archivedArtist.SongsArchived = int(tag.RowsAffected())
+
+		query3 := `UPDATE album SET archived = TRUE, archived_at = now() WHERE artist_id = $1 AND archived = FALSE`
+
+		tag, err = tx.Exec(ctx, query3, id)
+		if err != nil {
+			return err
+		}
+		archivedArtist.AlbumsArchived = int(tag.RowsAffected())
+
+		// The artist's songs on compilations and other artists' albums go
+		// too, closing the gaps they leave, last track first.
+		query4 := `UPDATE song SET archived = TRUE, archived_at = now()
+					FROM album
+					WHERE song.album_id = album.id AND song.artist_id = $1 AND album.artist_id IS DISTINCT FROM $1 AND song.archived = FALSE
+					RETURNING song.album_id, song.disc_number, song.track_number`
+
+		rows, err := tx.Query(ctx, query4, id)
+		if err != nil {
+			return err
+		}
+
+		type trackGap struct {
+			albumID, disc, track int
+		}
+
+		var gaps []trackGap
+		var gap trackGap
+		_, err = pgx.ForEachRow(rows, []any{&gap.albumID, &gap.disc, &gap.track}, func() error {
+			gaps = append(gaps, gap)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		archivedArtist.OtherSongsArchived = len(gaps)
+
+		slices.SortFunc(gaps, func(a, b trackGap) int { return cmp.Compare(b.track, a.track) })
+
+		for _, gap := range gaps {
+			if err := closeTrackGap(ctx, tx, gap.albumID, gap.disc, gap.track); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	query := `SELECT id, name, description FROM artist WHERE id = $1 AND archived = FALSE`
+	return &archivedArtist, nil
+}
+
+func (r *ArtistRepository) RestoreArtist(ctx context.Context, id string, cascade bool) (*model.RestoredArtist, error) {
+
+	var restoredArtist model.RestoredArtist
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&patchedArtist.ID, &patchedArtist.Name, &patchedArtist.Description)
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		query := `UPDATE artist SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE RETURNING id`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&restoredArtist.ID)
+		if err != nil {
+			return err
+		}
+
+		if !cascade {
+			return nil
+		}
+
+		query2 := `UPDATE album SET archived = FALSE, archived_at = NULL WHERE artist_id = $1 AND archived = TRUE`
+
+		tag, err := tx.Exec(ctx, query2, id)
+		if err != nil {
+			return err
+		}
+		restoredArtist.AlbumsRestored = int(tag.RowsAffected())
+
+		if err := deferTrackNumbers(ctx, tx); err != nil {
+			return err
+		}
+
+		query3 := `UPDATE song SET archived = FALSE, archived_at = NULL
+					FROM album
+					WHERE song.album_id = album.id AND album.artist_id = $1 AND song.archived = TRUE
+					RETURNING song.id`
+
+		rows, err := tx.Query(ctx, query3, id)
+		if err != nil {
+			return err
+		}
+
+		songIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+		restoredArtist.SongsRestored = len(songIDs)
+
+		query4 := `UPDATE song SET archived = FALSE, archived_at = NULL
+					FROM album
+					WHERE song.album_id = album.id AND song.artist_id = $1 AND album.artist_id IS DISTINCT FROM $1
+						AND album.archived = FALSE AND song.archived = TRUE
+					RETURNING song.id`
+
+		rows, err = tx.Query(ctx, query4, id)
+		if err != nil {
+			return err
+		}
+
+		otherSongIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+		restoredArtist.OtherSongsRestored = len(otherSongIDs)
+
+		return placeRestoredSongs(ctx, tx, append(songIDs, otherSongIDs...))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &patchedArtist, nil
+	return &restoredArtist, nil
+}
+
+func (r *ArtistRepository) MatchArtists(ctx context.Context, params model.MatchParams) ([]model.ArtistMatch, error) {
+
+	artists := []model.ArtistMatch{}
+
+	err := pgx.BeginFunc(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := setSimilarityThreshold(ctx, tx, params.Threshold); err != nil {
+			return err
+		}
+
+		query := `SELECT id, name, description, similarity(lower(name), lower($1)) AS score
+					FROM artist
+					WHERE archived = FALSE AND lower(name) % lower($1)
+					ORDER BY score DESC, id
+					LIMIT $2`
+
+		rows, err := tx.Query(ctx, query, params.Name, params.Limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var artist model.ArtistMatch
+			if err := rows.Scan(&artist.ID, &artist.Name, &artist.Description, &artist.Score); err != nil {
+				return err
+			}
+
+			artists = append(artists, artist)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return artists, nil
 }
 
-func (r *ArtistRepository) DeleteArtist(ctx context.Context, id string) error {
+func (r *ArtistRepository) FindOrCreateArtist(ctx context.Context, artist model.CreateArtist, threshold float64) (*model.Artist, bool, error) {
+
+	var foundArtist model.Artist
+	created := false
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, findOrCreateLockID)
+		if err != nil {
+			return err
+		}
+
+		if err := setSimilarityThreshold(ctx, tx, threshold); err != nil {
+			return err
+		}
+
+		query := `SELECT id, name, description, version
+					FROM artist
+					WHERE archived = FALSE AND lower(name) % lower($1)
+					ORDER BY similarity(lower(name), lower($1)) DESC, id
+					LIMIT 1`
+
+		err = tx.QueryRow(ctx, query, artist.Name).Scan(&foundArtist.ID, &foundArtist.Name, &foundArtist.Description, &foundArtist.Version)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
 
-	// TODO: Cascade and set albums / songs as well
-	// query := `DELETE FROM artist where id = $1`
-	query := `UPDATE artist SET archived = TRUE WHERE id = $1`
+		query2 := `INSERT INTO artist (name, description, archived) VALUES ($1, $2, FALSE) RETURNING id, name, description, version`
 
-	_, err := r.dbPool.Exec(ctx, query, id)
+		created = true
+		return tx.QueryRow(ctx, query2, artist.Name, artist.Description).Scan(&foundArtist.ID, &foundArtist.Name, &foundArtist.Description, &foundArtist.Version)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &foundArtist, created, nil
+}
+
+func (r *ArtistRepository) MergeArtist(ctx context.Context, id string, targetID int) (*model.MergedArtist, error) {
+
+	mergedArtist := model.MergedArtist{TargetID: targetID}
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		sourceID, err := lockForMerge(ctx, tx, "artist", id, targetID)
+		if err != nil {
+			return err
+		}
+		mergedArtist.ID = sourceID
+
+		query := `UPDATE album SET artist_id = $2 WHERE artist_id = $1`
+
+		tag, err := tx.Exec(ctx, query, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		mergedArtist.AlbumsMoved = int(tag.RowsAffected())
+
+		query2 := `UPDATE song SET artist_id = $2 WHERE artist_id = $1`
+
+		_, err = tx.Exec(ctx, query2, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+
+		if err := mergeCredits(ctx, tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		query3 := `UPDATE artist SET archived = TRUE, archived_at = now() WHERE id = $1`
+
+		_, err = tx.Exec(ctx, query3, sourceID)
+		if err != nil {
+			return err
+		}
+
+		return recordRedirect(ctx, tx, "artist", sourceID, targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergedArtist, nil
+}
+
+// mergeCredits moves the credits of an artist being merged to the target,
+// dropping those that would credit the target twice on an album or song.
+func mergeCredits(ctx context.Context, tx pgx.Tx, sourceID, targetID int) error {
+	query := `DELETE FROM album_artist credit USING album
+				WHERE credit.artist_id = $1 AND album.id = credit.album_id
+				AND (album.artist_id = $2 OR EXISTS (SELECT 1 FROM album_artist other WHERE other.album_id = credit.album_id AND other.artist_id = $2))`
+
+	_, err := tx.Exec(ctx, query, sourceID, targetID)
 	if err != nil {
 		return err
 	}
 
-	query2 := `UPDATE album SET archived = TRUE WHERE artist_id = $1`
+	query2 := `DELETE FROM song_artist credit USING song JOIN album ON album.id = song.album_id
+				WHERE credit.artist_id = $1 AND song.id = credit.song_id
+				AND (COALESCE(song.artist_id, album.artist_id) = $2 OR EXISTS (SELECT 1 FROM song_artist other WHERE other.song_id = credit.song_id AND other.artist_id = $2))`
 
-	_, err = r.dbPool.Exec(ctx, query2, id)
+	_, err = tx.Exec(ctx, query2, sourceID, targetID)
 	if err != nil {
 		return err
 	}
 
-	query3 := `UPDATE song SET archived = TRUE FROM album WHERE song.album_id = album.id AND album.id = $1`
+	query3 := `UPDATE album_artist SET artist_id = $2 WHERE artist_id = $1`
 
-	_, err = r.dbPool.Exec(ctx, query3, id)
+	_, err = tx.Exec(ctx, query3, sourceID, targetID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	query4 := `UPDATE song_artist SET artist_id = $2 WHERE artist_id = $1`
+
+	_, err = tx.Exec(ctx, query4, sourceID, targetID)
+	return err
+}
+
+func (r *ArtistRepository) ArtistRedirect(ctx context.Context, id string) (int, error) {
+	var targetID int
+	query := `SELECT target_id FROM artist_redirect WHERE artist_id = $1`
+
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	return targetID, nil
+}
+
+var artistBulk = bulkTable[model.CreateArtist, model.UpdateArtist]{
+	table:   "artist",
+	columns: []string{"name", "description"},
+	values: func(artist model.CreateArtist) []any {
+		return []any{artist.Name, artist.Description}
+	},
+	update: func(id int, artist model.UpdateArtist) (string, []any) {
+		query := `UPDATE artist SET name = $2, description = $3 WHERE id = $1 AND archived = FALSE RETURNING id`
+		return query, []any{id, artist.Name, artist.Description}
+	},
+}
+
+// BulkArtists creates and replaces artists in one transaction.
+func (r *ArtistRepository) BulkArtists(ctx context.Context, items []model.BulkItem[model.CreateArtist, model.UpdateArtist], atomic bool) ([]model.BulkResult, error) {
+	return bulkWrite(ctx, r.dbPool, artistBulk, items, atomic)
 }
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// bulkTable describes how the items of a bulk write map onto a table.
+type bulkTable[C, U any] struct {
+	table string
+	// columns are copied into for new rows, after id.
+	columns []string
+	values  func(C) []any
+	// parentTable and parent identify the live row a new row must belong
+	// to. Artists have no parent.
+	parentTable string
+	parent      func(C) int
+	// update returns the statement and arguments replacing a live row. It
+	// must return the row's id.
+	update func(id int, item U) (string, []any)
+	// plan, if set, vets the items against the rows already stored once
+	// their parents are locked, failing those that can't be written in
+	// order. It may fill in what new rows leave to the store.
+	plan func(ctx context.Context, tx pgx.Tx, items []model.BulkItem[C, U], results []model.BulkResult, fail func(int, error) error) error
+	// credits, if set, returns the artists an item credits, which replace
+	// the row's credits in creditTable once it is written.
+	credits     func(item model.BulkItem[C, U]) []model.CreditArtist
+	creditTable creditTable
+}
+
+// bulkWrite writes the items in one transaction. New rows are copied in with
+// ids drawn from the table's sequence, since COPY can't return them, and if
+// the COPY fails they are inserted one at a time, each in its own savepoint.
+// When atomic is set, updates are sent as one batch and the first item that
+// fails rolls back the whole write with a *BulkError. Otherwise each update
+// runs in its own savepoint too, so failed items are skipped and reported in
+// their results while the rest are written.
+func bulkWrite[C, U any](ctx context.Context, dbPool *pgxpool.Pool, t bulkTable[C, U], items []model.BulkItem[C, U], atomic bool) ([]model.BulkResult, error) {
+	results := make([]model.BulkResult, len(items))
+
+	fail := func(i int, err error) error {
+		if atomic {
+			return &BulkError{Index: i, Err: Translate(err)}
+		}
+
+		results[i].Err = Translate(err)
+		return nil
+	}
+
+	err := writeTx(ctx, dbPool, func(tx pgx.Tx) error {
+		if t.parent != nil {
+			if err := lockParents(ctx, tx, t, items, fail); err != nil {
+				return err
+			}
+		}
+
+		if t.plan != nil {
+			if err := t.plan(ctx, tx, items, results, fail); err != nil {
+				return err
+			}
+		}
+
+		if t.credits != nil {
+			if err := checkCredits(ctx, tx, t, items, results, fail); err != nil {
+				return err
+			}
+		}
+
+		batch := &pgx.Batch{}
+		updated := []int{}
+
+		for i, item := range items {
+			if item.ID == 0 || results[i].Err != nil {
+				continue
+			}
+
+			query, args := t.update(item.ID, item.Update)
+			batch.Queue(query, args...)
+			updated = append(updated, i)
+		}
+
+		if batch.Len() > 0 {
+			send := sendUpdates
+			if !atomic {
+				send = sendUpdatesApart
+			}
+
+			if err := send(ctx, tx, batch, updated, results, fail); err != nil {
+				return err
+			}
+		}
+
+		created := []int{}
+		for i, item := range items {
+			if item.ID == 0 && results[i].Err == nil {
+				created = append(created, i)
+			}
+		}
+
+		if len(created) > 0 {
+			if err := copyRows(ctx, tx, t, items, created, results, fail); err != nil {
+				return err
+			}
+		}
+
+		if t.credits == nil {
+			return nil
+		}
+
+		artists := map[int][]model.CreditArtist{}
+		for i, item := range items {
+			if results[i].Err == nil {
+				artists[results[i].ID] = t.credits(item)
+			}
+		}
+
+		return replaceCredits(ctx, tx, t.creditTable, artists)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// copyRows copies in the new rows of the items at the given indexes, with
+// ids drawn from the table's sequence. A failed COPY doesn't say which row
+// was refused, so it is undone and the rows are inserted one at a time
+// instead, failing the items whose rows are refused. In an atomic write the
+// first of them ends the write, naming the item.
+func copyRows[C, U any](ctx context.Context, tx pgx.Tx, t bulkTable[C, U], items []model.BulkItem[C, U], created []int, results []model.BulkResult, fail func(int, error) error) error {
+	query := `SELECT nextval(pg_get_serial_sequence($1, 'id')) FROM generate_series(1, $2)`
+
+	rows, err := tx.Query(ctx, query, t.table, len(created))
+	if err != nil {
+		return err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+
+	copied := make([][]any, len(created))
+	for n, i := range created {
+		results[i].ID = ids[n]
+		results[i].Created = true
+		copied[n] = append([]any{ids[n], false}, t.values(items[i].Create)...)
+	}
+
+	columns := append([]string{"id", "archived"}, t.columns...)
+
+	err = savepoint(ctx, tx, func(sp pgx.Tx) error {
+		_, err := sp.CopyFrom(ctx, pgx.Identifier{t.table}, columns, pgx.CopyFromRows(copied))
+		return err
+	})
+	if !itemError(err) {
+		return err
+	}
+
+	placeholders := make([]string, len(columns))
+	for n := range columns {
+		placeholders[n] = "$" + strconv.Itoa(n+1)
+	}
+
+	query2 := `INSERT INTO ` + pgx.Identifier{t.table}.Sanitize() + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+
+	for n, i := range created {
+		err := savepoint(ctx, tx, func(sp pgx.Tx) error {
+			_, err := sp.Exec(ctx, query2, copied[n]...)
+			return err
+		})
+		if !itemError(err) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		results[i].ID = 0
+		results[i].Created = false
+		if err := fail(i, err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCredits fails the items that credit an artist who isn't live and
+// locks the others' artists until the write commits.
+func checkCredits[C, U any](ctx context.Context, tx pgx.Tx, t bulkTable[C, U], items []model.BulkItem[C, U], results []model.BulkResult, fail func(int, error) error) error {
+	artistIDs := []int{}
+	for i, item := range items {
+		if results[i].Err != nil {
+			continue
+		}
+
+		for _, credit := range t.credits(item) {
+			artistIDs = append(artistIDs, credit.ArtistID)
+		}
+	}
+
+	live, err := liveArtists(ctx, tx, artistIDs)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if results[i].Err != nil {
+			continue
+		}
+
+		for _, credit := range t.credits(item) {
+			if !live[credit.ArtistID] {
+				if err := fail(i, ErrCreditedArtistNotFound); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockParents fails the new rows whose parent isn't live and locks the
+// others' parents until the write commits. A parent of 0 is none, as for a
+// compilation, which has no artist.
+func lockParents[C, U any](ctx context.Context, tx pgx.Tx, t bulkTable[C, U], items []model.BulkItem[C, U], fail func(int, error) error) error {
+	parentIDs := []int{}
+	for _, item := range items {
+		if item.ID == 0 {
+			parentIDs = append(parentIDs, t.parent(item.Create))
+		}
+	}
+
+	query := `SELECT id FROM ` + t.parentTable + ` WHERE id = ANY($1) AND archived = FALSE ` + parentLock(t.parentTable)
+
+	rows, err := tx.Query(ctx, query, parentIDs)
+	if err != nil {
+		return err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+
+	live := map[int]bool{}
+	for _, id := range ids {
+		live[id] = true
+	}
+
+	for i, item := range items {
+		if parentID := t.parent(item.Create); item.ID == 0 && parentID != 0 && !live[parentID] {
+			if err := fail(i, ErrParentNotFound); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sendUpdates runs the batched updates of the items at the given indexes. An
+// update that finds no live row or is refused fails its item, which ends the
+// batch.
+func sendUpdates(ctx context.Context, tx pgx.Tx, batch *pgx.Batch, indexes []int, results []model.BulkResult, fail func(int, error) error) error {
+	br := tx.SendBatch(ctx, batch)
+
+	for _, i := range indexes {
+		err := br.QueryRow().Scan(&results[i].ID)
+		if itemError(err) {
+			err = fail(i, err)
+		}
+
+		if err != nil {
+			br.Close()
+			return err
+		}
+	}
+
+	return br.Close()
+}
+
+// sendUpdatesApart runs the updates of the items at the given indexes one at
+// a time, each in its own savepoint, so that an update the database refuses
+// only fails its item.
+func sendUpdatesApart(ctx context.Context, tx pgx.Tx, batch *pgx.Batch, indexes []int, results []model.BulkResult, fail func(int, error) error) error {
+	for n, i := range indexes {
+		queued := batch.QueuedQueries[n]
+
+		err := savepoint(ctx, tx, func(sp pgx.Tx) error {
+			return sp.QueryRow(ctx, queued.SQL, queued.Arguments...).Scan(&results[i].ID)
+		})
+		if itemError(err) {
+			err = fail(i, err)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// savepoint runs fn in a savepoint of tx and rolls back to it if fn fails,
+// leaving the rest of the transaction usable.
+func savepoint(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(sp); err != nil {
+		if rollbackErr := sp.Rollback(ctx); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+
+	return sp.Commit(ctx)
+}
+
+// itemError reports whether err is the database refusing a single row: no
+// live row to update, or data that breaks a constraint or doesn't fit its
+// column. Any other error ends the whole write.
+func itemError(err error) bool {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+	}
+
+	return false
+}
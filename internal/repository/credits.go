@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryer is what credits are read through: the pool, or the transaction
+// that just wrote them.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// creditTable is where the credits of albums or songs are kept, by the id
+// of the album or song in owner.
+type creditTable struct {
+	table string
+	owner string
+}
+
+var (
+	albumCredits = creditTable{table: "album_artist", owner: "album_id"}
+	songCredits  = creditTable{table: "song_artist", owner: "song_id"}
+)
+
+// loadAlbumCredits fills in the credits of albums, given by id: the album's
+// artist, then the others it credits.
+func loadAlbumCredits(ctx context.Context, q queryer, credits map[int]*model.Credits) error {
+	query := `SELECT credit.album_id, credit.artist_id, artist.name, credit.role, credit.join_phrase
+				FROM (
+					SELECT id AS album_id, 0 AS position, artist_id, 'primary' AS role, '' AS join_phrase
+					FROM album WHERE id = ANY($1)
+					UNION ALL
+					SELECT album_id, position, artist_id, role, join_phrase
+					FROM album_artist WHERE album_id = ANY($1)
+				) credit
+				JOIN artist ON artist.id = credit.artist_id
+				ORDER BY credit.album_id, credit.position`
+
+	return readCredits(ctx, q, query, credits)
+}
+
+// loadSongCredits fills in the credits of songs, given by id: their own
+// artist if they have one and otherwise their album's credits, then the
+// artists the song credits itself.
+func loadSongCredits(ctx context.Context, q queryer, credits map[int]*model.Credits) error {
+	query := `SELECT credit.song_id, credit.artist_id, artist.name, credit.role, credit.join_phrase
+				FROM (
+					SELECT song.id AS song_id, 0 AS source, 0 AS position, COALESCE(song.artist_id, album.artist_id) AS artist_id, 'primary' AS role, '' AS join_phrase
+					FROM song JOIN album ON album.id = song.album_id WHERE song.id = ANY($1)
+					UNION ALL
+					SELECT song.id, 1, album_artist.position, album_artist.artist_id, album_artist.role, album_artist.join_phrase
+					FROM song JOIN album_artist ON album_artist.album_id = song.album_id WHERE song.id = ANY($1) AND song.artist_id IS NULL
+					UNION ALL
+					SELECT song_id, 2, position, artist_id, role, join_phrase
+					FROM song_artist WHERE song_id = ANY($1)
+				) credit
+				JOIN artist ON artist.id = credit.artist_id
+				ORDER BY credit.song_id, credit.source, credit.position`
+
+	return readCredits(ctx, q, query, credits)
+}
+
+func readCredits(ctx context.Context, q queryer, query string, credits map[int]*model.Credits) error {
+	if len(credits) == 0 {
+		return nil
+	}
+
+	rows, err := q.Query(ctx, query, slices.Collect(maps.Keys(credits)))
+	if err != nil {
+		return err
+	}
+
+	artists := map[int][]model.ArtistCredit{}
+
+	var id int
+	var artist model.ArtistCredit
+	_, err = pgx.ForEachRow(rows, []any{&id, &artist.ArtistID, &artist.Name, &artist.Role, &artist.JoinPhrase}, func() error {
+		artists[id] = append(artists[id], artist)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for id, c := range credits {
+		*c = model.NewCredits(artists[id])
+	}
+
+	return nil
+}
+
+// albumCreditsByID returns the credits of albums for loadAlbumCredits to fill
+// in.
+func albumCreditsByID(albums []model.Album) map[int]*model.Credits {
+	credits := make(map[int]*model.Credits, len(albums))
+	for i := range albums {
+		credits[albums[i].ID] = &albums[i].Credits
+	}
+
+	return credits
+}
+
+// songCreditsByID returns the credits of songs for loadSongCredits to fill
+// in.
+func songCreditsByID(songs []model.Song) map[int]*model.Credits {
+	credits := make(map[int]*model.Credits, len(songs))
+	for i := range songs {
+		credits[songs[i].ID] = &songs[i].Credits
+	}
+
+	return credits
+}
+
+// replaceCredits replaces the credits of the albums or songs in artists, by
+// id, with the artists given for each. The artists must be live; they are
+// locked so they can't be archived before the credits commit.
+func replaceCredits(ctx context.Context, tx pgx.Tx, t creditTable, artists map[int][]model.CreditArtist) error {
+	if len(artists) == 0 {
+		return nil
+	}
+
+	artistIDs := []int{}
+	for _, credits := range artists {
+		for _, credit := range credits {
+			artistIDs = append(artistIDs, credit.ArtistID)
+		}
+	}
+
+	live, err := liveArtists(ctx, tx, artistIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range artistIDs {
+		if !live[id] {
+			return ErrCreditedArtistNotFound
+		}
+	}
+
+	query := `DELETE FROM ` + t.table + ` WHERE ` + t.owner + ` = ANY($1)`
+
+	_, err = tx.Exec(ctx, query, slices.Collect(maps.Keys(artists)))
+	if err != nil {
+		return err
+	}
+
+	var owners, positions, ids []int
+	var roles, joinPhrases []string
+
+	for owner, credits := range artists {
+		for i, credit := range credits {
+			credit = credit.Settled()
+			owners = append(owners, owner)
+			positions = append(positions, i+1)
+			ids = append(ids, credit.ArtistID)
+			roles = append(roles, string(credit.Role))
+			joinPhrases = append(joinPhrases, credit.JoinPhrase)
+		}
+	}
+
+	if len(owners) == 0 {
+		return nil
+	}
+
+	query2 := `INSERT INTO ` + t.table + ` (` + t.owner + `, position, artist_id, role, join_phrase)
+				SELECT * FROM unnest($1::bigint[], $2::integer[], $3::bigint[], $4::text[], $5::text[])`
+
+	_, err = tx.Exec(ctx, query2, owners, positions, ids, roles, joinPhrases)
+	return err
+}
+
+// liveArtists returns which of the artists exist and aren't archived,
+// locking them until the transaction ends.
+func liveArtists(ctx context.Context, tx pgx.Tx, ids []int) (map[int]bool, error) {
+	live := map[int]bool{}
+	if len(ids) == 0 {
+		return live, nil
+	}
+
+	query := `SELECT id FROM artist WHERE id = ANY($1) AND archived = FALSE FOR SHARE`
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range found {
+		live[id] = true
+	}
+
+	return live, nil
+}
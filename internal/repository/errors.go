@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// The kinds of repository error. Every *Error is one of these, so callers can
+// test for a kind with errors.Is.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrValidation          = errors.New("validation failed")
+)
+
+// Error is a repository error of one of the kinds above. Detail describes it
+// in terms fit for clients and is empty when the kind says it all. Err is the
+// database error it was translated from, if any.
+type Error struct {
+	Kind   error
+	Detail string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Err}
+}
+
+// Translate classifies an error from pgx, Postgres or the memory store as an
+// *Error. Errors that are already classified, and those that can't be, are
+// returned as they are.
+func Translate(err error) error {
+	var repoErr *Error
+	if err == nil || errors.As(err, &repoErr) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case "23503":
+		return &Error{Kind: ErrForeignKeyViolation, Detail: pgErr.Message, Err: err}
+	case "23505":
+		if pgErr.ConstraintName == personCreditAlbumKey || pgErr.ConstraintName == personCreditSongKey {
+			return &Error{Kind: ErrUniqueViolation, Detail: ErrPersonCreditTaken.Detail, Err: err}
+		}
+		return &Error{Kind: ErrUniqueViolation, Detail: pgErr.Message, Err: err}
+	case "23P01":
+		if pgErr.ConstraintName == trackNumberConstraint {
+			return &Error{Kind: ErrUniqueViolation, Detail: ErrTrackNumberTaken.Detail, Err: err}
+		}
+		return &Error{Kind: ErrUniqueViolation, Detail: pgErr.Message, Err: err}
+	case "22001", "22003", "22P02", "23502", "23514":
+		return &Error{Kind: ErrValidation, Detail: pgErr.Message, Err: err}
+	case "40001", "40P01":
+		return &Error{Kind: ErrConflict, Detail: "the record was changed concurrently, try again", Err: err}
+	}
+
+	return err
+}
+
+// ErrParentArchived is returned when restoring an album or song whose artist
+// or album is still archived.
+var ErrParentArchived = &Error{Kind: ErrConflict, Detail: "parent is archived, restore it first"}
+
+// ErrParentNotFound is returned when creating an album or song whose artist
+// or album doesn't exist or is archived.
+var ErrParentNotFound = &Error{Kind: ErrForeignKeyViolation, Detail: "parent not found"}
+
+// ErrCreditedArtistNotFound is returned when an album or song would credit
+// an artist that doesn't exist or is archived.
+var ErrCreditedArtistNotFound = &Error{Kind: ErrForeignKeyViolation, Detail: "credited artist not found"}
+
+// ErrSongArtistNotFound is returned when a song's own artist doesn't exist
+// or is archived.
+var ErrSongArtistNotFound = &Error{Kind: ErrForeignKeyViolation, Detail: "song artist not found"}
+
+// ErrSongArtistRequired is returned when a song on a compilation would have
+// no artist of its own.
+var ErrSongArtistRequired = &Error{Kind: ErrForeignKeyViolation, Detail: "songs on a compilation need an artist_id"}
+
+// ErrPersonNotFound is returned when a credit would name a person that
+// doesn't exist or is archived.
+var ErrPersonNotFound = &Error{Kind: ErrForeignKeyViolation, Detail: "credited person not found"}
+
+// ErrPersonCreditTaken is returned when a person would be credited in the
+// same role on an album or song twice.
+var ErrPersonCreditTaken = &Error{Kind: ErrUniqueViolation, Detail: "person is already credited in this role"}
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded or
+// doesn't belong to the collection being listed.
+var ErrInvalidCursor = &Error{Kind: ErrValidation, Detail: "invalid cursor"}
+
+// ErrMergeIntoSelf is returned when an artist or album is merged into itself.
+var ErrMergeIntoSelf = &Error{Kind: ErrValidation, Detail: "cannot merge into itself"}
+
+// ErrMergeTargetNotFound is returned when the artist or album being merged
+// into doesn't exist or is archived.
+var ErrMergeTargetNotFound = &Error{Kind: ErrForeignKeyViolation, Detail: "merge target not found"}
+
+// ErrVersionMismatch is returned when a write is conditional on versions the
+// record is no longer at.
+var ErrVersionMismatch = &Error{Kind: ErrConflict, Detail: "version mismatch"}
+
+// ErrRevisionNotFound is returned when reverting to a revision that doesn't
+// belong to the record.
+var ErrRevisionNotFound = &Error{Kind: ErrNotFound, Detail: "revision not found"}
+
+// ErrTrackNumberTaken is returned when a write would give two live songs of
+// an album the same track number.
+var ErrTrackNumberTaken = &Error{Kind: ErrUniqueViolation, Detail: "track number is already taken on this disc"}
+
+// BulkError is returned when an item of an all-or-nothing bulk write fails,
+// which rolls back the whole write. Index is the item's place in the write.
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return "item " + strconv.Itoa(e.Index) + ": " + e.Err.Error()
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   error
+		detail string
+	}{
+		{"no rows", fmt.Errorf("scanning: %w", pgx.ErrNoRows), ErrNotFound, ""},
+		{"foreign key", &pgconn.PgError{Code: "23503", Message: "violates fk"}, ErrForeignKeyViolation, "violates fk"},
+		{"unique", &pgconn.PgError{Code: "23505", Message: "duplicate key"}, ErrUniqueViolation, "duplicate key"},
+		{"person credit", &pgconn.PgError{Code: "23505", ConstraintName: personCreditSongKey}, ErrUniqueViolation, ErrPersonCreditTaken.Detail},
+		{"track number", &pgconn.PgError{Code: "23P01", ConstraintName: trackNumberConstraint}, ErrUniqueViolation, ErrTrackNumberTaken.Detail},
+		{"too long", &pgconn.PgError{Code: "22001", Message: "value too long"}, ErrValidation, "value too long"},
+		{"serialization", &pgconn.PgError{Code: "40001"}, ErrConflict, "the record was changed concurrently, try again"},
+		{"already translated", ErrMergeIntoSelf, ErrValidation, ErrMergeIntoSelf.Detail},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var repoErr *Error
+			if !errors.As(Translate(test.err), &repoErr) {
+				t.Fatalf("got %v, want an *Error", Translate(test.err))
+			}
+
+			if !errors.Is(repoErr, test.kind) || repoErr.Detail != test.detail {
+				t.Errorf("got %v %q, want %v %q", repoErr.Kind, repoErr.Detail, test.kind, test.detail)
+			}
+		})
+	}
+}
+
+func TestTranslateLeavesOtherErrors(t *testing.T) {
+	for _, err := range []error{nil, errors.New("connection reset"), &pgconn.PgError{Code: "57014"}} {
+		if got := Translate(err); got != err {
+			t.Errorf("Translate(%v): got %v, want it unchanged", err, got)
+		}
+	}
+}
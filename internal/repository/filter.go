@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+)
+
+var filterOperators = map[model.FilterOp]string{
+	model.OpEq:  "=",
+	model.OpNe:  "<>",
+	model.OpGt:  ">",
+	model.OpGte: ">=",
+	model.OpLt:  "<",
+	model.OpLte: "<=",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterConditions translates parsed filters into parameterised SQL
+// conditions, using columns to map API field names to SQL expressions.
+func filterConditions(filters []model.Filter, columns map[string]string, args *[]any) ([]string, error) {
+	conditions := []string{}
+
+	for _, filter := range filters {
+		expr, ok := columns[filter.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter field %q", filter.Field)
+		}
+
+		switch filter.Op {
+		case model.OpIn:
+			conditions = append(conditions, expr+" = ANY("+addArg(args, filter.Value)+")")
+		case model.OpContains:
+			value, _ := filter.Value.(string)
+			conditions = append(conditions, expr+" ILIKE '%' || "+addArg(args, likeEscaper.Replace(value))+" || '%'")
+		default:
+			op, ok := filterOperators[filter.Op]
+			if !ok {
+				return nil, fmt.Errorf("unknown filter operator %q", filter.Op)
+			}
+			conditions = append(conditions, expr+" "+op+" "+addArg(args, filter.Value))
+		}
+	}
+
+	return conditions, nil
+}
+
+// matchesFilters is the in-memory counterpart of filterConditions. value
+// reads a field from the row being tested.
+func matchesFilters(filters []model.Filter, value func(field string) any) bool {
+	for _, filter := range filters {
+		if !matchesFilter(filter, value(filter.Field)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesFilter(filter model.Filter, value any) bool {
+	// A NULL column matches no filter, as in SQL.
+	if value == nil {
+		return false
+	}
+
+	switch filter.Op {
+	case model.OpIn:
+		switch values := filter.Value.(type) {
+		case []string:
+			for _, v := range values {
+				if compareValues(value, v) == 0 {
+					return true
+				}
+			}
+		case []int64:
+			for _, v := range values {
+				if compareValues(value, v) == 0 {
+					return true
+				}
+			}
+		}
+		return false
+	case model.OpContains:
+		s, _ := value.(string)
+		substr, _ := filter.Value.(string)
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	cmp := compareValues(value, filter.Value)
+
+	switch filter.Op {
+	case model.OpEq:
+		return cmp == 0
+	case model.OpNe:
+		return cmp != 0
+	case model.OpGt:
+		return cmp > 0
+	case model.OpGte:
+		return cmp >= 0
+	case model.OpLt:
+		return cmp < 0
+	case model.OpLte:
+		return cmp <= 0
+	}
+
+	return false
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultActor is recorded against changes made without an actor in the
+// context.
+const DefaultActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded in the history as
+// made by actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return DefaultActor
+}
+
+// setActor tags the rest of the transaction with the actor from ctx, which
+// the record_history trigger stores with every change.
+func setActor(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('music.actor', $1, true)`, actorFrom(ctx))
+	return err
+}
+
+// writeTx runs fn in a transaction whose changes are recorded against the
+// actor from ctx, and classifies the error it fails with, if any.
+func writeTx(ctx context.Context, dbPool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	err := pgx.BeginFunc(ctx, dbPool, func(tx pgx.Tx) error {
+		if err := setActor(ctx, tx); err != nil {
+			return err
+		}
+
+		return fn(tx)
+	})
+
+	return Translate(err)
+}
+
+// HistoryEntities maps each table with a history to the columns a revert
+// restores. Archive state and parents are left alone; restore and merge
+// cover those.
+var HistoryEntities = map[string][]string{
+	"artist": {"name", "description"},
+	"album":  {"name", "release_year", "release_date", "release_type"},
+	"song":   {"title", "disc_number", "disc_title", "track_number", "duration_seconds"},
+	"person": {"name", "description"},
+}
+
+type HistoryRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewHistoryRepository(dbPool *pgxpool.Pool) *HistoryRepository {
+	return &HistoryRepository{
+		dbPool: dbPool,
+	}
+}
+
+func (r *HistoryRepository) History(ctx context.Context, entity string, id string) ([]model.Revision, error) {
+	query := `SELECT id, action, actor, before, after, changed_at
+				FROM history
+				WHERE entity = $1 AND entity_id = $2
+				ORDER BY id DESC`
+
+	rows, err := r.dbPool.Query(ctx, query, entity, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []model.Revision{}
+
+	for rows.Next() {
+		var revision model.Revision
+		if err := rows.Scan(&revision.ID, &revision.Action, &revision.Actor, &revision.Before, &revision.After, &revision.ChangedAt); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(revisions) > 0 {
+		return revisions, nil
+	}
+
+	var exists bool
+	query2 := `SELECT EXISTS (SELECT 1 FROM ` + entity + ` WHERE id = $1)`
+
+	err = r.dbPool.QueryRow(ctx, query2, id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	return revisions, nil
+}
+
+// Revert puts the record's columns back to how they were right after
+// revisionID by undoing every later revision, and returns the revision the
+// revert itself was recorded as. It returns nil when the record already
+// matches.
+func (r *HistoryRepository) Revert(ctx context.Context, entity string, id string, revisionID int) (*model.Revision, error) {
+	columns := HistoryEntities[entity]
+
+	var revision *model.Revision
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var current map[string]any
+		query := `SELECT to_jsonb(t) FROM ` + entity + ` t WHERE id = $1 AND archived = FALSE FOR UPDATE`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&current)
+		if err != nil {
+			return err
+		}
+
+		var found bool
+		query2 := `SELECT EXISTS (SELECT 1 FROM history WHERE id = $1 AND entity = $2 AND entity_id = $3)`
+
+		err = tx.QueryRow(ctx, query2, revisionID, entity, id).Scan(&found)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return ErrRevisionNotFound
+		}
+
+		query3 := `SELECT before FROM history WHERE entity = $1 AND entity_id = $2 AND id > $3 ORDER BY id DESC`
+
+		rows, err := tx.Query(ctx, query3, entity, id, revisionID)
+		if err != nil {
+			return err
+		}
+
+		befores, err := pgx.CollectRows(rows, pgx.RowTo[map[string]any])
+		if err != nil {
+			return err
+		}
+
+		state := revertedState(columns, current, befores)
+		if !changesColumns(columns, current, state) {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `SELECT set_config('music.action', 'revert', true)`)
+		if err != nil {
+			return err
+		}
+
+		list := strings.Join(columns, ", ")
+		query4 := `UPDATE ` + entity + ` SET (` + list + `) = (SELECT ` + list + ` FROM jsonb_populate_record(NULL::` + entity + `, $2)) WHERE id = $1`
+
+		_, err = tx.Exec(ctx, query4, id, state)
+		if err != nil {
+			return err
+		}
+
+		revision = &model.Revision{}
+		query5 := `SELECT id, action, actor, before, after, changed_at
+					FROM history
+					WHERE entity = $1 AND entity_id = $2
+					ORDER BY id DESC
+					LIMIT 1`
+
+		return tx.QueryRow(ctx, query5, entity, id).Scan(&revision.ID, &revision.Action, &revision.Actor, &revision.Before, &revision.After, &revision.ChangedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// revertedState applies the before values of later revisions, newest first,
+// to the current values of columns.
+func revertedState(columns []string, current map[string]any, befores []map[string]any) map[string]any {
+	state := map[string]any{}
+	for _, column := range columns {
+		state[column] = current[column]
+	}
+
+	for _, before := range befores {
+		for _, column := range columns {
+			if value, ok := before[column]; ok {
+				state[column] = value
+			}
+		}
+	}
+
+	return state
+}
+
+func changesColumns(columns []string, current, state map[string]any) bool {
+	for _, column := range columns {
+		if state[column] != current[column] {
+			return true
+		}
+	}
+
+	return false
+}
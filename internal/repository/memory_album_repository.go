@@ -0,0 +1,865 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type MemoryAlbumRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryAlbumRepository(db *MemoryDB) *MemoryAlbumRepository {
+	return &MemoryAlbumRepository{
+		db: db,
+	}
+}
+
+func (db *MemoryDB) albumResponse(a *albumRow) model.AlbumResponse {
+	return model.AlbumResponse{
+		ID:          a.id,
+		Name:        a.name,
+		ReleaseYear: a.releaseYear,
+		ReleaseDate: a.releaseDate,
+		ReleaseType: a.releaseType,
+		Compilation: a.compilation,
+		Credits:     db.albumCredits(a),
+		Version:     a.version,
+	}
+}
+
+func (db *MemoryDB) albumModel(a *albumRow) model.Album {
+	return model.Album{
+		ID:          a.id,
+		ArtistName:  db.artistName(a.artistID),
+		Name:        a.name,
+		ReleaseYear: a.releaseYear,
+		ReleaseDate: a.releaseDate,
+		ReleaseType: a.releaseType,
+		Compilation: a.compilation,
+		Credits:     db.albumCredits(a),
+		Version:     a.version,
+	}
+}
+
+func (db *MemoryDB) albumField(a *albumRow) func(string) any {
+	return func(name string) any {
+		switch name {
+		case "id":
+			return a.id
+		case "name":
+			return a.name
+		case "release_year":
+			return a.releaseYear
+		case "release_date":
+			return string(a.releaseDate)
+		case "release_type":
+			return string(a.releaseType)
+		case "compilation":
+			return a.compilation
+		case "artist_id":
+			return nullID(a.artistID)
+		case "artist":
+			return db.artistName(a.artistID)
+		}
+
+		return nil
+	}
+}
+
+func (r *MemoryAlbumRepository) GetAlbums(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error) {
+	l, err := newListing(params, albumColumns, albumOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	albums := []model.Album{}
+
+	for _, album := range r.db.albums {
+		if album.archived != params.Archived || !matchesFilters(params.Filters, r.db.albumField(album)) {
+			continue
+		}
+
+		albums = append(albums, r.db.albumModel(album))
+	}
+
+	return paginate(l, albums, albumValue), nil
+}
+
+func (r *MemoryAlbumRepository) GetArtistAlbums(ctx context.Context, artistID string, params model.ListParams) (*model.Page[model.Album], error) {
+	r.db.mu.RLock()
+	artist, err := r.db.liveArtist(artistID)
+	r.db.mu.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetAlbums(ctx, childParams(params, "artist_id", artist.id))
+}
+
+func (r *MemoryAlbumRepository) GetCompilations(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error) {
+	return r.GetAlbums(ctx, compilationParams(params))
+}
+
+func (r *MemoryAlbumRepository) GetAlbum(ctx context.Context, id string) (*model.AlbumWithSongs, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	album := model.AlbumWithSongs{Album: r.db.albumModel(row)}
+	album.Personnel = r.db.personnel(func(c *personCreditRow) bool { return c.albumID == row.id })
+	album.Songs = r.db.songsForAlbum(row.id)
+
+	for i := range album.Songs {
+		album.Songs[i].Personnel = r.db.songPersonnel(album.Songs[i].ID)
+	}
+
+	return &album, nil
+}
+
+func (db *MemoryDB) songsForAlbum(albumID int) []model.Song {
+	songs := []model.Song{}
+
+	for _, song := range db.songs {
+		if song.albumID != albumID || song.archived {
+			continue
+		}
+
+		songs = append(songs, model.Song{
+			ID:              song.id,
+			ArtistID:        song.artistID,
+			ArtistName:      db.ownArtistName(song),
+			Title:           song.title,
+			DiscNumber:      song.discNumber,
+			DiscTitle:       song.discTitle,
+			TrackNumber:     song.trackNumber,
+			DurationSeconds: song.durationSeconds,
+			Credits:         db.songCredits(song),
+			Version:         song.version,
+		})
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		if songs[i].DiscNumber != songs[j].DiscNumber {
+			return songs[i].DiscNumber < songs[j].DiscNumber
+		}
+		if songs[i].TrackNumber != songs[j].TrackNumber {
+			return songs[i].TrackNumber < songs[j].TrackNumber
+		}
+		return songs[i].ID < songs[j].ID
+	})
+
+	return songs
+}
+
+func (r *MemoryAlbumRepository) CreateAlbum(ctx context.Context, album model.CreateAlbum) (*model.AlbumResponse, error) {
+	if err := checkVarchar(album.Name); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkAlbumArtist(album); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.checkCredits(album.Artists); err != nil {
+		return nil, err
+	}
+
+	row := r.db.insertAlbum(ctx, album)
+
+	albumCreated := r.db.albumResponse(row)
+	return &albumCreated, nil
+}
+
+func (r *MemoryAlbumRepository) CreateAlbumTree(ctx context.Context, album model.AlbumTree) (*model.AlbumWithSongs, error) {
+	if err := checkVarchar(treeValues(album.Name, album.Songs)...); err != nil {
+		return nil, err
+	}
+
+	if err := checkTreeTracks(album.Songs); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkAlbumArtist(album.CreateAlbum); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.checkTreeCredits(album.Artists, album.Songs); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.checkTreeArtists(album.Compilation, album.Songs); err != nil {
+		return nil, err
+	}
+
+	row := r.db.insertAlbum(ctx, album.CreateAlbum)
+	r.db.insertTreeSongs(ctx, row.id, album.Songs)
+
+	createdAlbum := model.AlbumWithSongs{Album: r.db.albumModel(row), Songs: r.db.songsForAlbum(row.id)}
+	return &createdAlbum, nil
+}
+
+// checkAlbumArtist mirrors lockAlbumArtist: a new album needs a live artist
+// unless it is a compilation.
+func (db *MemoryDB) checkAlbumArtist(album model.CreateAlbum) error {
+	if album.Compilation {
+		return nil
+	}
+
+	if artist, ok := db.artists[album.ArtistID]; !ok || artist.archived {
+		return ErrParentNotFound
+	}
+
+	return nil
+}
+
+// checkTreeArtists checks the artists of their own the songs of a new album
+// are to have.
+func (db *MemoryDB) checkTreeArtists(compilation bool, songs []model.TreeSong) error {
+	for _, song := range songs {
+		if err := db.checkSongArtist(compilation, song.ArtistID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// treeValues lists the varchar values of an album being created with its
+// songs, for checkVarchar.
+func treeValues(name string, songs []model.TreeSong) []string {
+	values := []string{name}
+	for _, song := range songs {
+		values = append(values, song.Title)
+	}
+
+	return values
+}
+
+// checkTreeCredits checks the artists an album being created with its songs
+// credits, on the album and on the songs.
+func (db *MemoryDB) checkTreeCredits(artists []model.CreditArtist, songs []model.TreeSong) error {
+	if err := db.checkCredits(artists); err != nil {
+		return err
+	}
+
+	for _, song := range songs {
+		if err := db.checkCredits(song.Artists); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAlbumTracks checks the live songs on each disc of an album will
+// still have distinct track numbers once changes are made, however they were
+// numbered along the way.
+func (db *MemoryDB) checkAlbumTracks(albumID int, changes model.AlbumChanges) error {
+	taken := map[[2]int]bool{}
+
+	take := func(disc, track int) error {
+		if taken[[2]int{disc, track}] {
+			return ErrTrackNumberTaken
+		}
+
+		taken[[2]int{disc, track}] = true
+		return nil
+	}
+
+	for _, song := range db.songs {
+		if song.albumID != albumID || song.archived || slices.Contains(changes.Removed, song.id) {
+			continue
+		}
+
+		disc, track := song.discNumber, song.trackNumber
+		if patch, ok := changes.Songs[song.id]; ok {
+			if patch.DiscNumber != nil {
+				disc = *patch.DiscNumber
+			}
+			if patch.TrackNumber != nil {
+				track = *patch.TrackNumber
+			}
+		}
+
+		if err := take(disc, track); err != nil {
+			return err
+		}
+	}
+
+	for _, song := range changes.Added {
+		if err := take(model.DiscOrFirst(song.DiscNumber), song.TrackNumber); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkTreeTracks checks the songs of a new album don't repeat a track
+// number on a disc.
+func checkTreeTracks(songs []model.TreeSong) error {
+	t := newTracklists()
+	positions := model.DiscPositions{}
+	for _, song := range songs {
+		disc := model.DiscOrFirst(song.DiscNumber)
+		if _, err := t.add(0, disc, song.TrackAt(positions.Next(disc))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *MemoryDB) insertTreeSongs(ctx context.Context, albumID int, songs []model.TreeSong) {
+	positions := model.DiscPositions{}
+	for _, song := range songs {
+		disc := model.DiscOrFirst(song.DiscNumber)
+		db.insertSong(ctx, model.CreateSong{
+			AlbumID:         albumID,
+			ArtistID:        song.ArtistID,
+			Title:           song.Title,
+			DiscNumber:      disc,
+			DiscTitle:       song.DiscTitle,
+			TrackNumber:     song.TrackAt(positions.Next(disc)),
+			DurationSeconds: song.DurationSeconds,
+			Artists:         song.Artists,
+		})
+	}
+}
+
+func (db *MemoryDB) insertAlbum(ctx context.Context, album model.CreateAlbum) *albumRow {
+	row := &albumRow{
+		id:          db.nextAlbumID,
+		artistID:    album.ArtistID,
+		compilation: album.Compilation,
+		name:        album.Name,
+		releaseType: cmp.Or(album.ReleaseType, model.ReleaseLP),
+		credits:     creditRows(album.Artists),
+		version:     1,
+	}
+	row.releaseYear, row.releaseDate = model.Release(album.ReleaseYear, album.ReleaseDate)
+	db.albums[row.id] = row
+	db.nextAlbumID++
+	db.record(ctx, "album", "", nil, row.columns())
+
+	return row
+}
+
+func (r *MemoryAlbumRepository) UpdateAlbum(ctx context.Context, album model.UpdateAlbum, id string, ifMatch []int) (*model.AlbumResponse, error) {
+	if err := checkVarchar(album.Name); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err := r.db.checkCredits(album.Artists); err != nil {
+		return nil, err
+	}
+
+	r.db.updateAlbum(ctx, row, album)
+
+	updatedAlbum := r.db.albumResponse(row)
+	return &updatedAlbum, nil
+}
+
+func (db *MemoryDB) updateAlbum(ctx context.Context, row *albumRow, album model.UpdateAlbum) {
+	db.track(ctx, "album", row, func() {
+		row.name = album.Name
+		row.releaseYear, row.releaseDate = model.Release(album.ReleaseYear, album.ReleaseDate)
+		row.releaseType = cmp.Or(album.ReleaseType, model.ReleaseLP)
+		row.credits = creditRows(album.Artists)
+		row.version++
+	})
+}
+
+func (r *MemoryAlbumRepository) PatchAlbum(ctx context.Context, album model.PatchAlbum, id string, ifMatch []int) (*model.AlbumResponse, error) {
+	if err := checkVarchar(deref(album.Name)); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if album.Artists != nil {
+		if err := r.db.checkCredits(*album.Artists); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.patchAlbum(ctx, row, album)
+
+	patchedAlbum := r.db.albumResponse(row)
+	return &patchedAlbum, nil
+}
+
+// patchAlbum applies the fields of a patch that are set, if there are any,
+// as one change.
+func (db *MemoryDB) patchAlbum(ctx context.Context, row *albumRow, album model.PatchAlbum) {
+	if album.Name == nil && album.ReleaseYear == nil && album.ReleaseDate == nil && album.ReleaseType == nil && album.Artists == nil {
+		return
+	}
+
+	db.track(ctx, "album", row, func() {
+		if album.Name != nil {
+			row.name = *album.Name
+		}
+		switch {
+		case album.ReleaseDate != nil:
+			row.releaseYear, row.releaseDate = model.Release(0, *album.ReleaseDate)
+		case album.ReleaseYear != nil && *album.ReleaseYear != row.releaseYear:
+			row.releaseYear, row.releaseDate = model.Release(*album.ReleaseYear, "")
+		}
+		if album.ReleaseType != nil {
+			row.releaseType = *album.ReleaseType
+		}
+		if album.Artists != nil {
+			row.credits = creditRows(*album.Artists)
+		}
+		row.version++
+	})
+}
+
+func (r *MemoryAlbumRepository) ChangeAlbum(ctx context.Context, id string, ifMatch []int, change func(model.AlbumWithSongs) (model.AlbumChanges, error)) (*model.AlbumWithSongs, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	changes, err := change(model.AlbumWithSongs{Album: r.db.albumModel(row), Songs: r.db.songsForAlbum(row.id)})
+	if err != nil {
+		return nil, err
+	}
+
+	// Check everything before touching anything, so a change is applied
+	// completely or not at all.
+	values := []string{deref(changes.Album.Name)}
+	for _, song := range changes.Songs {
+		values = append(values, deref(song.Title))
+	}
+	for _, song := range changes.Added {
+		values = append(values, song.Title)
+	}
+
+	if err := checkVarchar(values...); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.checkAlbumTracks(row.id, changes); err != nil {
+		return nil, err
+	}
+
+	credited := [][]model.CreditArtist{}
+	if changes.Album.Artists != nil {
+		credited = append(credited, *changes.Album.Artists)
+	}
+	for _, song := range changes.Songs {
+		if song.Artists != nil {
+			credited = append(credited, *song.Artists)
+		}
+	}
+	for _, song := range changes.Added {
+		credited = append(credited, song.Artists)
+	}
+
+	for _, artists := range credited {
+		if err := r.db.checkCredits(artists); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, song := range changes.Songs {
+		if song.ArtistID != nil {
+			if err := r.db.checkSongArtist(row.compilation, *song.ArtistID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, song := range changes.Added {
+		if err := r.db.checkSongArtist(row.compilation, song.ArtistID); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.patchAlbum(ctx, row, changes.Album)
+
+	for _, songID := range changes.Removed {
+		if song, ok := r.db.songs[songID]; ok && song.albumID == row.id && !song.archived {
+			r.db.track(ctx, "song", song, func() {
+				song.archived = true
+				song.version++
+				song.archivedAt = time.Now()
+			})
+		}
+	}
+
+	for _, songID := range slices.Sorted(maps.Keys(changes.Songs)) {
+		if song, ok := r.db.songs[songID]; ok && song.albumID == row.id && !song.archived {
+			r.db.patchSong(ctx, song, changes.Songs[songID])
+		}
+	}
+
+	for _, song := range changes.Added {
+		song.AlbumID = row.id
+		r.db.insertSong(ctx, song)
+	}
+
+	changedAlbum := model.AlbumWithSongs{Album: r.db.albumModel(row), Songs: r.db.songsForAlbum(row.id)}
+	return &changedAlbum, nil
+}
+
+func (r *MemoryAlbumRepository) DeleteAlbum(ctx context.Context, id string, ifMatch []int) (*model.ArchivedAlbum, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	album, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, album.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	now := time.Now()
+	r.db.track(ctx, "album", album, func() {
+		album.archived = true
+		album.version++
+		album.archivedAt = now
+	})
+
+	archivedAlbum := model.ArchivedAlbum{ID: album.id}
+
+	for _, song := range r.db.songs {
+		if song.albumID == album.id && !song.archived {
+			r.db.track(ctx, "song", song, func() {
+				song.archived = true
+				song.version++
+				song.archivedAt = now
+			})
+			archivedAlbum.SongsArchived++
+		}
+	}
+
+	return &archivedAlbum, nil
+}
+
+func (r *MemoryAlbumRepository) RestoreAlbum(ctx context.Context, id string, cascade bool) (*model.RestoredAlbum, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	album, err := r.db.archivedAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if artist, ok := r.db.artists[album.artistID]; ok && artist.archived {
+		return nil, ErrParentArchived
+	}
+	r.db.track(ctx, "album", album, func() {
+		album.archived = false
+		album.version++
+		album.archivedAt = time.Time{}
+	})
+
+	restoredAlbum := model.RestoredAlbum{ID: album.id}
+
+	if !cascade {
+		return &restoredAlbum, nil
+	}
+
+	restored := []*songRow{}
+	for _, song := range r.db.songs {
+		if song.albumID == album.id && song.archived {
+			r.db.track(ctx, "song", song, func() {
+				song.archived = false
+				song.version++
+				song.archivedAt = time.Time{}
+			})
+			restored = append(restored, song)
+		}
+	}
+	r.db.placeRestoredSongs(ctx, restored)
+	restoredAlbum.SongsRestored = len(restored)
+
+	return &restoredAlbum, nil
+}
+
+func (r *MemoryAlbumRepository) MatchAlbums(ctx context.Context, artistID int, params model.MatchParams) ([]model.AlbumMatch, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	albums := []model.AlbumMatch{}
+
+	for _, album := range r.db.albums {
+		if album.artistID != artistID || album.archived {
+			continue
+		}
+
+		score := similarity(album.name, params.Name)
+		if score == 0 || score < params.Threshold {
+			continue
+		}
+
+		albums = append(albums, model.AlbumMatch{Album: r.db.albumModel(album), Score: score})
+	}
+
+	sort.Slice(albums, func(i, j int) bool {
+		if albums[i].Score != albums[j].Score {
+			return albums[i].Score > albums[j].Score
+		}
+		return albums[i].ID < albums[j].ID
+	})
+
+	if len(albums) > params.Limit {
+		albums = albums[:params.Limit]
+	}
+
+	return albums, nil
+}
+
+func (r *MemoryAlbumRepository) MergeAlbum(ctx context.Context, id string, targetID int) (*model.MergedAlbum, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	source, err := r.db.liveAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.id == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	if target, ok := r.db.albums[targetID]; !ok || target.archived {
+		return nil, ErrMergeTargetNotFound
+	}
+
+	mergedAlbum := model.MergedAlbum{ID: source.id, TargetID: targetID}
+
+	// Track numbers are compared disc by disc.
+	lastTrack := map[int]int{}
+	taken := map[[2]int]bool{}
+	clashes := []*songRow{}
+
+	for _, song := range r.db.songs {
+		if song.archived || (song.albumID != source.id && song.albumID != targetID) {
+			continue
+		}
+
+		lastTrack[song.discNumber] = max(lastTrack[song.discNumber], song.trackNumber)
+		if song.albumID == targetID {
+			taken[[2]int{song.discNumber, song.trackNumber}] = true
+		}
+	}
+
+	for _, song := range r.db.songs {
+		if song.albumID == source.id && !song.archived && taken[[2]int{song.discNumber, song.trackNumber}] {
+			clashes = append(clashes, song)
+		}
+	}
+
+	sort.Slice(clashes, func(i, j int) bool {
+		if clashes[i].discNumber != clashes[j].discNumber {
+			return clashes[i].discNumber < clashes[j].discNumber
+		}
+		if clashes[i].trackNumber != clashes[j].trackNumber {
+			return clashes[i].trackNumber < clashes[j].trackNumber
+		}
+		return clashes[i].id < clashes[j].id
+	})
+
+	for _, song := range clashes {
+		lastTrack[song.discNumber]++
+		track := lastTrack[song.discNumber]
+
+		r.db.track(ctx, "song", song, func() {
+			song.trackNumber = track
+			song.version++
+		})
+	}
+	mergedAlbum.TracksRenumbered = len(clashes)
+
+	target := r.db.albums[targetID]
+	r.db.mergeAlbumCredits(source, target)
+	r.db.mergePersonCredits(source, target)
+
+	// Songs moving onto an album of another artist, or a compilation, take
+	// their album's artist along.
+	for _, song := range r.db.songs {
+		if song.albumID == source.id {
+			r.db.track(ctx, "song", song, func() {
+				song.albumID = targetID
+				if song.artistID == 0 && source.artistID != target.artistID {
+					song.artistID = source.artistID
+				}
+				song.version++
+			})
+			mergedAlbum.SongsMoved++
+		}
+	}
+
+	r.db.track(ctx, "album", source, func() {
+		source.archived = true
+		source.version++
+		source.archivedAt = time.Now()
+	})
+	setRedirect(r.db.albumRedirects, source.id, targetID)
+
+	return &mergedAlbum, nil
+}
+
+// mergeAlbumCredits is the in-memory counterpart of the package's
+// mergeAlbumCredits.
+func (db *MemoryDB) mergeAlbumCredits(source, target *albumRow) {
+	credited := func(credits []creditRow, artistID int) bool {
+		return slices.ContainsFunc(credits, func(c creditRow) bool { return c.artistID == artistID })
+	}
+
+	songs := []*songRow{}
+	for _, song := range db.songs {
+		if song.albumID == source.id && song.artistID == 0 {
+			songs = append(songs, song)
+		}
+	}
+
+	if source.artistID != target.artistID {
+		for _, song := range songs {
+			own := song.credits
+			song.credits = []creditRow{}
+
+			for _, credit := range source.credits {
+				if !credited(own, credit.artistID) {
+					song.credits = append(song.credits, credit)
+				}
+			}
+			song.credits = append(song.credits, own...)
+		}
+
+		return
+	}
+
+	moved := false
+	for _, credit := range source.credits {
+		if credit.artistID != target.artistID && !credited(target.credits, credit.artistID) {
+			target.credits = append(target.credits, credit)
+			moved = true
+		}
+	}
+	source.credits = nil
+
+	if moved {
+		target.version++
+	}
+
+	for _, song := range songs {
+		song.credits = slices.DeleteFunc(song.credits, func(c creditRow) bool { return credited(target.credits, c.artistID) })
+	}
+}
+
+func (r *MemoryAlbumRepository) AlbumRedirect(ctx context.Context, id string) (int, error) {
+	albumID, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	targetID, ok := r.db.albumRedirects[albumID]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+
+	return targetID, nil
+}
+
+func (r *MemoryAlbumRepository) BulkAlbums(ctx context.Context, items []model.BulkItem[model.CreateAlbum, model.UpdateAlbum], atomic bool) ([]model.BulkResult, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	check := func(i int) error {
+		item := items[i]
+		if item.ID == 0 {
+			if err := r.db.checkAlbumArtist(item.Create); err != nil {
+				return err
+			}
+
+			if err := r.db.checkCredits(item.Create.Artists); err != nil {
+				return err
+			}
+
+			return checkVarchar(item.Create.Name)
+		}
+
+		if row, ok := r.db.albums[item.ID]; !ok || row.archived {
+			return pgx.ErrNoRows
+		}
+
+		if err := r.db.checkCredits(item.Update.Artists); err != nil {
+			return err
+		}
+
+		return checkVarchar(item.Update.Name)
+	}
+
+	write := func(i int) model.BulkResult {
+		item := items[i]
+		if item.ID == 0 {
+			return model.BulkResult{ID: r.db.insertAlbum(ctx, item.Create).id, Created: true}
+		}
+
+		r.db.updateAlbum(ctx, r.db.albums[item.ID], item.Update)
+		return model.BulkResult{ID: item.ID}
+	}
+
+	return r.db.bulkWrite(len(items), atomic, check, write)
+}
@@ -0,0 +1,663 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type MemoryArtistRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryArtistRepository(db *MemoryDB) *MemoryArtistRepository {
+	return &MemoryArtistRepository{
+		db: db,
+	}
+}
+
+func (a *artistRow) toModel() model.Artist {
+	return model.Artist{
+		ID:          a.id,
+		Name:        a.name,
+		Description: a.description,
+		Version:     a.version,
+	}
+}
+
+func (a *artistRow) field(name string) any {
+	switch name {
+	case "id":
+		return a.id
+	case "name":
+		return a.name
+	case "description":
+		return a.description
+	}
+
+	return nil
+}
+
+func (r *MemoryArtistRepository) GetArtists(ctx context.Context, params model.ListParams) (*model.Page[model.Artist], error) {
+	l, err := newListing(params, artistColumns, artistOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	artists := []model.Artist{}
+
+	for _, artist := range r.db.artists {
+		if artist.archived != params.Archived || !matchesFilters(params.Filters, artist.field) {
+			continue
+		}
+
+		artists = append(artists, artist.toModel())
+	}
+
+	return paginate(l, artists, artistValue), nil
+}
+
+func (r *MemoryArtistRepository) GetArtist(ctx context.Context, id string) (*model.ArtistWithAlbums, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artist := model.ArtistWithAlbums{Artist: row.toModel()}
+	artist.Albums = r.db.albumsForArtist(row.id)
+	artist.Appearances = r.db.appearancesForArtist(row.id)
+
+	return &artist, nil
+}
+
+func (db *MemoryDB) albumsForArtist(artistID int) []model.Album {
+	albums := []model.Album{}
+
+	for _, album := range db.albums {
+		if album.artistID != artistID || album.archived {
+			continue
+		}
+
+		albums = append(albums, model.Album{
+			ID:          album.id,
+			Name:        album.name,
+			ReleaseYear: album.releaseYear,
+			ReleaseDate: album.releaseDate,
+			ReleaseType: album.releaseType,
+			Credits:     db.albumCredits(album),
+		})
+	}
+
+	slices.SortFunc(albums, model.CompareReleases)
+
+	return albums
+}
+
+// appearancesForArtist lists the live albums of other artists, or
+// compilations, that credit an artist, on the album or on its live songs,
+// latest release first.
+func (db *MemoryDB) appearancesForArtist(artistID int) []model.Appearance {
+	appearances := []model.Appearance{}
+
+	for _, album := range db.albums {
+		if album.artistID == artistID || album.archived {
+			continue
+		}
+
+		appearance := model.Appearance{Album: db.albumModel(album), Roles: creditedRoles(album.credits, artistID)}
+
+		for _, song := range db.songsForAlbum(album.id) {
+			roles := creditedRoles(db.songs[song.ID].credits, artistID)
+			if song.ArtistID == artistID {
+				roles = append([]model.ArtistRole{model.RolePrimary}, roles...)
+			}
+
+			if len(roles) > 0 {
+				appearance.Songs = append(appearance.Songs, model.SongAppearance{ID: song.ID, Title: song.Title, Roles: roles})
+			}
+		}
+
+		if len(appearance.Roles) > 0 || len(appearance.Songs) > 0 {
+			appearances = append(appearances, appearance)
+		}
+	}
+
+	slices.SortFunc(appearances, func(a, b model.Appearance) int {
+		return model.CompareReleases(a.Album, b.Album)
+	})
+
+	return appearances
+}
+
+// creditedRoles lists the roles credits give an artist.
+func creditedRoles(credits []creditRow, artistID int) []model.ArtistRole {
+	roles := []model.ArtistRole{}
+	for _, credit := range credits {
+		if credit.artistID == artistID {
+			roles = append(roles, credit.role)
+		}
+	}
+
+	return roles
+}
+
+func (r *MemoryArtistRepository) CreateArtist(ctx context.Context, artist model.CreateArtist) (*model.Artist, error) {
+	if err := checkVarchar(artist.Name, artist.Description); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row := r.db.insertArtist(ctx, artist)
+
+	createdArtist := row.toModel()
+	return &createdArtist, nil
+}
+
+func (r *MemoryArtistRepository) CreateArtistTree(ctx context.Context, artist model.ArtistTree) (*model.ArtistWithAlbums, error) {
+	values := []string{artist.Name, artist.Description}
+	for _, album := range artist.Albums {
+		values = append(values, treeValues(album.Name, album.Songs)...)
+	}
+
+	if err := checkVarchar(values...); err != nil {
+		return nil, err
+	}
+
+	for _, album := range artist.Albums {
+		if err := checkTreeTracks(album.Songs); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, album := range artist.Albums {
+		if err := r.db.checkTreeCredits(album.Artists, album.Songs); err != nil {
+			return nil, err
+		}
+
+		if err := r.db.checkTreeArtists(false, album.Songs); err != nil {
+			return nil, err
+		}
+	}
+
+	row := r.db.insertArtist(ctx, artist.CreateArtist)
+
+	for _, album := range artist.Albums {
+		albumRow := r.db.insertAlbum(ctx, model.CreateAlbum{
+			ArtistID:    row.id,
+			Name:        album.Name,
+			ReleaseYear: album.ReleaseYear,
+			ReleaseDate: album.ReleaseDate,
+			ReleaseType: album.ReleaseType,
+			Artists:     album.Artists,
+		})
+		r.db.insertTreeSongs(ctx, albumRow.id, album.Songs)
+	}
+
+	createdArtist := model.ArtistWithAlbums{Artist: row.toModel(), Albums: r.db.albumsForArtist(row.id), Appearances: []model.Appearance{}}
+	return &createdArtist, nil
+}
+
+func (db *MemoryDB) insertArtist(ctx context.Context, artist model.CreateArtist) *artistRow {
+	row := &artistRow{
+		id:          db.nextArtistID,
+		name:        artist.Name,
+		description: artist.Description,
+		version:     1,
+	}
+	db.artists[row.id] = row
+	db.nextArtistID++
+	db.record(ctx, "artist", "", nil, row.columns())
+
+	return row
+}
+
+func (r *MemoryArtistRepository) UpdateArtist(ctx context.Context, artist model.UpdateArtist, id string, ifMatch []int) (*model.Artist, error) {
+	if err := checkVarchar(artist.Name, artist.Description); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	r.db.updateArtist(ctx, row, artist)
+
+	updatedArtist := row.toModel()
+	return &updatedArtist, nil
+}
+
+func (db *MemoryDB) updateArtist(ctx context.Context, row *artistRow, artist model.UpdateArtist) {
+	db.track(ctx, "artist", row, func() {
+		row.name = artist.Name
+		row.description = artist.Description
+		row.version++
+	})
+}
+
+func (r *MemoryArtistRepository) PatchArtist(ctx context.Context, artist model.PatchArtist, id string, ifMatch []int) (*model.Artist, error) {
+	if err := checkVarchar(deref(artist.Name), deref(artist.Description)); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	r.db.patchArtist(ctx, row, artist)
+
+	patchedArtist := row.toModel()
+	return &patchedArtist, nil
+}
+
+// patchArtist applies the fields of a patch that are set, if there are any,
+// as one change.
+func (db *MemoryDB) patchArtist(ctx context.Context, row *artistRow, artist model.PatchArtist) {
+	if artist.Name == nil && artist.Description == nil {
+		return
+	}
+
+	db.track(ctx, "artist", row, func() {
+		if artist.Name != nil {
+			row.name = *artist.Name
+		}
+		if artist.Description != nil {
+			row.description = *artist.Description
+		}
+		row.version++
+	})
+}
+
+func (r *MemoryArtistRepository) ChangeArtist(ctx context.Context, id string, ifMatch []int, change func(model.Artist) (model.PatchArtist, error)) (*model.Artist, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	artist, err := change(row.toModel())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVarchar(deref(artist.Name), deref(artist.Description)); err != nil {
+		return nil, err
+	}
+
+	r.db.patchArtist(ctx, row, artist)
+
+	changedArtist := row.toModel()
+	return &changedArtist, nil
+}
+
+func (r *MemoryArtistRepository) DeleteArtist(ctx context.Context, id string, ifMatch []int) (*model.ArchivedArtist, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	artist, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, artist.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	now := time.Now()
+	r.db.track(ctx, "artist", artist, func() {
+		artist.archived = true
+		artist.version++
+		artist.archivedAt = now
+	})
+
+	archivedArtist := model.ArchivedArtist{ID: artist.id}
+
+	for _, album := range r.db.albums {
+		if album.artistID != artist.id {
+			continue
+		}
+
+		for _, song := range r.db.songs {
+			if song.albumID == album.id && !song.archived {
+				r.db.track(ctx, "song", song, func() {
+					song.archived = true
+					song.version++
+					song.archivedAt = now
+				})
+				archivedArtist.SongsArchived++
+			}
+		}
+
+		if !album.archived {
+			r.db.track(ctx, "album", album, func() {
+				album.archived = true
+				album.version++
+				album.archivedAt = now
+			})
+			archivedArtist.AlbumsArchived++
+		}
+	}
+
+	// The artist's songs on compilations and other artists' albums go too,
+	// closing the gaps they leave, last track first.
+	others := []*songRow{}
+	for _, song := range r.db.songs {
+		if song.artistID == artist.id && r.db.albums[song.albumID].artistID != artist.id && !song.archived {
+			others = append(others, song)
+		}
+	}
+
+	slices.SortFunc(others, func(a, b *songRow) int { return cmp.Compare(b.trackNumber, a.trackNumber) })
+
+	for _, song := range others {
+		r.db.track(ctx, "song", song, func() {
+			song.archived = true
+			song.version++
+			song.archivedAt = now
+		})
+		r.db.closeTrackGap(ctx, song.albumID, song.discNumber, song.trackNumber)
+	}
+	archivedArtist.OtherSongsArchived = len(others)
+
+	return &archivedArtist, nil
+}
+
+func (r *MemoryArtistRepository) RestoreArtist(ctx context.Context, id string, cascade bool) (*model.RestoredArtist, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	artist, err := r.db.archivedArtist(id)
+	if err != nil {
+		return nil, err
+	}
+	r.db.track(ctx, "artist", artist, func() {
+		artist.archived = false
+		artist.version++
+		artist.archivedAt = time.Time{}
+	})
+
+	restoredArtist := model.RestoredArtist{ID: artist.id}
+
+	if !cascade {
+		return &restoredArtist, nil
+	}
+
+	restored := []*songRow{}
+	for _, album := range r.db.albums {
+		if album.artistID != artist.id {
+			continue
+		}
+
+		if album.archived {
+			r.db.track(ctx, "album", album, func() {
+				album.archived = false
+				album.version++
+				album.archivedAt = time.Time{}
+			})
+			restoredArtist.AlbumsRestored++
+		}
+
+		for _, song := range r.db.songs {
+			if song.albumID == album.id && song.archived {
+				r.db.track(ctx, "song", song, func() {
+					song.archived = false
+					song.version++
+					song.archivedAt = time.Time{}
+				})
+				restored = append(restored, song)
+			}
+		}
+	}
+	restoredArtist.SongsRestored = len(restored)
+
+	for _, song := range r.db.songs {
+		album := r.db.albums[song.albumID]
+		if song.artistID == artist.id && album.artistID != artist.id && !album.archived && song.archived {
+			r.db.track(ctx, "song", song, func() {
+				song.archived = false
+				song.version++
+				song.archivedAt = time.Time{}
+			})
+			restored = append(restored, song)
+			restoredArtist.OtherSongsRestored++
+		}
+	}
+	r.db.placeRestoredSongs(ctx, restored)
+
+	return &restoredArtist, nil
+}
+
+// bestArtistMatches returns the live artists at least threshold similar to
+// name, most similar first. Callers must hold the lock.
+func (db *MemoryDB) bestArtistMatches(name string, threshold float64) []model.ArtistMatch {
+	artists := []model.ArtistMatch{}
+
+	for _, artist := range db.artists {
+		if artist.archived {
+			continue
+		}
+
+		score := similarity(artist.name, name)
+		if score == 0 || score < threshold {
+			continue
+		}
+
+		artists = append(artists, model.ArtistMatch{Artist: artist.toModel(), Score: score})
+	}
+
+	sort.Slice(artists, func(i, j int) bool {
+		if artists[i].Score != artists[j].Score {
+			return artists[i].Score > artists[j].Score
+		}
+		return artists[i].ID < artists[j].ID
+	})
+
+	return artists
+}
+
+func (r *MemoryArtistRepository) MatchArtists(ctx context.Context, params model.MatchParams) ([]model.ArtistMatch, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	artists := r.db.bestArtistMatches(params.Name, params.Threshold)
+	if len(artists) > params.Limit {
+		artists = artists[:params.Limit]
+	}
+
+	return artists, nil
+}
+
+func (r *MemoryArtistRepository) FindOrCreateArtist(ctx context.Context, artist model.CreateArtist, threshold float64) (*model.Artist, bool, error) {
+	if err := checkVarchar(artist.Name, artist.Description); err != nil {
+		return nil, false, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if matches := r.db.bestArtistMatches(artist.Name, threshold); len(matches) > 0 {
+		return &matches[0].Artist, false, nil
+	}
+
+	row := &artistRow{
+		id:          r.db.nextArtistID,
+		name:        artist.Name,
+		description: artist.Description,
+		version:     1,
+	}
+	r.db.artists[row.id] = row
+	r.db.nextArtistID++
+	r.db.record(ctx, "artist", "", nil, row.columns())
+
+	createdArtist := row.toModel()
+	return &createdArtist, true, nil
+}
+
+func (r *MemoryArtistRepository) MergeArtist(ctx context.Context, id string, targetID int) (*model.MergedArtist, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	source, err := r.db.liveArtist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.id == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	if target, ok := r.db.artists[targetID]; !ok || target.archived {
+		return nil, ErrMergeTargetNotFound
+	}
+
+	mergedArtist := model.MergedArtist{ID: source.id, TargetID: targetID}
+
+	for _, album := range r.db.albums {
+		if album.artistID == source.id {
+			r.db.track(ctx, "album", album, func() {
+				album.artistID = targetID
+				album.version++
+			})
+			mergedArtist.AlbumsMoved++
+		}
+	}
+
+	for _, song := range r.db.songs {
+		if song.artistID == source.id {
+			r.db.track(ctx, "song", song, func() {
+				song.artistID = targetID
+				song.version++
+			})
+		}
+	}
+
+	r.db.mergeCredits(source.id, targetID)
+
+	r.db.track(ctx, "artist", source, func() {
+		source.archived = true
+		source.version++
+		source.archivedAt = time.Now()
+	})
+	setRedirect(r.db.artistRedirects, source.id, targetID)
+
+	return &mergedArtist, nil
+}
+
+// mergeCredits moves the credits of an artist being merged to the target,
+// dropping those that would credit the target twice on an album or song.
+func (db *MemoryDB) mergeCredits(sourceID, targetID int) {
+	merge := func(credits []creditRow, ownerID int) []creditRow {
+		if !slices.ContainsFunc(credits, func(c creditRow) bool { return c.artistID == sourceID }) {
+			return credits
+		}
+
+		credited := ownerID == targetID || slices.ContainsFunc(credits, func(c creditRow) bool { return c.artistID == targetID })
+
+		merged := []creditRow{}
+		for _, credit := range credits {
+			if credit.artistID == sourceID {
+				if credited {
+					continue
+				}
+				credit.artistID = targetID
+			}
+
+			merged = append(merged, credit)
+		}
+
+		return merged
+	}
+
+	for _, album := range db.albums {
+		album.credits = merge(album.credits, album.artistID)
+	}
+
+	for _, song := range db.songs {
+		song.credits = merge(song.credits, db.songArtistID(song))
+	}
+}
+
+func (r *MemoryArtistRepository) ArtistRedirect(ctx context.Context, id string) (int, error) {
+	artistID, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	targetID, ok := r.db.artistRedirects[artistID]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+
+	return targetID, nil
+}
+
+func (r *MemoryArtistRepository) BulkArtists(ctx context.Context, items []model.BulkItem[model.CreateArtist, model.UpdateArtist], atomic bool) ([]model.BulkResult, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	check := func(i int) error {
+		item := items[i]
+		if item.ID == 0 {
+			return checkVarchar(item.Create.Name, item.Create.Description)
+		}
+
+		if row, ok := r.db.artists[item.ID]; !ok || row.archived {
+			return pgx.ErrNoRows
+		}
+
+		return checkVarchar(item.Update.Name, item.Update.Description)
+	}
+
+	write := func(i int) model.BulkResult {
+		item := items[i]
+		if item.ID == 0 {
+			return model.BulkResult{ID: r.db.insertArtist(ctx, item.Create).id, Created: true}
+		}
+
+		r.db.updateArtist(ctx, r.db.artists[item.ID], item.Update)
+		return model.BulkResult{ID: item.ID}
+	}
+
+	return r.db.bulkWrite(len(items), atomic, check, write)
+}
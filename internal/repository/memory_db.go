@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MemoryDB is an in-process stand-in for the album, artist, song and people
+// tables. The memory repositories share one MemoryDB so cascades between
+// them behave the same way they do against Postgres.
+type MemoryDB struct {
+	mu sync.RWMutex
+
+	artists map[int]*artistRow
+	albums  map[int]*albumRow
+	songs   map[int]*songRow
+	people  map[int]*personRow
+
+	// personCredits are the credits of people on albums and songs, as in
+	// person_credit.
+	personCredits map[int]*personCreditRow
+
+	// artistRedirects and albumRedirects map the id of a merged record to
+	// the record it was merged into.
+	artistRedirects map[int]int
+	albumRedirects  map[int]int
+
+	// history is the append-only change log, oldest first.
+	history []*revisionRow
+
+	nextArtistID int
+	nextAlbumID  int
+	nextSongID   int
+	nextPersonID int
+
+	nextPersonCreditID int
+
+	nextRevisionID int
+}
+
+type artistRow struct {
+	id          int
+	name        string
+	description string
+	version     int
+	archived    bool
+	archivedAt  time.Time
+}
+
+type albumRow struct {
+	id          int
+	artistID    int
+	compilation bool
+	name        string
+	releaseYear int
+	releaseDate model.ReleaseDate
+	releaseType model.ReleaseType
+	credits     []creditRow
+	version     int
+	archived    bool
+	archivedAt  time.Time
+}
+
+type songRow struct {
+	id              int
+	albumID         int
+	artistID        int
+	title           string
+	discNumber      int
+	discTitle       string
+	trackNumber     int
+	durationSeconds int
+	credits         []creditRow
+	version         int
+	archived        bool
+	archivedAt      time.Time
+}
+
+type personRow struct {
+	id          int
+	name        string
+	description string
+	version     int
+	archived    bool
+	archivedAt  time.Time
+}
+
+// personCreditRow credits a person on either an album or a song; the other
+// id is 0.
+type personCreditRow struct {
+	id       int
+	personID int
+	albumID  int
+	songID   int
+	role     model.PersonRole
+}
+
+// creditRow is an artist an album or song credits, as in album_artist and
+// song_artist.
+type creditRow struct {
+	artistID   int
+	role       model.ArtistRole
+	joinPhrase string
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		artists:            map[int]*artistRow{},
+		albums:             map[int]*albumRow{},
+		songs:              map[int]*songRow{},
+		people:             map[int]*personRow{},
+		personCredits:      map[int]*personCreditRow{},
+		artistRedirects:    map[int]int{},
+		albumRedirects:     map[int]int{},
+		nextArtistID:       1,
+		nextAlbumID:        1,
+		nextSongID:         1,
+		nextPersonID:       1,
+		nextPersonCreditID: 1,
+		nextRevisionID:     1,
+	}
+}
+
+// parseID mirrors Postgres rejecting a non-numeric id for a bigint column.
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     "22P02",
+			Message:  `invalid input syntax for type bigint: "` + id + `"`,
+		}
+	}
+
+	return n, nil
+}
+
+// checkVarchar mirrors the varchar(255) limit on the name, description and
+// title columns.
+func checkVarchar(values ...string) error {
+	for _, v := range values {
+		if len([]rune(v)) > 255 {
+			return &pgconn.PgError{
+				Severity: "ERROR",
+				Code:     "22001",
+				Message:  "value too long for type character varying(255)",
+			}
+		}
+	}
+
+	return nil
+}
+
+// deref returns the value a patch field points to, or "" when it isn't set.
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+// creditRows settles the artists a request credits for storing.
+func creditRows(artists []model.CreditArtist) []creditRow {
+	rows := make([]creditRow, len(artists))
+	for i, artist := range artists {
+		artist = artist.Settled()
+		rows[i] = creditRow{artistID: artist.ArtistID, role: artist.Role, joinPhrase: artist.JoinPhrase}
+	}
+
+	return rows
+}
+
+// checkCredits mirrors replaceCredits refusing to credit an artist that is
+// missing or archived.
+func (db *MemoryDB) checkCredits(artists []model.CreditArtist) error {
+	for _, credit := range artists {
+		if artist, ok := db.artists[credit.ArtistID]; !ok || artist.archived {
+			return ErrCreditedArtistNotFound
+		}
+	}
+
+	return nil
+}
+
+// albumArtists lists the artists an album credits: its artist, if it isn't
+// a compilation, then the others.
+func (db *MemoryDB) albumArtists(a *albumRow) []model.ArtistCredit {
+	return db.appendCredits(db.primaryArtist(a.artistID), a.credits)
+}
+
+// primaryArtist is the credit of an album's or song's own artist, none for
+// 0.
+func (db *MemoryDB) primaryArtist(artistID int) []model.ArtistCredit {
+	if artistID == 0 {
+		return []model.ArtistCredit{}
+	}
+
+	artists := []model.ArtistCredit{{ArtistID: artistID, Role: model.RolePrimary}}
+	if artist, ok := db.artists[artistID]; ok {
+		artists[0].Name = artist.name
+	}
+
+	return artists
+}
+
+// artistName mirrors the name an album or song is listed under: its
+// artist's, or model.VariousArtists for a compilation.
+func (db *MemoryDB) artistName(artistID int) string {
+	if artist, ok := db.artists[artistID]; ok {
+		return artist.name
+	}
+
+	return model.VariousArtists
+}
+
+// ownArtistName is the name of a song's own artist, if it has one, as its
+// album lists it.
+func (db *MemoryDB) ownArtistName(s *songRow) string {
+	if artist, ok := db.artists[s.artistID]; ok {
+		return artist.name
+	}
+
+	return ""
+}
+
+// songArtistID is the artist a song is by: its own, or its album's.
+func (db *MemoryDB) songArtistID(s *songRow) int {
+	if s.artistID != 0 {
+		return s.artistID
+	}
+
+	if album, ok := db.albums[s.albumID]; ok {
+		return album.artistID
+	}
+
+	return 0
+}
+
+// checkSongArtist mirrors checkSongArtists for a song on an album, a
+// compilation or not.
+func (db *MemoryDB) checkSongArtist(compilation bool, artistID int) error {
+	live := map[int]bool{}
+	if artist, ok := db.artists[artistID]; ok && !artist.archived {
+		live[artistID] = true
+	}
+
+	return songArtistError(compilation, artistID, live)
+}
+
+func (db *MemoryDB) appendCredits(artists []model.ArtistCredit, credits []creditRow) []model.ArtistCredit {
+	for _, credit := range credits {
+		artist := model.ArtistCredit{ArtistID: credit.artistID, Role: credit.role, JoinPhrase: credit.joinPhrase}
+		if row, ok := db.artists[credit.artistID]; ok {
+			artist.Name = row.name
+		}
+
+		artists = append(artists, artist)
+	}
+
+	return artists
+}
+
+func (db *MemoryDB) albumCredits(a *albumRow) model.Credits {
+	return model.NewCredits(db.albumArtists(a))
+}
+
+// songCredits returns the credits of a song: its own artist if it has one
+// and otherwise its album's credits, then the artists it credits itself.
+func (db *MemoryDB) songCredits(s *songRow) model.Credits {
+	artists := db.primaryArtist(s.artistID)
+	if album, ok := db.albums[s.albumID]; ok && s.artistID == 0 {
+		artists = db.albumArtists(album)
+	}
+
+	return model.NewCredits(db.appendCredits(artists, s.credits))
+}
+
+func (db *MemoryDB) archivedArtist(id string) (*artistRow, error) {
+	artistID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artist, ok := db.artists[artistID]
+	if !ok || !artist.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return artist, nil
+}
+
+func (db *MemoryDB) archivedAlbum(id string) (*albumRow, error) {
+	albumID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	album, ok := db.albums[albumID]
+	if !ok || !album.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return album, nil
+}
+
+func (db *MemoryDB) archivedSong(id string) (*songRow, error) {
+	songID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	song, ok := db.songs[songID]
+	if !ok || !song.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return song, nil
+}
+
+func (db *MemoryDB) liveArtist(id string) (*artistRow, error) {
+	artistID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	artist, ok := db.artists[artistID]
+	if !ok || artist.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return artist, nil
+}
+
+func (db *MemoryDB) liveAlbum(id string) (*albumRow, error) {
+	albumID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	album, ok := db.albums[albumID]
+	if !ok || album.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return album, nil
+}
+
+func (db *MemoryDB) liveSong(id string) (*songRow, error) {
+	songID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	song, ok := db.songs[songID]
+	if !ok || song.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return song, nil
+}
+
+// setRedirect points the merged source, and anything already redirecting
+// to it, at the target, dropping the target's own stale redirect.
+func setRedirect(redirects map[int]int, sourceID, targetID int) {
+	delete(redirects, targetID)
+
+	for id, to := range redirects {
+		if to == sourceID {
+			redirects[id] = targetID
+		}
+	}
+
+	redirects[sourceID] = targetID
+}
+
+// bulkWrite is the in-memory counterpart of the package's bulkWrite. check
+// vets an item and write writes it. Every item is checked before any is
+// written, so an all-or-nothing write that fails leaves the store as it was.
+// The caller holds the write lock.
+func (db *MemoryDB) bulkWrite(n int, atomic bool, check func(i int) error, write func(i int) model.BulkResult) ([]model.BulkResult, error) {
+	results := make([]model.BulkResult, n)
+
+	for i := range results {
+		if err := check(i); err != nil {
+			if atomic {
+				return nil, &BulkError{Index: i, Err: Translate(err)}
+			}
+
+			results[i].Err = Translate(err)
+		}
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = write(i)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type revisionRow struct {
+	entity   string
+	entityID int
+	revision model.Revision
+}
+
+// auditedRow is a memory row whose columns are recorded in the history.
+type auditedRow interface {
+	columns() map[string]any
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
+
+func (a *artistRow) columns() map[string]any {
+	return map[string]any{
+		"id":          a.id,
+		"name":        a.name,
+		"description": a.description,
+		"archived":    a.archived,
+		"archived_at": nullTime(a.archivedAt),
+	}
+}
+
+func (a *albumRow) columns() map[string]any {
+	return map[string]any{
+		"id":           a.id,
+		"artist_id":    nullID(a.artistID),
+		"compilation":  a.compilation,
+		"name":         a.name,
+		"release_year": a.releaseYear,
+		"release_date": string(a.releaseDate),
+		"release_type": string(a.releaseType),
+		"archived":     a.archived,
+		"archived_at":  nullTime(a.archivedAt),
+	}
+}
+
+func (p *personRow) columns() map[string]any {
+	return map[string]any{
+		"id":          p.id,
+		"name":        p.name,
+		"description": p.description,
+		"archived":    p.archived,
+		"archived_at": nullTime(p.archivedAt),
+	}
+}
+
+func (s *songRow) columns() map[string]any {
+	return map[string]any{
+		"id":               s.id,
+		"album_id":         s.albumID,
+		"artist_id":        nullID(s.artistID),
+		"title":            s.title,
+		"disc_number":      s.discNumber,
+		"disc_title":       s.discTitle,
+		"track_number":     s.trackNumber,
+		"duration_seconds": s.durationSeconds,
+		"archived":         s.archived,
+		"archived_at":      nullTime(s.archivedAt),
+	}
+}
+
+// record appends a revision the same way the record_history trigger does:
+// creates keep the whole row, purges the whole old row and updates only the
+// columns that changed. Updates that change nothing aren't recorded. An empty
+// action is worked out from the change. Callers must hold the write lock.
+func (db *MemoryDB) record(ctx context.Context, entity, action string, before, after map[string]any) {
+	revision := model.Revision{
+		ID:        db.nextRevisionID,
+		Action:    action,
+		Actor:     actorFrom(ctx),
+		Before:    before,
+		After:     after,
+		ChangedAt: time.Now(),
+	}
+
+	switch {
+	case before == nil:
+		if revision.Action == "" {
+			revision.Action = "create"
+		}
+	case after == nil:
+		revision.Action = "purge"
+	default:
+		revision.Before, revision.After = map[string]any{}, map[string]any{}
+		for column, value := range after {
+			if before[column] != value {
+				revision.Before[column] = before[column]
+				revision.After[column] = value
+			}
+		}
+
+		if len(revision.After) == 0 {
+			return
+		}
+
+		if revision.Action == "" {
+			switch {
+			case !before["archived"].(bool) && after["archived"].(bool):
+				revision.Action = "delete"
+			case before["archived"].(bool) && !after["archived"].(bool):
+				revision.Action = "restore"
+			default:
+				revision.Action = "update"
+			}
+		}
+	}
+
+	entityID := after["id"]
+	if after == nil {
+		entityID = before["id"]
+	}
+
+	db.history = append(db.history, &revisionRow{entity: entity, entityID: entityID.(int), revision: revision})
+	db.nextRevisionID++
+}
+
+// track records the change fn makes to row.
+func (db *MemoryDB) track(ctx context.Context, entity string, row auditedRow, fn func()) {
+	before := row.columns()
+	fn()
+	db.record(ctx, entity, "", before, row.columns())
+}
+
+type MemoryHistoryRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryHistoryRepository(db *MemoryDB) *MemoryHistoryRepository {
+	return &MemoryHistoryRepository{
+		db: db,
+	}
+}
+
+func (db *MemoryDB) auditedRow(entity string, id int) (auditedRow, bool) {
+	switch entity {
+	case "artist":
+		row, ok := db.artists[id]
+		return row, ok
+	case "album":
+		row, ok := db.albums[id]
+		return row, ok
+	case "song":
+		row, ok := db.songs[id]
+		return row, ok
+	case "person":
+		row, ok := db.people[id]
+		return row, ok
+	}
+
+	return nil, false
+}
+
+func (r *MemoryHistoryRepository) History(ctx context.Context, entity string, id string) ([]model.Revision, error) {
+	entityID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	revisions := []model.Revision{}
+
+	for i := len(r.db.history) - 1; i >= 0; i-- {
+		row := r.db.history[i]
+		if row.entity == entity && row.entityID == entityID {
+			revisions = append(revisions, row.revision)
+		}
+	}
+
+	if _, ok := r.db.auditedRow(entity, entityID); !ok && len(revisions) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return revisions, nil
+}
+
+func (r *MemoryHistoryRepository) Revert(ctx context.Context, entity string, id string, revisionID int) (*model.Revision, error) {
+	entityID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.auditedRow(entity, entityID)
+	if !ok || row.columns()["archived"].(bool) {
+		return nil, pgx.ErrNoRows
+	}
+
+	found := false
+	befores := []map[string]any{}
+
+	for i := len(r.db.history) - 1; i >= 0; i-- {
+		revision := r.db.history[i]
+		if revision.entity != entity || revision.entityID != entityID {
+			continue
+		}
+
+		if revision.revision.ID == revisionID {
+			found = true
+			break
+		}
+		befores = append(befores, revision.revision.Before)
+	}
+
+	if !found {
+		return nil, ErrRevisionNotFound
+	}
+
+	columns := HistoryEntities[entity]
+	current := row.columns()
+	state := revertedState(columns, current, befores)
+	if !changesColumns(columns, current, state) {
+		return nil, nil
+	}
+
+	if song, ok := row.(*songRow); ok {
+		if err := r.db.tracklists().move(song.id, state["disc_number"].(int), state["track_number"].(int)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch row := row.(type) {
+	case *artistRow:
+		row.name = state["name"].(string)
+		row.description = state["description"].(string)
+		row.version++
+	case *albumRow:
+		row.name = state["name"].(string)
+		row.releaseYear = state["release_year"].(int)
+		row.releaseDate = model.ReleaseDate(state["release_date"].(string))
+		row.releaseType = model.ReleaseType(state["release_type"].(string))
+		row.version++
+	case *personRow:
+		row.name = state["name"].(string)
+		row.description = state["description"].(string)
+		row.version++
+	case *songRow:
+		row.title = state["title"].(string)
+		row.discNumber = state["disc_number"].(int)
+		row.discTitle = state["disc_title"].(string)
+		row.trackNumber = state["track_number"].(int)
+		row.durationSeconds = state["duration_seconds"].(int)
+		row.version++
+	}
+
+	r.db.record(ctx, entity, "revert", current, row.columns())
+
+	revision := r.db.history[len(r.db.history)-1].revision
+	return &revision, nil
+}
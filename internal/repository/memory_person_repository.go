@@ -0,0 +1,544 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type MemoryPersonRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryPersonRepository(db *MemoryDB) *MemoryPersonRepository {
+	return &MemoryPersonRepository{
+		db: db,
+	}
+}
+
+func (p *personRow) toModel() model.Person {
+	return model.Person{
+		ID:          p.id,
+		Name:        p.name,
+		Description: p.description,
+		Version:     p.version,
+	}
+}
+
+func (p *personRow) field(name string) any {
+	switch name {
+	case "id":
+		return p.id
+	case "name":
+		return p.name
+	case "description":
+		return p.description
+	}
+
+	return nil
+}
+
+func (c *personCreditRow) toModel() model.PersonCredit {
+	return model.PersonCredit{
+		ID:       c.id,
+		PersonID: c.personID,
+		AlbumID:  c.albumID,
+		SongID:   c.songID,
+		Role:     c.role,
+	}
+}
+
+func (r *MemoryPersonRepository) GetPeople(ctx context.Context, params model.ListParams) (*model.Page[model.Person], error) {
+	l, err := newListing(params, personColumns, personOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	people := []model.Person{}
+
+	for _, person := range r.db.people {
+		if person.archived != params.Archived || !matchesFilters(params.Filters, person.field) {
+			continue
+		}
+
+		people = append(people, person.toModel())
+	}
+
+	return paginate(l, people, personValue), nil
+}
+
+func (r *MemoryPersonRepository) GetPerson(ctx context.Context, id string) (*model.Person, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	person := row.toModel()
+	return &person, nil
+}
+
+func (r *MemoryPersonRepository) GetPersonCredits(ctx context.Context, id string) ([]model.PersonAlbum, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := []model.PersonAlbum{}
+
+	for _, album := range r.db.albums {
+		if album.archived {
+			continue
+		}
+
+		credited := model.PersonAlbum{Album: r.db.albumModel(album), Roles: r.db.personRoles(row.id, album.id, 0)}
+
+		for _, song := range r.db.songsForAlbum(album.id) {
+			if roles := r.db.personRoles(row.id, 0, song.ID); len(roles) > 0 {
+				credited.Songs = append(credited.Songs, model.PersonSong{ID: song.ID, Title: song.Title, Roles: roles})
+			}
+		}
+
+		if len(credited.Roles) > 0 || len(credited.Songs) > 0 {
+			albums = append(albums, credited)
+		}
+	}
+
+	slices.SortFunc(albums, func(a, b model.PersonAlbum) int {
+		return model.CompareReleases(a.Album, b.Album)
+	})
+
+	return albums, nil
+}
+
+// personRoles lists the roles a person is credited in on an album or song,
+// in the order of model.PersonRoles.
+func (db *MemoryDB) personRoles(personID, albumID, songID int) []model.PersonRole {
+	roles := []model.PersonRole{}
+	for _, credit := range db.personCredits {
+		if credit.personID == personID && credit.albumID == albumID && credit.songID == songID {
+			roles = append(roles, credit.role)
+		}
+	}
+
+	slices.SortFunc(roles, comparePersonRoles)
+
+	return roles
+}
+
+func comparePersonRoles(a, b model.PersonRole) int {
+	return cmp.Compare(slices.Index(model.PersonRoles, a), slices.Index(model.PersonRoles, b))
+}
+
+// personnel lists the credits matched by credited whose person is live, the
+// way loadPersonnel orders them.
+func (db *MemoryDB) personnel(credited func(*personCreditRow) bool) []model.Personnel {
+	personnel := []model.Personnel{}
+
+	for _, credit := range db.personCredits {
+		person, ok := db.people[credit.personID]
+		if !credited(credit) || !ok || person.archived {
+			continue
+		}
+
+		personnel = append(personnel, model.Personnel{CreditID: credit.id, PersonID: person.id, Name: person.name, Role: credit.role})
+	}
+
+	slices.SortFunc(personnel, func(a, b model.Personnel) int {
+		return cmp.Or(
+			comparePersonRoles(a.Role, b.Role),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.CreditID, b.CreditID),
+		)
+	})
+
+	return personnel
+}
+
+func (db *MemoryDB) songPersonnel(songID int) []model.Personnel {
+	return db.personnel(func(c *personCreditRow) bool { return c.songID == songID })
+}
+
+func (r *MemoryPersonRepository) CreatePerson(ctx context.Context, person model.CreatePerson) (*model.Person, error) {
+	if err := checkVarchar(person.Name, person.Description); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row := &personRow{
+		id:          r.db.nextPersonID,
+		name:        person.Name,
+		description: person.Description,
+		version:     1,
+	}
+	r.db.people[row.id] = row
+	r.db.nextPersonID++
+	r.db.record(ctx, "person", "", nil, row.columns())
+
+	createdPerson := row.toModel()
+	return &createdPerson, nil
+}
+
+func (r *MemoryPersonRepository) UpdatePerson(ctx context.Context, person model.UpdatePerson, id string, ifMatch []int) (*model.Person, error) {
+	if err := checkVarchar(person.Name, person.Description); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	r.db.track(ctx, "person", row, func() {
+		row.name = person.Name
+		row.description = person.Description
+		row.version++
+	})
+
+	updatedPerson := row.toModel()
+	return &updatedPerson, nil
+}
+
+func (r *MemoryPersonRepository) PatchPerson(ctx context.Context, person model.PatchPerson, id string, ifMatch []int) (*model.Person, error) {
+	if err := checkVarchar(deref(person.Name), deref(person.Description)); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	r.db.patchPerson(ctx, row, person)
+
+	patchedPerson := row.toModel()
+	return &patchedPerson, nil
+}
+
+// patchPerson applies the fields of a patch that are set, if there are any,
+// as one change.
+func (db *MemoryDB) patchPerson(ctx context.Context, row *personRow, person model.PatchPerson) {
+	if person.Name == nil && person.Description == nil {
+		return
+	}
+
+	db.track(ctx, "person", row, func() {
+		if person.Name != nil {
+			row.name = *person.Name
+		}
+		if person.Description != nil {
+			row.description = *person.Description
+		}
+		row.version++
+	})
+}
+
+func (r *MemoryPersonRepository) ChangePerson(ctx context.Context, id string, ifMatch []int, change func(model.Person) (model.PatchPerson, error)) (*model.Person, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	person, err := change(row.toModel())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVarchar(deref(person.Name), deref(person.Description)); err != nil {
+		return nil, err
+	}
+
+	r.db.patchPerson(ctx, row, person)
+
+	changedPerson := row.toModel()
+	return &changedPerson, nil
+}
+
+func (r *MemoryPersonRepository) DeletePerson(ctx context.Context, id string, ifMatch []int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.livePerson(id)
+	if err != nil {
+		return err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return ErrVersionMismatch
+	}
+
+	r.db.track(ctx, "person", row, func() {
+		row.archived = true
+		row.archivedAt = time.Now()
+		row.version++
+	})
+
+	return nil
+}
+
+func (r *MemoryPersonRepository) RestorePerson(ctx context.Context, id string) (*model.Person, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.archivedPerson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.track(ctx, "person", row, func() {
+		row.archived = false
+		row.archivedAt = time.Time{}
+		row.version++
+	})
+
+	restoredPerson := row.toModel()
+	return &restoredPerson, nil
+}
+
+func (r *MemoryPersonRepository) GetPersonCredit(ctx context.Context, id string) (*model.PersonCredit, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.personCredit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	credit := row.toModel()
+	return &credit, nil
+}
+
+func (r *MemoryPersonRepository) CreatePersonCredit(ctx context.Context, credit model.CreatePersonCredit) (*model.PersonCredit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkPerson(credit.PersonID); err != nil {
+		return nil, err
+	}
+
+	if album, ok := r.db.albums[credit.AlbumID]; credit.AlbumID != 0 && (!ok || album.archived) {
+		return nil, ErrParentNotFound
+	}
+
+	if song, ok := r.db.songs[credit.SongID]; credit.SongID != 0 && (!ok || song.archived) {
+		return nil, ErrParentNotFound
+	}
+
+	row := &personCreditRow{
+		id:       r.db.nextPersonCreditID,
+		personID: credit.PersonID,
+		albumID:  credit.AlbumID,
+		songID:   credit.SongID,
+		role:     credit.Role,
+	}
+
+	if err := r.db.checkPersonCreditTaken(row); err != nil {
+		return nil, err
+	}
+
+	r.db.personCredits[row.id] = row
+	r.db.nextPersonCreditID++
+	r.db.touchCredited(row)
+
+	createdCredit := row.toModel()
+	return &createdCredit, nil
+}
+
+func (r *MemoryPersonRepository) UpdatePersonCredit(ctx context.Context, credit model.UpdatePersonCredit, id string) (*model.PersonCredit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkPerson(credit.PersonID); err != nil {
+		return nil, err
+	}
+
+	row, err := r.db.personCredit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *row
+	updated.personID = credit.PersonID
+	updated.role = credit.Role
+
+	if err := r.db.checkPersonCreditTaken(&updated); err != nil {
+		return nil, err
+	}
+
+	*row = updated
+	r.db.touchCredited(row)
+
+	updatedCredit := row.toModel()
+	return &updatedCredit, nil
+}
+
+func (r *MemoryPersonRepository) DeletePersonCredit(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.personCredit(id)
+	if err != nil {
+		return err
+	}
+
+	delete(r.db.personCredits, row.id)
+	r.db.touchCredited(row)
+
+	return nil
+}
+
+// checkPerson mirrors lockPerson refusing to credit a person that is missing
+// or archived.
+func (db *MemoryDB) checkPerson(personID int) error {
+	if person, ok := db.people[personID]; !ok || person.archived {
+		return ErrPersonNotFound
+	}
+
+	return nil
+}
+
+// checkPersonCreditTaken mirrors the unique indexes on person_credit for a
+// credit about to be written.
+func (db *MemoryDB) checkPersonCreditTaken(c *personCreditRow) error {
+	for _, other := range db.personCredits {
+		if other.id != c.id && other.personID == c.personID && other.albumID == c.albumID && other.songID == c.songID && other.role == c.role {
+			return ErrPersonCreditTaken
+		}
+	}
+
+	return nil
+}
+
+// touchCredited mirrors touch on the album or song a credit is on.
+func (db *MemoryDB) touchCredited(c *personCreditRow) {
+	if album, ok := db.albums[c.albumID]; ok {
+		album.version++
+	}
+
+	if song, ok := db.songs[c.songID]; ok {
+		song.version++
+	}
+}
+
+// mergePersonCredits is the in-memory counterpart of the package's
+// mergePersonCredits.
+func (db *MemoryDB) mergePersonCredits(source, target *albumRow) {
+	type role struct {
+		personID int
+		role     model.PersonRole
+	}
+
+	held := map[role]bool{}
+	for _, credit := range db.personCredits {
+		if credit.albumID == target.id {
+			held[role{credit.personID, credit.role}] = true
+		}
+	}
+
+	moved := false
+	for id, credit := range db.personCredits {
+		if credit.albumID != source.id {
+			continue
+		}
+
+		if held[role{credit.personID, credit.role}] {
+			delete(db.personCredits, id)
+			continue
+		}
+
+		credit.albumID = target.id
+		moved = true
+	}
+
+	if moved {
+		target.version++
+	}
+}
+
+// deletePersonCredits mirrors person_credit's ON DELETE CASCADE on the
+// person, album and song.
+func (db *MemoryDB) deletePersonCredits(credited func(*personCreditRow) bool) {
+	for id, credit := range db.personCredits {
+		if credited(credit) {
+			delete(db.personCredits, id)
+		}
+	}
+}
+
+func (db *MemoryDB) personCredit(id string) (*personCreditRow, error) {
+	creditID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, ok := db.personCredits[creditID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return credit, nil
+}
+
+func (db *MemoryDB) livePerson(id string) (*personRow, error) {
+	personID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	person, ok := db.people[personID]
+	if !ok || person.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return person, nil
+}
+
+func (db *MemoryDB) archivedPerson(id string) (*personRow, error) {
+	personID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	person, ok := db.people[personID]
+	if !ok || !person.archived {
+		return nil, pgx.ErrNoRows
+	}
+
+	return person, nil
+}
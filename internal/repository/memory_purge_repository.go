@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+)
+
+type MemoryPurgeRepository struct {
+	db *MemoryDB
+}
+
+func NewMemoryPurgeRepository(db *MemoryDB) *MemoryPurgeRepository {
+	return &MemoryPurgeRepository{
+		db: db,
+	}
+}
+
+func (r *MemoryPurgeRepository) Purge(ctx context.Context, archivedBefore time.Time, dryRun bool) (*model.PurgeResult, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	expired := func(archived bool, archivedAt time.Time) bool {
+		return archived && archivedAt.Before(archivedBefore)
+	}
+
+	result := model.PurgeResult{
+		ArchivedBefore: archivedBefore,
+		DryRun:         dryRun,
+		ArtistIDs:      []int{},
+		AlbumIDs:       []int{},
+		SongIDs:        []int{},
+		PersonIDs:      []int{},
+	}
+
+	purgedArtists := map[int]bool{}
+	for _, artist := range r.db.artists {
+		if expired(artist.archived, artist.archivedAt) {
+			purgedArtists[artist.id] = true
+			result.ArtistIDs = append(result.ArtistIDs, artist.id)
+		}
+	}
+
+	purgedAlbums := map[int]bool{}
+	for _, album := range r.db.albums {
+		if expired(album.archived, album.archivedAt) || purgedArtists[album.artistID] {
+			purgedAlbums[album.id] = true
+			result.AlbumIDs = append(result.AlbumIDs, album.id)
+		}
+	}
+
+	for _, song := range r.db.songs {
+		if expired(song.archived, song.archivedAt) || purgedAlbums[song.albumID] {
+			result.SongIDs = append(result.SongIDs, song.id)
+		}
+	}
+
+	for _, person := range r.db.people {
+		if expired(person.archived, person.archivedAt) {
+			result.PersonIDs = append(result.PersonIDs, person.id)
+		}
+	}
+
+	sort.Ints(result.ArtistIDs)
+	sort.Ints(result.AlbumIDs)
+	sort.Ints(result.SongIDs)
+	sort.Ints(result.PersonIDs)
+
+	if dryRun {
+		return &result, nil
+	}
+
+	for _, id := range result.ArtistIDs {
+		r.db.record(ctx, "artist", "", r.db.artists[id].columns(), nil)
+		delete(r.db.artists, id)
+		deleteRedirectsTo(r.db.artistRedirects, id)
+		r.db.deleteCreditsOf(id)
+	}
+	for _, id := range result.AlbumIDs {
+		r.db.record(ctx, "album", "", r.db.albums[id].columns(), nil)
+		delete(r.db.albums, id)
+		deleteRedirectsTo(r.db.albumRedirects, id)
+		r.db.deletePersonCredits(func(c *personCreditRow) bool { return c.albumID == id })
+	}
+	for _, id := range result.SongIDs {
+		r.db.record(ctx, "song", "", r.db.songs[id].columns(), nil)
+		delete(r.db.songs, id)
+		r.db.deletePersonCredits(func(c *personCreditRow) bool { return c.songID == id })
+	}
+	for _, id := range result.PersonIDs {
+		r.db.record(ctx, "person", "", r.db.people[id].columns(), nil)
+		delete(r.db.people, id)
+		r.db.deletePersonCredits(func(c *personCreditRow) bool { return c.personID == id })
+	}
+
+	return &result, nil
+}
+
+// deleteCreditsOf mirrors the credit tables' ON DELETE CASCADE on the
+// artist, and song.artist_id's ON DELETE SET NULL.
+func (db *MemoryDB) deleteCreditsOf(artistID int) {
+	credited := func(c creditRow) bool { return c.artistID == artistID }
+
+	for _, album := range db.albums {
+		album.credits = slices.DeleteFunc(album.credits, credited)
+	}
+	for _, song := range db.songs {
+		song.credits = slices.DeleteFunc(song.credits, credited)
+		if song.artistID == artistID {
+			song.artistID = 0
+		}
+	}
+}
+
+// deleteRedirectsTo mirrors the redirect tables' ON DELETE CASCADE on the
+// target.
+func deleteRedirectsTo(redirects map[int]int, targetID int) {
+	for id, to := range redirects {
+		if to == targetID {
+			delete(redirects, id)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/liamcoleman/music-go/internal/model"
+)
+
+type MemorySearchRepository struct {
+	db *MemoryDB
+}
+
+func NewMemorySearchRepository(db *MemoryDB) *MemorySearchRepository {
+	return &MemorySearchRepository{
+		db: db,
+	}
+}
+
+type weightedText struct {
+	text   string
+	weight float64
+}
+
+// searchRank scores a document by the best weighted text each term prefixes
+// a word of, mirroring the A/B weights of the search_vector columns. It is
+// zero unless every term matches.
+func searchRank(terms []string, texts ...weightedText) float64 {
+	var rank float64
+
+	for _, term := range terms {
+		best := 0.0
+		for _, t := range texts {
+			for _, word := range searchTerms(t.text) {
+				if strings.HasPrefix(word, term) && t.weight > best {
+					best = t.weight
+				}
+			}
+		}
+
+		if best == 0 {
+			return 0
+		}
+		rank += best
+	}
+
+	return rank / float64(len(terms))
+}
+
+// highlight wraps every word starting with one of the terms in <mark> tags,
+// the same way ts_headline does.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+
+		word := string(runes[i:j])
+		matched := slices.ContainsFunc(terms, func(term string) bool {
+			return strings.HasPrefix(strings.ToLower(word), term)
+		})
+
+		if matched {
+			b.WriteString("<mark>" + word + "</mark>")
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+
+	return b.String()
+}
+
+func (r *MemorySearchRepository) Search(ctx context.Context, params model.SearchParams) ([]model.SearchResult, error) {
+	results := []model.SearchResult{}
+
+	terms := searchTerms(params.Query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	include := func(searchType string) bool {
+		return len(params.Types) == 0 || slices.Contains(params.Types, searchType)
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if include("artist") {
+		for _, artist := range r.db.artists {
+			if artist.archived != params.Archived {
+				continue
+			}
+
+			rank := searchRank(terms, weightedText{artist.name, 1}, weightedText{artist.description, 0.4})
+			if rank == 0 {
+				continue
+			}
+
+			snippet := artist.name
+			if artist.description != "" {
+				snippet += " - " + artist.description
+			}
+
+			results = append(results, model.SearchResult{
+				Type:    "artist",
+				ID:      artist.id,
+				Name:    artist.name,
+				Rank:    rank,
+				Snippet: highlight(snippet, terms),
+			})
+		}
+	}
+
+	if include("album") {
+		for _, album := range r.db.albums {
+			if album.archived != params.Archived {
+				continue
+			}
+
+			rank := searchRank(terms, weightedText{album.name, 1})
+			if rank == 0 {
+				continue
+			}
+
+			results = append(results, model.SearchResult{
+				Type:       "album",
+				ID:         album.id,
+				Name:       album.name,
+				ArtistName: r.db.artistName(album.artistID),
+				Rank:       rank,
+				Snippet:    highlight(album.name, terms),
+			})
+		}
+	}
+
+	if include("song") {
+		for _, song := range r.db.songs {
+			if song.archived != params.Archived {
+				continue
+			}
+
+			rank := searchRank(terms, weightedText{song.title, 1})
+			if rank == 0 {
+				continue
+			}
+
+			result := r.db.songModel(song)
+			results = append(results, model.SearchResult{
+				Type:       "song",
+				ID:         song.id,
+				Name:       song.title,
+				ArtistName: result.ArtistName,
+				AlbumName:  result.AlbumName,
+				Rank:       rank,
+				Snippet:    highlight(song.title, terms),
+			})
+		}
+	}
+
+	slices.SortFunc(results, func(a, b model.SearchResult) int {
+		switch {
+		case a.Rank > b.Rank:
+			return -1
+		case a.Rank < b.Rank:
+			return 1
+		case a.Type != b.Type:
+			return strings.Compare(a.Type, b.Type)
+		}
+		return a.ID - b.ID
+	})
+
+	if len(results) > params.Limit {
+		results = results[:params.Limit]
+	}
+
+	return results, nil
+}
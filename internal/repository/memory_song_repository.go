@@ -0,0 +1,583 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type MemorySongRepository struct {
+	db *MemoryDB
+}
+
+func NewMemorySongRepository(db *MemoryDB) *MemorySongRepository {
+	return &MemorySongRepository{
+		db: db,
+	}
+}
+
+func (db *MemoryDB) songResponse(s *songRow) model.SongResponse {
+	return model.SongResponse{
+		ID:              s.id,
+		ArtistID:        s.artistID,
+		Title:           s.title,
+		DiscNumber:      s.discNumber,
+		DiscTitle:       s.discTitle,
+		TrackNumber:     s.trackNumber,
+		DurationSeconds: s.durationSeconds,
+		Credits:         db.songCredits(s),
+		Version:         s.version,
+	}
+}
+
+func (db *MemoryDB) songModel(s *songRow) model.Song {
+	song := model.Song{
+		ID:              s.id,
+		ArtistID:        s.artistID,
+		ArtistName:      db.artistName(db.songArtistID(s)),
+		Title:           s.title,
+		DiscNumber:      s.discNumber,
+		DiscTitle:       s.discTitle,
+		TrackNumber:     s.trackNumber,
+		DurationSeconds: s.durationSeconds,
+		Credits:         db.songCredits(s),
+		Version:         s.version,
+	}
+
+	if album, ok := db.albums[s.albumID]; ok {
+		song.AlbumName = album.name
+	}
+
+	return song
+}
+
+func (db *MemoryDB) songField(s *songRow) func(string) any {
+	return func(name string) any {
+		switch name {
+		case "id":
+			return s.id
+		case "title":
+			return s.title
+		case "disc_number":
+			return s.discNumber
+		case "track_number":
+			return s.trackNumber
+		case "duration_seconds":
+			return s.durationSeconds
+		case "album_id":
+			return s.albumID
+		}
+
+		album, ok := db.albums[s.albumID]
+		if !ok {
+			return nil
+		}
+
+		switch name {
+		case "album":
+			return album.name
+		case "release_year":
+			return album.releaseYear
+		case "artist_id":
+			return nullID(db.songArtistID(s))
+		case "artist":
+			return db.artistName(db.songArtistID(s))
+		}
+
+		return nil
+	}
+}
+
+func (r *MemorySongRepository) GetSongs(ctx context.Context, params model.ListParams) (*model.Page[model.Song], error) {
+	l, err := newListing(params, songColumns, songOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	songs := []model.Song{}
+
+	for _, song := range r.db.songs {
+		if song.archived != params.Archived || !matchesFilters(params.Filters, r.db.songField(song)) {
+			continue
+		}
+
+		songs = append(songs, r.db.songModel(song))
+	}
+
+	return paginate(l, songs, songValue), nil
+}
+
+func (r *MemorySongRepository) GetAlbumSongs(ctx context.Context, albumID string, params model.ListParams) (*model.Page[model.Song], error) {
+	r.db.mu.RLock()
+	album, err := r.db.liveAlbum(albumID)
+	r.db.mu.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetSongs(ctx, childParams(params, "album_id", album.id))
+}
+
+func (r *MemorySongRepository) GetSong(ctx context.Context, id string) (*model.Song, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, err := r.db.liveSong(id)
+	if err != nil {
+		return nil, err
+	}
+
+	song := r.db.songModel(row)
+	song.Personnel = r.db.songPersonnel(row.id)
+
+	return &song, nil
+}
+
+func (r *MemorySongRepository) CreateSong(ctx context.Context, song model.CreateSong) (*model.SongResponse, error) {
+	if err := checkVarchar(song.Title); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	album, ok := r.db.albums[song.AlbumID]
+	if !ok || album.archived {
+		return nil, ErrParentNotFound
+	}
+
+	if err := r.db.checkSongArtist(album.compilation, song.ArtistID); err != nil {
+		return nil, err
+	}
+
+	track, err := r.db.tracklists().add(song.AlbumID, model.DiscOrFirst(song.DiscNumber), song.TrackNumber)
+	if err != nil {
+		return nil, err
+	}
+	song.TrackNumber = track
+
+	if err := r.db.checkCredits(song.Artists); err != nil {
+		return nil, err
+	}
+
+	row := r.db.insertSong(ctx, song)
+
+	songCreated := r.db.songResponse(row)
+	return &songCreated, nil
+}
+
+func (db *MemoryDB) insertSong(ctx context.Context, song model.CreateSong) *songRow {
+	row := &songRow{
+		id:              db.nextSongID,
+		albumID:         song.AlbumID,
+		artistID:        song.ArtistID,
+		title:           song.Title,
+		discNumber:      model.DiscOrFirst(song.DiscNumber),
+		discTitle:       song.DiscTitle,
+		trackNumber:     song.TrackNumber,
+		durationSeconds: song.DurationSeconds,
+		credits:         creditRows(song.Artists),
+		version:         1,
+	}
+	db.songs[row.id] = row
+	db.nextSongID++
+	db.record(ctx, "song", "", nil, row.columns())
+
+	return row
+}
+
+func (r *MemorySongRepository) UpdateSong(ctx context.Context, song model.UpdateSong, id string, ifMatch []int) (*model.SongResponse, error) {
+	if err := checkVarchar(song.Title); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveSong(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err := r.db.checkSongArtist(r.db.albums[row.albumID].compilation, song.ArtistID); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.tracklists().move(row.id, model.DiscOrFirst(song.DiscNumber), song.TrackNumber); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.checkCredits(song.Artists); err != nil {
+		return nil, err
+	}
+
+	r.db.updateSong(ctx, row, song)
+
+	updateSong := r.db.songResponse(row)
+	return &updateSong, nil
+}
+
+func (db *MemoryDB) updateSong(ctx context.Context, row *songRow, song model.UpdateSong) {
+	db.track(ctx, "song", row, func() {
+		row.artistID = song.ArtistID
+		row.title = song.Title
+		row.discNumber = model.DiscOrFirst(song.DiscNumber)
+		row.discTitle = song.DiscTitle
+		row.trackNumber = song.TrackNumber
+		row.durationSeconds = song.DurationSeconds
+		row.credits = creditRows(song.Artists)
+		row.version++
+	})
+}
+
+func (r *MemorySongRepository) PatchSong(ctx context.Context, song model.PatchSong, id string, ifMatch []int) (*model.SongResponse, error) {
+	if err := checkVarchar(deref(song.Title)); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveSong(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if song.ArtistID != nil {
+		if err := r.db.checkSongArtist(false, *song.ArtistID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.moveSong(row, song); err != nil {
+		return nil, err
+	}
+
+	if song.Artists != nil {
+		if err := r.db.checkCredits(*song.Artists); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.patchSong(ctx, row, song)
+
+	patchedSong := r.db.songResponse(row)
+	return &patchedSong, nil
+}
+
+// moveSong checks the disc and track number a patch gives a song are free.
+func (db *MemoryDB) moveSong(row *songRow, song model.PatchSong) error {
+	if song.DiscNumber == nil && song.TrackNumber == nil {
+		return nil
+	}
+
+	disc, track := row.discNumber, row.trackNumber
+	if song.DiscNumber != nil {
+		disc = *song.DiscNumber
+	}
+	if song.TrackNumber != nil {
+		track = *song.TrackNumber
+	}
+
+	return db.tracklists().move(row.id, disc, track)
+}
+
+// patchSong applies the fields of a patch that are set, if there are any, as
+// one change.
+func (db *MemoryDB) patchSong(ctx context.Context, row *songRow, song model.PatchSong) {
+	if song.ArtistID == nil && song.Title == nil && song.DiscNumber == nil && song.DiscTitle == nil && song.TrackNumber == nil && song.DurationSeconds == nil && song.Artists == nil {
+		return
+	}
+
+	db.track(ctx, "song", row, func() {
+		if song.ArtistID != nil {
+			row.artistID = *song.ArtistID
+		}
+		if song.Title != nil {
+			row.title = *song.Title
+		}
+		if song.DiscNumber != nil {
+			row.discNumber = *song.DiscNumber
+		}
+		if song.DiscTitle != nil {
+			row.discTitle = *song.DiscTitle
+		}
+		if song.TrackNumber != nil {
+			row.trackNumber = *song.TrackNumber
+		}
+		if song.DurationSeconds != nil {
+			row.durationSeconds = *song.DurationSeconds
+		}
+		if song.Artists != nil {
+			row.credits = creditRows(*song.Artists)
+		}
+		row.version++
+	})
+}
+
+func (r *MemorySongRepository) ChangeSong(ctx context.Context, id string, ifMatch []int, change func(model.Song) (model.PatchSong, error)) (*model.SongResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, err := r.db.liveSong(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesVersion(ifMatch, row.version) {
+		return nil, ErrVersionMismatch
+	}
+
+	song, err := change(r.db.songModel(row))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVarchar(deref(song.Title)); err != nil {
+		return nil, err
+	}
+
+	if song.ArtistID != nil {
+		if err := r.db.checkSongArtist(false, *song.ArtistID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.db.moveSong(row, song); err != nil {
+		return nil, err
+	}
+
+	if song.Artists != nil {
+		if err := r.db.checkCredits(*song.Artists); err != nil {
+			return nil, err
+		}
+	}
+
+	r.db.patchSong(ctx, row, song)
+
+	changedSong := r.db.songResponse(row)
+	return &changedSong, nil
+}
+
+func (r *MemorySongRepository) DeleteSong(ctx context.Context, id string, ifMatch []int) error {
+	songID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	song, ok := r.db.songs[songID]
+	if !ok || song.archived {
+		return pgx.ErrNoRows
+	}
+
+	if !matchesVersion(ifMatch, song.version) {
+		return ErrVersionMismatch
+	}
+
+	r.db.track(ctx, "song", song, func() {
+		song.archived = true
+		song.version++
+		song.archivedAt = time.Now()
+	})
+	r.db.closeTrackGap(ctx, song.albumID, song.discNumber, song.trackNumber)
+
+	return nil
+}
+
+func (r *MemorySongRepository) RestoreSong(ctx context.Context, id string) (*model.SongResponse, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	song, err := r.db.archivedSong(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if album, ok := r.db.albums[song.albumID]; ok && album.archived {
+		return nil, ErrParentArchived
+	}
+	r.db.track(ctx, "song", song, func() {
+		song.archived = false
+		song.version++
+		song.archivedAt = time.Time{}
+	})
+	r.db.placeRestoredSongs(ctx, []*songRow{song})
+
+	restoredSong := r.db.songResponse(song)
+	return &restoredSong, nil
+}
+
+func (r *MemorySongRepository) BulkSongs(ctx context.Context, items []model.BulkItem[model.CreateSong, model.UpdateSong], atomic bool) ([]model.BulkResult, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Items are checked in order, each against the track numbers the ones
+	// before it will leave, and new songs without one are numbered.
+	tracks := r.db.tracklists()
+
+	check := func(i int) error {
+		item := &items[i]
+		if item.ID == 0 {
+			album, ok := r.db.albums[item.Create.AlbumID]
+			if !ok || album.archived {
+				return ErrParentNotFound
+			}
+
+			if err := r.db.checkSongArtist(album.compilation, item.Create.ArtistID); err != nil {
+				return err
+			}
+
+			if err := checkVarchar(item.Create.Title); err != nil {
+				return err
+			}
+
+			if err := r.db.checkCredits(item.Create.Artists); err != nil {
+				return err
+			}
+
+			track, err := tracks.add(item.Create.AlbumID, model.DiscOrFirst(item.Create.DiscNumber), item.Create.TrackNumber)
+			item.Create.TrackNumber = track
+			return err
+		}
+
+		row, ok := r.db.songs[item.ID]
+		if !ok || row.archived {
+			return pgx.ErrNoRows
+		}
+
+		if err := checkVarchar(item.Update.Title); err != nil {
+			return err
+		}
+
+		if err := r.db.checkSongArtist(r.db.albums[row.albumID].compilation, item.Update.ArtistID); err != nil {
+			return err
+		}
+
+		if err := r.db.checkCredits(item.Update.Artists); err != nil {
+			return err
+		}
+
+		return tracks.move(item.ID, model.DiscOrFirst(item.Update.DiscNumber), item.Update.TrackNumber)
+	}
+
+	write := func(i int) model.BulkResult {
+		item := items[i]
+		if item.ID == 0 {
+			return model.BulkResult{ID: r.db.insertSong(ctx, item.Create).id, Created: true}
+		}
+
+		r.db.updateSong(ctx, r.db.songs[item.ID], item.Update)
+		return model.BulkResult{ID: item.ID}
+	}
+
+	return r.db.bulkWrite(len(items), atomic, check, write)
+}
+
+// tracklists holds the track numbers of every live song.
+func (db *MemoryDB) tracklists() *tracklists {
+	t := newTracklists()
+	for _, song := range db.songs {
+		if !song.archived {
+			t.put(song.id, discID{song.albumID, song.discNumber}, song.trackNumber)
+		}
+	}
+
+	return t
+}
+
+// closeTrackGap moves the live songs after track on a disc up by one, into
+// the place of a song that has left it.
+func (db *MemoryDB) closeTrackGap(ctx context.Context, albumID, disc, track int) {
+	for _, id := range slices.Sorted(maps.Keys(db.songs)) {
+		song := db.songs[id]
+		if song.albumID != albumID || song.discNumber != disc || song.archived || song.trackNumber <= track {
+			continue
+		}
+
+		db.track(ctx, "song", song, func() {
+			song.trackNumber--
+			song.version++
+		})
+	}
+}
+
+// placeRestoredSongs settles the track numbers of songs just restored. A
+// restored song keeps its number when no other live song on its disc has
+// it; otherwise it moves past the last track of the disc, in the order the
+// clashing songs had.
+func (db *MemoryDB) placeRestoredSongs(ctx context.Context, restored []*songRow) {
+	type place struct {
+		disc  discID
+		track int
+	}
+
+	isRestored := map[int]bool{}
+	for _, song := range restored {
+		isRestored[song.id] = true
+	}
+
+	taken := map[place]bool{}
+	last := map[discID]int{}
+	for _, song := range db.songs {
+		if song.archived {
+			continue
+		}
+
+		disc := discID{song.albumID, song.discNumber}
+		last[disc] = max(last[disc], song.trackNumber)
+		if !isRestored[song.id] {
+			taken[place{disc, song.trackNumber}] = true
+		}
+	}
+
+	// Of restored songs sharing a number, the oldest keeps it.
+	restored = slices.SortedFunc(slices.Values(restored), func(a, b *songRow) int {
+		return cmp.Compare(a.id, b.id)
+	})
+
+	clashes := []*songRow{}
+	for _, song := range restored {
+		key := place{discID{song.albumID, song.discNumber}, song.trackNumber}
+		if taken[key] {
+			clashes = append(clashes, song)
+			continue
+		}
+		taken[key] = true
+	}
+
+	slices.SortFunc(clashes, func(a, b *songRow) int {
+		return cmp.Or(cmp.Compare(a.trackNumber, b.trackNumber), cmp.Compare(a.id, b.id))
+	})
+
+	for _, song := range clashes {
+		disc := discID{song.albumID, song.discNumber}
+		last[disc]++
+		track := last[disc]
+
+		db.track(ctx, "song", song, func() {
+			song.trackNumber = track
+			song.version++
+		})
+	}
+}
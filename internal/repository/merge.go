@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// lockForMerge locks the live source and target rows of table in id order,
+// so two merges between the same pair can't deadlock, and returns the
+// source's id.
+func lockForMerge(ctx context.Context, tx pgx.Tx, table, id string, targetID int) (int, error) {
+	query := `SELECT id, id = $1 FROM ` + table + `
+				WHERE (id = $1 OR id = $2) AND archived = FALSE
+				ORDER BY id
+				FOR UPDATE`
+
+	rows, err := tx.Query(ctx, query, id, targetID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	sourceID := 0
+	targetFound := false
+
+	for rows.Next() {
+		var rowID int
+		var isSource bool
+		if err := rows.Scan(&rowID, &isSource); err != nil {
+			return 0, err
+		}
+
+		if isSource {
+			sourceID = rowID
+		}
+		if rowID == targetID {
+			targetFound = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if sourceID == 0 {
+		return 0, pgx.ErrNoRows
+	}
+	if sourceID == targetID {
+		return 0, ErrMergeIntoSelf
+	}
+	if !targetFound {
+		return 0, ErrMergeTargetNotFound
+	}
+
+	return sourceID, nil
+}
+
+// recordRedirect points the merged source, and anything already redirecting
+// to it, at the target. A redirect away from the target itself is stale now
+// that it is live, so it is dropped.
+func recordRedirect(ctx context.Context, tx pgx.Tx, table string, sourceID, targetID int) error {
+	query := `DELETE FROM ` + table + `_redirect WHERE ` + table + `_id = $1`
+
+	_, err := tx.Exec(ctx, query, targetID)
+	if err != nil {
+		return err
+	}
+
+	query2 := `UPDATE ` + table + `_redirect SET target_id = $2 WHERE target_id = $1`
+
+	_, err = tx.Exec(ctx, query2, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	query3 := `INSERT INTO ` + table + `_redirect (` + table + `_id, target_id) VALUES ($1, $2)
+				ON CONFLICT (` + table + `_id) DO UPDATE SET target_id = EXCLUDED.target_id, created_at = now()`
+
+	_, err = tx.Exec(ctx, query3, sourceID, targetID)
+	return err
+}
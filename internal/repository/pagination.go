@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/liamcoleman/music-go/internal/model"
+)
+
+// orderColumn is one column of a listing's ORDER BY. The last column of every
+// ordering is the row id so that keyset cursors are unambiguous.
+type orderColumn struct {
+	field string
+	expr  string
+	desc  bool
+}
+
+type cursor struct {
+	Sort     string `json:"s"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// listOrder returns the requested sort followed by the id tie-breaker, or the
+// listing's default order when no sort was requested.
+func listOrder(sort []model.SortField, columns map[string]string, defaults []orderColumn) ([]orderColumn, error) {
+	if len(sort) == 0 {
+		return defaults, nil
+	}
+
+	order := []orderColumn{}
+
+	for _, s := range sort {
+		expr, ok := columns[s.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", s.Field)
+		}
+
+		order = append(order, orderColumn{field: s.Field, expr: expr, desc: s.Desc})
+		if s.Field == "id" {
+			return order, nil
+		}
+	}
+
+	return append(order, defaults[len(defaults)-1]), nil
+}
+
+func orderSignature(columns []orderColumn) string {
+	var fields []string
+
+	for _, column := range columns {
+		if column.desc {
+			fields = append(fields, "-"+column.field)
+		} else {
+			fields = append(fields, column.field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// orderKey returns the cursor values for a row, given a function that reads
+// a sortable field from it.
+func orderKey[T any](columns []orderColumn, value func(T, string) any) func(T) []any {
+	return func(item T) []any {
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = value(item, column.field)
+		}
+		return values
+	}
+}
+
+func encodeCursor(columns []orderColumn, values []any, backward bool) string {
+	encoded, _ := json.Marshal(cursor{Sort: orderSignature(columns), Values: values, Backward: backward})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(value string, columns []orderColumn) (*cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil || len(c.Values) != len(columns) || c.Sort != orderSignature(columns) {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, v := range c.Values {
+		switch v := v.(type) {
+		case json.Number:
+			n, err := v.Int64()
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			c.Values[i] = n
+		case string:
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &c, nil
+}
+
+func addArg(args *[]any, value any) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+// keysetCondition builds the WHERE clause selecting rows after the cursor in
+// the listing's order, or before it when paging backwards.
+func keysetCondition(columns []orderColumn, c *cursor, args *[]any) string {
+	var clauses []string
+
+	for i, column := range columns {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j].expr+" = "+addArg(args, c.Values[j]))
+		}
+
+		op := ">"
+		if column.desc != c.Backward {
+			op = "<"
+		}
+		parts = append(parts, column.expr+" "+op+" "+addArg(args, c.Values[i]))
+
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(clauses, " OR ") + ")"
+}
+
+func orderByClause(columns []orderColumn, backward bool) string {
+	var parts []string
+
+	for _, column := range columns {
+		desc := column.desc != backward
+		if desc {
+			parts = append(parts, column.expr+" DESC")
+		} else {
+			parts = append(parts, column.expr)
+		}
+	}
+
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// listing holds the resolved order and cursor for one page of a collection.
+type listing struct {
+	params  model.ListParams
+	columns map[string]string
+	order   []orderColumn
+	cursor  *cursor
+}
+
+// newListing resolves the requested sort against a collection's columns,
+// which map API field names to SQL expressions, and decodes the cursor.
+func newListing(params model.ListParams, columns map[string]string, defaultOrder []orderColumn) (*listing, error) {
+	order, err := listOrder(params.Sort, columns, defaultOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := decodeCursor(params.Cursor, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listing{
+		params:  params,
+		columns: columns,
+		order:   order,
+		cursor:  c,
+	}, nil
+}
+
+func (l *listing) backward() bool {
+	return l.cursor != nil && l.cursor.Backward
+}
+
+// query appends the filter, keyset, ORDER BY and LIMIT clauses to base, which
+// must already end in a WHERE condition.
+func (l *listing) query(base string, args *[]any) (string, error) {
+	conditions, err := filterConditions(l.params.Filters, l.columns, args)
+	if err != nil {
+		return "", err
+	}
+
+	query := base
+	for _, condition := range conditions {
+		query += " AND " + condition
+	}
+
+	if l.cursor != nil {
+		query += " AND " + keysetCondition(l.order, l.cursor, args)
+	}
+
+	query += " " + orderByClause(l.order, l.backward()) + " LIMIT " + addArg(args, l.params.Limit+1)
+
+	return query, nil
+}
+
+// page trims the limit+1 rows fetched for a page, restores their natural
+// order when paging backwards and works out the neighbouring cursors.
+func page[T any](l *listing, items []T, value func(T, string) any) *model.Page[T] {
+	key := orderKey(l.order, value)
+	backward := l.backward()
+	hasMore := len(items) > l.params.Limit
+
+	if hasMore {
+		items = items[:l.params.Limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	result := &model.Page[T]{Data: items}
+	if len(items) == 0 {
+		return result
+	}
+
+	first, last := items[0], items[len(items)-1]
+
+	if backward {
+		result.NextCursor = encodeCursor(l.order, key(last), false)
+		if hasMore {
+			result.PrevCursor = encodeCursor(l.order, key(first), true)
+		}
+	} else {
+		if hasMore {
+			result.NextCursor = encodeCursor(l.order, key(last), false)
+		}
+		if l.cursor != nil {
+			result.PrevCursor = encodeCursor(l.order, key(first), true)
+		}
+	}
+
+	return result
+}
+
+// compareKeys orders two sort keys the same way the SQL ORDER BY would for
+// the given columns. It backs the in-memory listings.
+func compareKeys(columns []orderColumn, a, b []any) int {
+	for i, column := range columns {
+		cmp := compareValues(a[i], b[i])
+		if column.desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	case int:
+		return compareInts(int64(a), toInt64(b))
+	case int64:
+		return compareInts(a, toInt64(b))
+	case bool:
+		b, _ := b.(bool)
+		if a == b {
+			return 0
+		}
+		if b {
+			return -1
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func toInt64(v any) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	}
+
+	return 0
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// paginate is the in-memory counterpart of listing.query: it sorts the
+// already filtered items, skips past the cursor and returns the page.
+func paginate[T any](l *listing, items []T, value func(T, string) any) *model.Page[T] {
+	key := orderKey(l.order, value)
+	backward := l.backward()
+
+	slices.SortFunc(items, func(a, b T) int {
+		cmp := compareKeys(l.order, key(a), key(b))
+		if backward {
+			cmp = -cmp
+		}
+		return cmp
+	})
+
+	selected := []T{}
+	for _, item := range items {
+		if l.cursor != nil {
+			cmp := compareKeys(l.order, key(item), l.cursor.Values)
+			if (!backward && cmp <= 0) || (backward && cmp >= 0) {
+				continue
+			}
+		}
+
+		selected = append(selected, item)
+		if len(selected) > l.params.Limit {
+			break
+		}
+	}
+
+	return page(l, selected, value)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockParent checks the artist or album a new row will belong to is live and
+// locks it, so it can't be archived before the insert commits.
+func lockParent(ctx context.Context, tx pgx.Tx, table string, id int) error {
+	var archived bool
+	query := `SELECT archived FROM ` + table + ` WHERE id = $1 ` + parentLock(table)
+
+	err := tx.QueryRow(ctx, query, id).Scan(&archived)
+	if errors.Is(err, pgx.ErrNoRows) || archived {
+		return ErrParentNotFound
+	}
+
+	return err
+}
+
+// parentLock is the lock taken on the parent of new rows. It is a share lock,
+// except that new songs lock their album against each other too, since each
+// may be numbered after the album's last track.
+func parentLock(table string) string {
+	if table == "album" {
+		return "FOR NO KEY UPDATE"
+	}
+
+	return "FOR SHARE"
+}
+
+// liveParent returns the id of the live artist or album whose children are
+// being listed, or pgx.ErrNoRows when there is none.
+func liveParent(ctx context.Context, dbPool *pgxpool.Pool, table string, id string) (int, error) {
+	var parentID int
+	query := `SELECT id FROM ` + table + ` WHERE id = $1 AND archived = FALSE`
+
+	err := dbPool.QueryRow(ctx, query, id).Scan(&parentID)
+	return parentID, err
+}
+
+// compilationParams narrows a listing of albums to compilations, on top of
+// whatever filters were requested.
+func compilationParams(params model.ListParams) model.ListParams {
+	params.Filters = append(slices.Clip(params.Filters), model.Filter{Field: "compilation", Op: model.OpEq, Value: true})
+	return params
+}
+
+// nullID is an optional reference as it is stored: NULL for none.
+func nullID(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// childParams narrows a listing to the children of one parent, on top of
+// whatever filters were requested.
+func childParams(params model.ListParams, field string, parentID int) model.ListParams {
+	params.Filters = append(slices.Clip(params.Filters), model.Filter{Field: field, Op: model.OpEq, Value: int64(parentID)})
+	return params
+}
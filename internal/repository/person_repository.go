@@ -0,0 +1,494 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The unique indexes that keep a person from holding a role on an album or
+// song twice.
+const (
+	personCreditAlbumKey = "person_credit_album_key"
+	personCreditSongKey  = "person_credit_song_key"
+)
+
+type PersonRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewPersonRepository(dbPool *pgxpool.Pool) *PersonRepository {
+	return &PersonRepository{
+		dbPool: dbPool,
+	}
+}
+
+var personColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"description": "description",
+}
+
+var personOrder = []orderColumn{
+	{field: "name", expr: personColumns["name"]},
+	{field: "id", expr: personColumns["id"]},
+}
+
+func personValue(person model.Person, field string) any {
+	switch field {
+	case "id":
+		return person.ID
+	case "name":
+		return person.Name
+	}
+
+	return nil
+}
+
+// personRoleNames lists the roles in order for array_position, which ranks
+// credits the way model.PersonRoles does.
+func personRoleNames() []string {
+	names := make([]string, len(model.PersonRoles))
+	for i, role := range model.PersonRoles {
+		names[i] = string(role)
+	}
+
+	return names
+}
+
+func (r *PersonRepository) GetPeople(ctx context.Context, params model.ListParams) (*model.Page[model.Person], error) {
+	l, err := newListing(params, personColumns, personOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{}
+	query, err := l.query(`SELECT id, name, description FROM person WHERE archived = `+addArg(&args, params.Archived), &args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	people := []model.Person{}
+
+	for rows.Next() {
+		var person model.Person
+		if err := rows.Scan(&person.ID, &person.Name, &person.Description); err != nil {
+			return nil, err
+		}
+
+		people = append(people, person)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page(l, people, personValue), nil
+}
+
+func (r *PersonRepository) GetPerson(ctx context.Context, id string) (*model.Person, error) {
+	var person model.Person
+	query := `SELECT id, name, description, version FROM person WHERE id = $1 AND archived = FALSE`
+
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&person.ID, &person.Name, &person.Description, &person.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
+
+// GetPersonCredits lists the live albums that credit a person, on the album
+// or on its live songs, latest release first.
+func (r *PersonRepository) GetPersonCredits(ctx context.Context, id string) ([]model.PersonAlbum, error) {
+	personID, err := liveParent(ctx, r.dbPool, "person", id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT album.id, album.name, album.release_year, album.release_date, album.release_type, album.compilation, COALESCE(owner.name, '` + model.VariousArtists + `'), song.id, song.title, credit.role
+				FROM person_credit credit
+				LEFT JOIN song ON song.id = credit.song_id
+				JOIN album ON album.id = COALESCE(credit.album_id, song.album_id)
+				LEFT JOIN artist owner ON owner.id = album.artist_id
+				WHERE credit.person_id = $1 AND album.archived = FALSE AND (song.id IS NULL OR song.archived = FALSE)
+				ORDER BY album.release_date DESC, album.id, song.disc_number NULLS FIRST, song.track_number, song.id, array_position($2::text[], credit.role::text)`
+	rows, err := r.dbPool.Query(ctx, query, personID, personRoleNames())
+	if err != nil {
+		return nil, err
+	}
+
+	albums := []model.PersonAlbum{}
+
+	var album model.Album
+	var songID *int
+	var songTitle *string
+	var role model.PersonRole
+	_, err = pgx.ForEachRow(rows, []any{&album.ID, &album.Name, &album.ReleaseYear, &album.ReleaseDate, &album.ReleaseType, &album.Compilation, &album.ArtistName, &songID, &songTitle, &role}, func() error {
+		if n := len(albums); n == 0 || albums[n-1].ID != album.ID {
+			albums = append(albums, model.PersonAlbum{Album: album, Roles: []model.PersonRole{}})
+		}
+		credited := &albums[len(albums)-1]
+
+		if songID == nil {
+			credited.Roles = append(credited.Roles, role)
+			return nil
+		}
+
+		if n := len(credited.Songs); n == 0 || credited.Songs[n-1].ID != *songID {
+			credited.Songs = append(credited.Songs, model.PersonSong{ID: *songID, Title: *songTitle})
+		}
+		song := &credited.Songs[len(credited.Songs)-1]
+		song.Roles = append(song.Roles, role)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make(map[int]*model.Credits, len(albums))
+	for i := range albums {
+		credits[albums[i].ID] = &albums[i].Credits
+	}
+
+	if err := loadAlbumCredits(ctx, r.dbPool, credits); err != nil {
+		return nil, err
+	}
+
+	return albums, nil
+}
+
+func (r *PersonRepository) CreatePerson(ctx context.Context, person model.CreatePerson) (*model.Person, error) {
+
+	var createdPerson model.Person
+	query := `INSERT INTO person (name, description) VALUES ($1, $2) RETURNING id, name, description, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, person.Name, person.Description).Scan(&createdPerson.ID, &createdPerson.Name, &createdPerson.Description, &createdPerson.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdPerson, nil
+}
+
+func (r *PersonRepository) UpdatePerson(ctx context.Context, person model.UpdatePerson, id string, ifMatch []int) (*model.Person, error) {
+	var updatedPerson model.Person
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "person", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `UPDATE person SET name = $2, description = $3 WHERE id = $1 RETURNING id, name, description, version`
+
+		return tx.QueryRow(ctx, query, id, person.Name, person.Description).Scan(&updatedPerson.ID, &updatedPerson.Name, &updatedPerson.Description, &updatedPerson.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedPerson, nil
+}
+
+func (r *PersonRepository) PatchPerson(ctx context.Context, person model.PatchPerson, id string, ifMatch []int) (*model.Person, error) {
+
+	var patchedPerson model.Person
+
+	args := []any{id}
+	sets := personSets(person, &args)
+
+	query := patchQuery("person", sets, "id = $1 AND archived = FALSE"+versionCondition(&args, ifMatch), "id, name, description, version")
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, args...).Scan(&patchedPerson.ID, &patchedPerson.Name, &patchedPerson.Description, &patchedPerson.Version)
+		if errors.Is(err, pgx.ErrNoRows) && ifMatch != nil {
+			return missingOrModified(ctx, tx, "person", id)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchedPerson, nil
+}
+
+// personSets lists the column assignments for the fields of a patch that are
+// set.
+func personSets(person model.PatchPerson, args *[]any) []string {
+	sets := []string{}
+
+	if person.Name != nil {
+		sets = append(sets, "name = "+addArg(args, *person.Name))
+	}
+
+	if person.Description != nil {
+		sets = append(sets, "description = "+addArg(args, *person.Description))
+	}
+
+	return sets
+}
+
+// ChangePerson locks the person, works out a patch from their current state
+// with change and applies it, all in one transaction.
+func (r *PersonRepository) ChangePerson(ctx context.Context, id string, ifMatch []int, change func(model.Person) (model.PatchPerson, error)) (*model.Person, error) {
+
+	var changedPerson model.Person
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var person model.Person
+		query := `SELECT id, name, description, version FROM person WHERE id = $1 AND archived = FALSE FOR UPDATE`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&person.ID, &person.Name, &person.Description, &person.Version)
+		if err != nil {
+			return err
+		}
+
+		if !matchesVersion(ifMatch, person.Version) {
+			return ErrVersionMismatch
+		}
+
+		patch, err := change(person)
+		if err != nil {
+			return err
+		}
+
+		args := []any{person.ID}
+		query2 := patchQuery("person", personSets(patch, &args), "id = $1", "id, name, description, version")
+
+		return tx.QueryRow(ctx, query2, args...).Scan(&changedPerson.ID, &changedPerson.Name, &changedPerson.Description, &changedPerson.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &changedPerson, nil
+}
+
+// DeletePerson archives a person. Their credits stay, but aren't listed
+// until the person is restored.
+func (r *PersonRepository) DeletePerson(ctx context.Context, id string, ifMatch []int) error {
+	return writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "person", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `UPDATE person SET archived = TRUE, archived_at = now() WHERE id = $1`
+
+		_, err := tx.Exec(ctx, query, id)
+		return err
+	})
+}
+
+func (r *PersonRepository) RestorePerson(ctx context.Context, id string) (*model.Person, error) {
+
+	var restoredPerson model.Person
+
+	query := `UPDATE person SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE RETURNING id, name, description, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, query, id).Scan(&restoredPerson.ID, &restoredPerson.Name, &restoredPerson.Description, &restoredPerson.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &restoredPerson, nil
+}
+
+func (r *PersonRepository) GetPersonCredit(ctx context.Context, id string) (*model.PersonCredit, error) {
+	var credit model.PersonCredit
+	query := `SELECT id, person_id, COALESCE(album_id, 0), COALESCE(song_id, 0), role FROM person_credit WHERE id = $1`
+
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&credit.ID, &credit.PersonID, &credit.AlbumID, &credit.SongID, &credit.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credit, nil
+}
+
+// CreatePersonCredit credits a live person on a live album or song.
+func (r *PersonRepository) CreatePersonCredit(ctx context.Context, credit model.CreatePersonCredit) (*model.PersonCredit, error) {
+
+	var createdCredit model.PersonCredit
+
+	query := `INSERT INTO person_credit (person_id, album_id, song_id, role) VALUES ($1, $2, $3, $4)
+				RETURNING id, person_id, COALESCE(album_id, 0), COALESCE(song_id, 0), role`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockPerson(ctx, tx, credit.PersonID); err != nil {
+			return err
+		}
+
+		table, targetID := creditTarget(credit.AlbumID, credit.SongID)
+		if err := lockParent(ctx, tx, table, targetID); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, query, credit.PersonID, nullID(credit.AlbumID), nullID(credit.SongID), credit.Role).Scan(&createdCredit.ID, &createdCredit.PersonID, &createdCredit.AlbumID, &createdCredit.SongID, &createdCredit.Role)
+		if err != nil {
+			return err
+		}
+
+		return touch(ctx, tx, table, targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdCredit, nil
+}
+
+// UpdatePersonCredit gives a credit a new person and role. The person must
+// be live.
+func (r *PersonRepository) UpdatePersonCredit(ctx context.Context, credit model.UpdatePersonCredit, id string) (*model.PersonCredit, error) {
+
+	var updatedCredit model.PersonCredit
+
+	query := `UPDATE person_credit SET person_id = $2, role = $3 WHERE id = $1
+				RETURNING id, person_id, COALESCE(album_id, 0), COALESCE(song_id, 0), role`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockPerson(ctx, tx, credit.PersonID); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, query, id, credit.PersonID, credit.Role).Scan(&updatedCredit.ID, &updatedCredit.PersonID, &updatedCredit.AlbumID, &updatedCredit.SongID, &updatedCredit.Role)
+		if err != nil {
+			return err
+		}
+
+		table, targetID := creditTarget(updatedCredit.AlbumID, updatedCredit.SongID)
+		return touch(ctx, tx, table, targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedCredit, nil
+}
+
+func (r *PersonRepository) DeletePersonCredit(ctx context.Context, id string) error {
+	query := `DELETE FROM person_credit WHERE id = $1 RETURNING COALESCE(album_id, 0), COALESCE(song_id, 0)`
+
+	return writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var albumID, songID int
+		if err := tx.QueryRow(ctx, query, id).Scan(&albumID, &songID); err != nil {
+			return err
+		}
+
+		table, targetID := creditTarget(albumID, songID)
+		return touch(ctx, tx, table, targetID)
+	})
+}
+
+// lockPerson checks the person a credit names is live and locks them, so
+// they can't be archived before the credit commits.
+func lockPerson(ctx context.Context, tx pgx.Tx, id int) error {
+	var archived bool
+	query := `SELECT archived FROM person WHERE id = $1 FOR SHARE`
+
+	err := tx.QueryRow(ctx, query, id).Scan(&archived)
+	if errors.Is(err, pgx.ErrNoRows) || archived {
+		return ErrPersonNotFound
+	}
+
+	return err
+}
+
+// creditTarget returns the table and id of the album or song a credit is
+// on.
+func creditTarget(albumID, songID int) (string, int) {
+	if albumID != 0 {
+		return "album", albumID
+	}
+
+	return "song", songID
+}
+
+// touch moves a row to a new version without changing it, so ETags handed
+// out before its personnel changed stop matching. Nothing is recorded in the
+// history, since no column changes.
+func touch(ctx context.Context, tx pgx.Tx, table string, id int) error {
+	_, err := tx.Exec(ctx, `UPDATE `+table+` SET version = version WHERE id = $1`, id)
+	return err
+}
+
+// mergePersonCredits moves the people credited on an album being merged to
+// the target, dropping the credits the target already gives the same person
+// in the same role.
+func mergePersonCredits(ctx context.Context, tx pgx.Tx, sourceID, targetID int) error {
+	query := `DELETE FROM person_credit credit
+				WHERE credit.album_id = $1
+				AND EXISTS (SELECT 1 FROM person_credit other WHERE other.album_id = $2 AND other.person_id = credit.person_id AND other.role = credit.role)`
+
+	_, err := tx.Exec(ctx, query, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	query2 := `UPDATE person_credit SET album_id = $2 WHERE album_id = $1`
+
+	tag, err := tx.Exec(ctx, query2, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	return touch(ctx, tx, "album", targetID)
+}
+
+// loadPersonnel fills in the personnel of albums or songs, given by id, from
+// the credits whose owner column names them. Archived people aren't listed.
+func loadPersonnel(ctx context.Context, q queryer, owner string, personnel map[int]*[]model.Personnel) error {
+	if len(personnel) == 0 {
+		return nil
+	}
+
+	query := `SELECT credit.` + owner + `, credit.id, credit.person_id, person.name, credit.role
+				FROM person_credit credit
+				JOIN person ON person.id = credit.person_id
+				WHERE credit.` + owner + ` = ANY($1) AND person.archived = FALSE
+				ORDER BY credit.` + owner + `, array_position($2::text[], credit.role::text), person.name, credit.id`
+
+	rows, err := q.Query(ctx, query, slices.Collect(maps.Keys(personnel)), personRoleNames())
+	if err != nil {
+		return err
+	}
+
+	var id int
+	var credit model.Personnel
+	_, err = pgx.ForEachRow(rows, []any{&id, &credit.CreditID, &credit.PersonID, &credit.Name, &credit.Role}, func() error {
+		*personnel[id] = append(*personnel[id], credit)
+		return nil
+	})
+
+	return err
+}
+
+// songPersonnelByID returns the personnel of songs for loadPersonnel to fill
+// in.
+func songPersonnelByID(songs []model.Song) map[int]*[]model.Personnel {
+	personnel := make(map[int]*[]model.Personnel, len(songs))
+	for i := range songs {
+		personnel[songs[i].ID] = &songs[i].Personnel
+	}
+
+	return personnel
+}
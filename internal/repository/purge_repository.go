@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PurgeRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewPurgeRepository(dbPool *pgxpool.Pool) *PurgeRepository {
+	return &PurgeRepository{
+		dbPool: dbPool,
+	}
+}
+
+// Purge permanently deletes artists, albums, songs and people archived
+// before the given time. Albums and songs belonging to a purged artist or
+// album are reported too, since the foreign keys cascade the delete to them.
+// The purged artists' own songs on other albums only go if they are archived
+// themselves; the rest fall back to their album's artist.
+// With dryRun set nothing is deleted.
+func (r *PurgeRepository) Purge(ctx context.Context, archivedBefore time.Time, dryRun bool) (*model.PurgeResult, error) {
+
+	result := model.PurgeResult{
+		ArchivedBefore: archivedBefore,
+		DryRun:         dryRun,
+	}
+
+	err := pgx.BeginTxFunc(ctx, r.dbPool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead}, func(tx pgx.Tx) error {
+		err := setActor(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		query := `SELECT id FROM artist WHERE archived = TRUE AND archived_at < $1 ORDER BY id`
+		result.ArtistIDs, err = collectIDs(ctx, tx, query, archivedBefore)
+		if err != nil {
+			return err
+		}
+
+		query2 := `SELECT album.id
+					FROM album
+					LEFT JOIN artist ON album.artist_id = artist.id
+					WHERE (album.archived = TRUE AND album.archived_at < $1)
+						OR (artist.archived = TRUE AND artist.archived_at < $1)
+					ORDER BY album.id`
+		result.AlbumIDs, err = collectIDs(ctx, tx, query2, archivedBefore)
+		if err != nil {
+			return err
+		}
+
+		query3 := `SELECT song.id
+					FROM song
+					JOIN album ON song.album_id = album.id
+					LEFT JOIN artist ON album.artist_id = artist.id
+					WHERE (song.archived = TRUE AND song.archived_at < $1)
+						OR (album.archived = TRUE AND album.archived_at < $1)
+						OR (artist.archived = TRUE AND artist.archived_at < $1)
+					ORDER BY song.id`
+		result.SongIDs, err = collectIDs(ctx, tx, query3, archivedBefore)
+		if err != nil {
+			return err
+		}
+
+		query4 := `SELECT id FROM person WHERE archived = TRUE AND archived_at < $1 ORDER BY id`
+		result.PersonIDs, err = collectIDs(ctx, tx, query4, archivedBefore)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM artist WHERE id = ANY($1)`, result.ArtistIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM album WHERE id = ANY($1)`, result.AlbumIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM song WHERE id = ANY($1)`, result.SongIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM person WHERE id = ANY($1)`, result.PersonIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func collectIDs(ctx context.Context, tx pgx.Tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/liamcoleman/music-go/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var SearchTypes = []string{"artist", "album", "song"}
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=10"
+
+var searchQueries = map[string]string{
+	"artist": `SELECT 'artist' AS type, artist.id, COALESCE(artist.name, '') AS name, '' AS artist, '' AS album,
+					ts_rank(artist.search_vector, q.query) AS rank,
+					ts_headline('simple', concat_ws(' - ', artist.name, artist.description), q.query, $3) AS snippet
+				FROM artist
+				CROSS JOIN q
+				WHERE artist.search_vector @@ q.query AND artist.archived = $2`,
+	"album": `SELECT 'album', album.id, COALESCE(album.name, ''), ` + artistName + `, '',
+					ts_rank(album.search_vector, q.query),
+					ts_headline('simple', COALESCE(album.name, ''), q.query, $3)
+				FROM album
+				LEFT JOIN artist ON album.artist_id = artist.id
+				CROSS JOIN q
+				WHERE album.search_vector @@ q.query AND album.archived = $2`,
+	"song": `SELECT 'song', song.id, song.title, ` + artistName + `, COALESCE(album.name, ''),
+					ts_rank(song.search_vector, q.query),
+					ts_headline('simple', song.title, q.query, $3)
+				FROM song
+				JOIN album ON song.album_id = album.id
+				LEFT JOIN artist ON artist.id = COALESCE(song.artist_id, album.artist_id)
+				CROSS JOIN q
+				WHERE song.search_vector @@ q.query AND song.archived = $2`,
+}
+
+type SearchRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewSearchRepository(dbPool *pgxpool.Pool) *SearchRepository {
+	return &SearchRepository{
+		dbPool: dbPool,
+	}
+}
+
+// searchTerms splits a search string into lower-cased words, dropping
+// punctuation so the terms are safe to splice into a tsquery.
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixQuery builds a tsquery matching documents that contain a word
+// starting with every term, so partial names still match.
+func prefixQuery(terms []string) string {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+
+	return strings.Join(prefixes, " & ")
+}
+
+func (r *SearchRepository) Search(ctx context.Context, params model.SearchParams) ([]model.SearchResult, error) {
+
+	results := []model.SearchResult{}
+
+	terms := searchTerms(params.Query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	var parts []string
+	for _, searchType := range SearchTypes {
+		if len(params.Types) == 0 || slices.Contains(params.Types, searchType) {
+			parts = append(parts, searchQueries[searchType])
+		}
+	}
+
+	query := `WITH q AS (SELECT to_tsquery('simple', $1) AS query) ` +
+		strings.Join(parts, " UNION ALL ") +
+		` ORDER BY rank DESC, type, id LIMIT $4`
+
+	rows, err := r.dbPool.Query(ctx, query, prefixQuery(terms), params.Archived, headlineOptions, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result model.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Name, &result.ArtistName, &result.AlbumName, &result.Rank, &result.Snippet); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// findOrCreateLockID is the pg_advisory_xact_lock key held while looking for
+// a similar artist before inserting one, so concurrent find-or-create calls
+// for the same misspelt name cannot both insert.
+const findOrCreateLockID = 8_311_972_009
+
+// setSimilarityThreshold sets the threshold used by the pg_trgm % operator
+// for the rest of the transaction, letting the trigram indexes do the
+// filtering.
+func setSimilarityThreshold(ctx context.Context, tx pgx.Tx, threshold float64) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(threshold, 'f', -1, 64))
+	return err
+}
+
+// trigrams returns the set of trigrams pg_trgm extracts from s: each
+// lowercased word is padded with two spaces in front and one behind.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+
+	for _, word := range searchTerms(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+
+	return set
+}
+
+// similarity mirrors pg_trgm's similarity(): the number of shared trigrams
+// over the number of distinct trigrams in either string.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
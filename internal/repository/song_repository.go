@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/liamcoleman/music-go/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,15 +20,63 @@ func NewSongRepository(dbPool *pgxpool.Pool) *SongRepository {
 	}
 }
 
-func (r *SongRepository) GetSongs(ctx context.Context) ([]model.Song, error) {
+var songColumns = map[string]string{
+	"id":               "song.id",
+	"title":            "song.title",
+	"disc_number":      "song.disc_number",
+	"track_number":     "song.track_number",
+	"duration_seconds": "song.duration_seconds",
+	"album":            "COALESCE(album.name, '')",
+	"album_id":         "song.album_id",
+	"artist":           artistName,
+	"artist_id":        "COALESCE(song.artist_id, album.artist_id)",
+	"release_year":     "album.release_year",
+}
+
+var songOrder = []orderColumn{
+	{field: "title", expr: songColumns["title"]},
+	{field: "id", expr: songColumns["id"]},
+}
+
+func songValue(song model.Song, field string) any {
+	switch field {
+	case "id":
+		return song.ID
+	case "title":
+		return song.Title
+	case "disc_number":
+		return song.DiscNumber
+	case "track_number":
+		return song.TrackNumber
+	case "duration_seconds":
+		return song.DurationSeconds
+	case "album":
+		return song.AlbumName
+	case "artist":
+		return song.ArtistName
+	}
+
+	return nil
+}
+
+func (r *SongRepository) GetSongs(ctx context.Context, params model.ListParams) (*model.Page[model.Song], error) {
+
+	l, err := newListing(params, songColumns, songOrder)
+	if err != nil {
+		return nil, err
+	}
 
-	query := `SELECT song.id, song.title, song.track_number, song.duration_seconds, album.name as album, artist.name as artist
+	args := []any{}
+	query, err := l.query(`SELECT song.id, song.title, song.disc_number, song.disc_title, song.track_number, song.duration_seconds, album.name as album, COALESCE(song.artist_id, 0), `+artistName+` as artist, song.version
 				FROM song
 				JOIN album ON song.album_id = album.id
-				JOIN artist ON album.artist_id = artist.id
-				WHERE song.archived = FALSE
-				ORDER BY song.title`
-	rows, err := r.dbPool.Query(ctx, query)
+				LEFT JOIN artist ON artist.id = COALESCE(song.artist_id, album.artist_id)
+				WHERE song.archived = `+addArg(&args, params.Archived), &args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.dbPool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +86,7 @@ func (r *SongRepository) GetSongs(ctx context.Context) ([]model.Song, error) {
 
 	for rows.Next() {
 		var song model.Song
-		if err := rows.Scan(&song.ID, &song.Title, &song.TrackNumber, &song.DurationSeconds, &song.AlbumName, &song.ArtistName); err != nil {
+		if err := rows.Scan(&song.ID, &song.Title, &song.DiscNumber, &song.DiscTitle, &song.TrackNumber, &song.DurationSeconds, &song.AlbumName, &song.ArtistID, &song.ArtistName, &song.Version); err != nil {
 			return nil, err
 		}
 
@@ -47,23 +97,46 @@ func (r *SongRepository) GetSongs(ctx context.Context) ([]model.Song, error) {
 		return nil, err
 	}
 
-	return songs, nil
+	if err := loadSongCredits(ctx, r.dbPool, songCreditsByID(songs)); err != nil {
+		return nil, err
+	}
+
+	return page(l, songs, songValue), nil
+}
+
+// GetAlbumSongs lists the songs of a live album.
+func (r *SongRepository) GetAlbumSongs(ctx context.Context, albumID string, params model.ListParams) (*model.Page[model.Song], error) {
+
+	id, err := liveParent(ctx, r.dbPool, "album", albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetSongs(ctx, childParams(params, "album_id", id))
 }
 
 func (r *SongRepository) GetSong(ctx context.Context, id string) (*model.Song, error) {
 
-	query := `SELECT song.id, song.title, song.track_number, song.duration_seconds, album.name as album, artist.name as artist
+	query := `SELECT song.id, song.title, song.disc_number, song.disc_title, song.track_number, song.duration_seconds, album.name as album, COALESCE(song.artist_id, 0), ` + artistName + ` as artist, song.version
 				FROM song
 				JOIN album ON song.album_id = album.id
-				JOIN artist ON album.artist_id = artist.id
+				LEFT JOIN artist ON artist.id = COALESCE(song.artist_id, album.artist_id)
 				WHERE song.id = $1 AND song.archived = FALSE`
 	var song model.Song
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&song.ID, &song.Title, &song.TrackNumber, &song.DurationSeconds, &song.AlbumName, &song.ArtistName)
+	err := r.dbPool.QueryRow(ctx, query, id).Scan(&song.ID, &song.Title, &song.DiscNumber, &song.DiscTitle, &song.TrackNumber, &song.DurationSeconds, &song.AlbumName, &song.ArtistID, &song.ArtistName, &song.Version)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := loadSongCredits(ctx, r.dbPool, map[int]*model.Credits{song.ID: &song.Credits}); err != nil {
+		return nil, err
+	}
+
+	if err := loadPersonnel(ctx, r.dbPool, "song_id", map[int]*[]model.Personnel{song.ID: &song.Personnel}); err != nil {
+		return nil, err
+	}
+
 	return &song, nil
 }
 
@@ -71,8 +144,37 @@ func (r *SongRepository) CreateSong(ctx context.Context, song model.CreateSong)
 
 	var songCreated model.SongResponse
 
-	query := `INSERT INTO song (album_id, title, track_number, duration_seconds, archived) VALUES ($1, $2, $3, $4, FALSE) RETURNING id, title, track_number, duration_seconds`
-	err := r.dbPool.QueryRow(ctx, query, song.AlbumID, song.Title, song.TrackNumber, song.DurationSeconds).Scan(&songCreated.ID, &songCreated.Title, &songCreated.TrackNumber, &songCreated.DurationSeconds)
+	// A song without a track number goes after the last track of its disc.
+	// The album stays locked until commit, so concurrent creates can't both
+	// take the same number.
+	query := `INSERT INTO song (album_id, title, disc_number, disc_title, track_number, duration_seconds, artist_id, archived)
+				VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5::integer, 0), (
+					SELECT COALESCE(MAX(track_number), 0) + 1 FROM song WHERE album_id = $1 AND disc_number = $3 AND archived = FALSE
+				)), $6, $7, FALSE)
+				RETURNING id, COALESCE(artist_id, 0), title, disc_number, disc_title, track_number, duration_seconds, version`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockParent(ctx, tx, "album", song.AlbumID); err != nil {
+			return err
+		}
+
+		query2 := `SELECT compilation FROM album WHERE id = $1`
+		if err := checkSongArtist(ctx, tx, query2, song.AlbumID, song.ArtistID); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, query, song.AlbumID, song.Title, model.DiscOrFirst(song.DiscNumber), song.DiscTitle, song.TrackNumber, song.DurationSeconds, nullID(song.ArtistID)).Scan(&songCreated.ID, &songCreated.ArtistID, &songCreated.Title, &songCreated.DiscNumber, &songCreated.DiscTitle, &songCreated.TrackNumber, &songCreated.DurationSeconds, &songCreated.Version)
+		if err != nil {
+			return err
+		}
+
+		if len(song.Artists) > 0 {
+			if err := replaceCredits(ctx, tx, songCredits, map[int][]model.CreditArtist{songCreated.ID: song.Artists}); err != nil {
+				return err
+			}
+		}
+
+		return loadSongCredits(ctx, tx, map[int]*model.Credits{songCreated.ID: &songCreated.Credits})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -81,13 +183,33 @@ func (r *SongRepository) CreateSong(ctx context.Context, song model.CreateSong)
 
 }
 
-func (r *SongRepository) UpdateSong(ctx context.Context, song model.UpdateSong, id string) (*model.SongResponse, error) {
+func (r *SongRepository) UpdateSong(ctx context.Context, song model.UpdateSong, id string, ifMatch []int) (*model.SongResponse, error) {
 
 	var updateSong model.SongResponse
 
-	query := `UPDATE song SET title = $2, track_number = $3, duration_seconds = $4 WHERE id = $1 AND archived = FALSE RETURNING id, title, track_number, duration_seconds`
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "song", id, ifMatch); err != nil {
+			return err
+		}
+
+		query := `SELECT album.compilation FROM song JOIN album ON album.id = song.album_id WHERE song.id = $1`
+		if err := checkSongArtist(ctx, tx, query, id, song.ArtistID); err != nil {
+			return err
+		}
+
+		query2 := `UPDATE song SET title = $2, disc_number = $3, disc_title = $4, track_number = $5, duration_seconds = $6, artist_id = $7 WHERE id = $1 RETURNING id, COALESCE(artist_id, 0), title, disc_number, disc_title, track_number, duration_seconds, version`
+
+		err := tx.QueryRow(ctx, query2, id, song.Title, model.DiscOrFirst(song.DiscNumber), song.DiscTitle, song.TrackNumber, song.DurationSeconds, nullID(song.ArtistID)).Scan(&updateSong.ID, &updateSong.ArtistID, &updateSong.Title, &updateSong.DiscNumber, &updateSong.DiscTitle, &updateSong.TrackNumber, &updateSong.DurationSeconds, &updateSong.Version)
+		if err != nil {
+			return err
+		}
+
+		if err := replaceCredits(ctx, tx, songCredits, map[int][]model.CreditArtist{updateSong.ID: song.Artists}); err != nil {
+			return err
+		}
 
-	err := r.dbPool.QueryRow(ctx, query, id, song.Title, song.TrackNumber, song.DurationSeconds).Scan(&updateSong.ID, &updateSong.Title, &updateSong.TrackNumber, &updateSong.DurationSeconds)
+		return loadSongCredits(ctx, tx, map[int]*model.Credits{updateSong.ID: &updateSong.Credits})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -95,53 +217,380 @@ func (r *SongRepository) UpdateSong(ctx context.Context, song model.UpdateSong,
 	return &updateSong, nil
 }
 
-func (r *SongRepository) PatchSong(ctx context.Context, song model.PatchSong, id string) (*model.SongResponse, error) {
+func (r *SongRepository) PatchSong(ctx context.Context, song model.PatchSong, id string, ifMatch []int) (*model.SongResponse, error) {
 
 	var patchedSong model.SongResponse
 
-	if song.Title != nil {
-		queryName := `UPDATE song SET title = $2 WHERE id = $1 AND archived = FALSE RETURNING id, title`
-		err := r.dbPool.QueryRow(ctx, queryName, id, song.Title).Scan(&patchedSong.ID, &patchedSong.Title)
+	args := []any{id}
+	sets := songSets(song, &args)
+
+	query := patchQuery("song", sets, "id = $1 AND archived = FALSE"+versionCondition(&args, ifMatch), "id, COALESCE(artist_id, 0), title, disc_number, disc_title, track_number, duration_seconds, version")
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if song.ArtistID != nil {
+			if err := checkSongArtists(ctx, tx, false, *song.ArtistID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.QueryRow(ctx, query, args...).Scan(&patchedSong.ID, &patchedSong.ArtistID, &patchedSong.Title, &patchedSong.DiscNumber, &patchedSong.DiscTitle, &patchedSong.TrackNumber, &patchedSong.DurationSeconds, &patchedSong.Version)
+		if errors.Is(err, pgx.ErrNoRows) && ifMatch != nil {
+			return missingOrModified(ctx, tx, "song", id)
+		}
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if song.Artists != nil {
+			if err := replaceCredits(ctx, tx, songCredits, map[int][]model.CreditArtist{patchedSong.ID: *song.Artists}); err != nil {
+				return err
+			}
 		}
+
+		return loadSongCredits(ctx, tx, map[int]*model.Credits{patchedSong.ID: &patchedSong.Credits})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchedSong, nil
+}
+
+// songSets lists the column assignments for the fields of a patch that are
+// set.
+func songSets(song model.PatchSong, args *[]any) []string {
+	sets := []string{}
+
+	if song.ArtistID != nil {
+		sets = append(sets, "artist_id = "+addArg(args, *song.ArtistID))
+	}
+
+	if song.Title != nil {
+		sets = append(sets, "title = "+addArg(args, *song.Title))
+	}
+
+	if song.DiscNumber != nil {
+		sets = append(sets, "disc_number = "+addArg(args, *song.DiscNumber))
+	}
+
+	if song.DiscTitle != nil {
+		sets = append(sets, "disc_title = "+addArg(args, *song.DiscTitle))
 	}
 
 	if song.TrackNumber != nil {
-		queryReleaseYear := `UPDATE song SET track_number = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryReleaseYear, id, song.TrackNumber)
-		if err != nil {
-			return nil, err
-		}
+		sets = append(sets, "track_number = "+addArg(args, *song.TrackNumber))
 	}
 
 	if song.DurationSeconds != nil {
-		queryReleaseYear := `UPDATE song SET duration_seconds = $2 WHERE id = $1 AND archived = FALSE`
-		_, err := r.dbPool.Exec(ctx, queryReleaseYear, id, song.DurationSeconds)
+		sets = append(sets, "duration_seconds = "+addArg(args, *song.DurationSeconds))
+	}
+
+	// Credits are kept in song_artist, so the row is only touched to move it
+	// to a new version.
+	if song.Artists != nil {
+		sets = append(sets, "version = version")
+	}
+
+	return sets
+}
+
+// ChangeSong locks the song, works out a patch from its current state with
+// change and applies it, all in one transaction.
+func (r *SongRepository) ChangeSong(ctx context.Context, id string, ifMatch []int, change func(model.Song) (model.PatchSong, error)) (*model.SongResponse, error) {
+
+	var changedSong model.SongResponse
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var song model.Song
+		query := `SELECT song.id, song.title, song.disc_number, song.disc_title, song.track_number, song.duration_seconds, album.name as album, COALESCE(song.artist_id, 0), ` + artistName + ` as artist, song.version
+					FROM song
+					JOIN album ON song.album_id = album.id
+					LEFT JOIN artist ON artist.id = COALESCE(song.artist_id, album.artist_id)
+					WHERE song.id = $1 AND song.archived = FALSE
+					FOR UPDATE OF song`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&song.ID, &song.Title, &song.DiscNumber, &song.DiscTitle, &song.TrackNumber, &song.DurationSeconds, &song.AlbumName, &song.ArtistID, &song.ArtistName, &song.Version)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if !matchesVersion(ifMatch, song.Version) {
+			return ErrVersionMismatch
+		}
+
+		if err := loadSongCredits(ctx, tx, map[int]*model.Credits{song.ID: &song.Credits}); err != nil {
+			return err
+		}
+
+		patch, err := change(song)
+		if err != nil {
+			return err
+		}
+
+		if patch.ArtistID != nil {
+			if err := checkSongArtists(ctx, tx, false, *patch.ArtistID); err != nil {
+				return err
+			}
 		}
+
+		args := []any{song.ID}
+		query2 := patchQuery("song", songSets(patch, &args), "id = $1", "id, COALESCE(artist_id, 0), title, disc_number, disc_title, track_number, duration_seconds, version")
+
+		err = tx.QueryRow(ctx, query2, args...).Scan(&changedSong.ID, &changedSong.ArtistID, &changedSong.Title, &changedSong.DiscNumber, &changedSong.DiscTitle, &changedSong.TrackNumber, &changedSong.DurationSeconds, &changedSong.Version)
+		if err != nil {
+			return err
+		}
+
+		if patch.Artists != nil {
+			if err := replaceCredits(ctx, tx, songCredits, map[int][]model.CreditArtist{changedSong.ID: *patch.Artists}); err != nil {
+				return err
+			}
+		}
+
+		return loadSongCredits(ctx, tx, map[int]*model.Credits{changedSong.ID: &changedSong.Credits})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	query := `SELECT id, title, track_number, duration_seconds FROM song WHERE id = $1 AND archived = FALSE`
+	return &changedSong, nil
+}
+
+func (r *SongRepository) DeleteSong(ctx context.Context, id string, ifMatch []int) error {
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		if err := lockVersion(ctx, tx, "song", id, ifMatch); err != nil {
+			return err
+		}
 
-	err := r.dbPool.QueryRow(ctx, query, id).Scan(&patchedSong.ID, &patchedSong.Title, &patchedSong.TrackNumber, &patchedSong.DurationSeconds)
+		var albumID, disc, track int
+
+		// query := `DELETE FROM song where id = $1`
+		query := `UPDATE song SET archived = TRUE, archived_at = now() WHERE id = $1 RETURNING album_id, disc_number, track_number`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&albumID, &disc, &track)
+		if err != nil {
+			return err
+		}
+
+		return closeTrackGap(ctx, tx, albumID, disc, track)
+	})
+
+	return err
+}
+
+func (r *SongRepository) RestoreSong(ctx context.Context, id string) (*model.SongResponse, error) {
+
+	var restoredSong model.SongResponse
+
+	err := writeTx(ctx, r.dbPool, func(tx pgx.Tx) error {
+		var albumArchived bool
+
+		query := `SELECT album.archived
+				FROM song
+				JOIN album ON song.album_id = album.id
+				WHERE song.id = $1 AND song.archived = TRUE
+				FOR UPDATE OF song`
+
+		err := tx.QueryRow(ctx, query, id).Scan(&albumArchived)
+		if err != nil {
+			return err
+		}
+
+		if albumArchived {
+			return ErrParentArchived
+		}
+
+		if err := deferTrackNumbers(ctx, tx); err != nil {
+			return err
+		}
+
+		query2 := `UPDATE song SET archived = FALSE, archived_at = NULL WHERE id = $1 RETURNING id`
+
+		err = tx.QueryRow(ctx, query2, id).Scan(&restoredSong.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := placeRestoredSongs(ctx, tx, []int{restoredSong.ID}); err != nil {
+			return err
+		}
+
+		query3 := `SELECT id, COALESCE(artist_id, 0), title, disc_number, disc_title, track_number, duration_seconds FROM song WHERE id = $1`
+
+		err = tx.QueryRow(ctx, query3, id).Scan(&restoredSong.ID, &restoredSong.ArtistID, &restoredSong.Title, &restoredSong.DiscNumber, &restoredSong.DiscTitle, &restoredSong.TrackNumber, &restoredSong.DurationSeconds)
+		if err != nil {
+			return err
+		}
+
+		return loadSongCredits(ctx, tx, map[int]*model.Credits{restoredSong.ID: &restoredSong.Credits})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &patchedSong, nil
+	return &restoredSong, nil
+}
+
+var songBulk = bulkTable[model.CreateSong, model.UpdateSong]{
+	table:   "song",
+	columns: []string{"album_id", "artist_id", "title", "disc_number", "disc_title", "track_number", "duration_seconds"},
+	values: func(song model.CreateSong) []any {
+		return []any{song.AlbumID, nullID(song.ArtistID), song.Title, model.DiscOrFirst(song.DiscNumber), song.DiscTitle, song.TrackNumber, song.DurationSeconds}
+	},
+	parentTable: "album",
+	parent: func(song model.CreateSong) int {
+		return song.AlbumID
+	},
+	update: func(id int, song model.UpdateSong) (string, []any) {
+		query := `UPDATE song SET title = $2, disc_number = $3, disc_title = $4, track_number = $5, duration_seconds = $6, artist_id = $7 WHERE id = $1 AND archived = FALSE RETURNING id`
+		return query, []any{id, song.Title, model.DiscOrFirst(song.DiscNumber), song.DiscTitle, song.TrackNumber, song.DurationSeconds, nullID(song.ArtistID)}
+	},
+	plan: planSongs,
+	credits: func(item model.BulkItem[model.CreateSong, model.UpdateSong]) []model.CreditArtist {
+		if item.ID == 0 {
+			return item.Create.Artists
+		}
+		return item.Update.Artists
+	},
+	creditTable: songCredits,
+}
+
+// planSongs numbers the new songs that have no track number and fails the
+// items that would take a track number already in use on their disc, as the
+// writes are made in order, or that would leave a song on a compilation
+// without a live artist of its own.
+func planSongs(ctx context.Context, tx pgx.Tx, items []model.BulkItem[model.CreateSong, model.UpdateSong], results []model.BulkResult, fail func(int, error) error) error {
+	albumIDs := []int{}
+	songIDs := []int{}
+	artistIDs := []int{}
+	for i, item := range items {
+		switch {
+		case results[i].Err != nil:
+		case item.ID == 0:
+			albumIDs = append(albumIDs, item.Create.AlbumID)
+			artistIDs = append(artistIDs, item.Create.ArtistID)
+		default:
+			songIDs = append(songIDs, item.ID)
+			artistIDs = append(artistIDs, item.Update.ArtistID)
+		}
+	}
+
+	live, err := liveArtists(ctx, tx, artistIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT song.id, song.album_id, album.compilation, song.disc_number, song.track_number
+				FROM song
+				JOIN album ON album.id = song.album_id
+				WHERE song.archived = FALSE AND (song.album_id = ANY($1) OR song.album_id IN (SELECT album_id FROM song WHERE id = ANY($2)))`
+
+	rows, err := tx.Query(ctx, query, albumIDs, songIDs)
+	if err != nil {
+		return err
+	}
+
+	t := newTracklists()
+	compilations := map[int]bool{}
+	var songID, albumID, disc, track int
+	var compilation bool
+	_, err = pgx.ForEachRow(rows, []any{&songID, &albumID, &compilation, &disc, &track}, func() error {
+		t.put(songID, discID{albumID, disc}, track)
+		compilations[albumID] = compilation
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// An album new songs go on may have none yet.
+	query2 := `SELECT id FROM album WHERE id = ANY($1) AND compilation`
+
+	rows, err = tx.Query(ctx, query2, albumIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&albumID}, func() error {
+		compilations[albumID] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if results[i].Err != nil {
+			continue
+		}
+
+		if items[i].ID != 0 {
+			update := items[i].Update
+			err = songArtistError(compilations[t.songs[items[i].ID].disc.albumID], update.ArtistID, live)
+			if err == nil {
+				err = t.move(items[i].ID, model.DiscOrFirst(update.DiscNumber), update.TrackNumber)
+			}
+		} else {
+			create := items[i].Create
+			err = songArtistError(compilations[create.AlbumID], create.ArtistID, live)
+			if err == nil {
+				items[i].Create.TrackNumber, err = t.add(create.AlbumID, model.DiscOrFirst(create.DiscNumber), create.TrackNumber)
+			}
+		}
+
+		if err != nil {
+			if err := fail(i, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-func (r *SongRepository) DeleteSong(ctx context.Context, id string) error {
+// checkSongArtist checks the artist a song is to have of its own against the
+// compilation flag of its album, read with query from id.
+func checkSongArtist(ctx context.Context, tx pgx.Tx, query string, id any, artistID int) error {
+	var compilation bool
+	if err := tx.QueryRow(ctx, query, id).Scan(&compilation); err != nil {
+		return err
+	}
 
-	// query := `DELETE FROM song where id = $1`
-	query := `UPDATE song SET archived = TRUE WHERE id = $1`
+	return checkSongArtists(ctx, tx, compilation, artistID)
+}
 
-	_, err := r.dbPool.Exec(ctx, query, id)
+// checkSongArtists checks the artists songs are to have of their own, 0 for
+// none: a song on a compilation must have one, and each must be live.
+func checkSongArtists(ctx context.Context, tx pgx.Tx, compilation bool, artistIDs ...int) error {
+	live, err := liveArtists(ctx, tx, artistIDs)
 	if err != nil {
 		return err
 	}
 
+	for _, id := range artistIDs {
+		if err := songArtistError(compilation, id, live); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// songArtistError is why a song on an album, a compilation or not, can't
+// have artistID as its own artist, or nil if it can.
+func songArtistError(compilation bool, artistID int, live map[int]bool) error {
+	switch {
+	case artistID == 0 && compilation:
+		return ErrSongArtistRequired
+	case artistID != 0 && !live[artistID]:
+		return ErrSongArtistNotFound
+	}
+
 	return nil
 }
+
+// BulkSongs creates and replaces songs in one transaction.
+func (r *SongRepository) BulkSongs(ctx context.Context, items []model.BulkItem[model.CreateSong, model.UpdateSong], atomic bool) ([]model.BulkResult, error) {
+	return bulkWrite(ctx, r.dbPool, songBulk, items, atomic)
+}
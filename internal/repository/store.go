@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/liamcoleman/music-go/internal/model"
+)
+
+type ArtistStore interface {
+	GetArtists(ctx context.Context, params model.ListParams) (*model.Page[model.Artist], error)
+	GetArtist(ctx context.Context, id string) (*model.ArtistWithAlbums, error)
+	CreateArtist(ctx context.Context, artist model.CreateArtist) (*model.Artist, error)
+	CreateArtistTree(ctx context.Context, artist model.ArtistTree) (*model.ArtistWithAlbums, error)
+	UpdateArtist(ctx context.Context, artist model.UpdateArtist, id string, ifMatch []int) (*model.Artist, error)
+	PatchArtist(ctx context.Context, artist model.PatchArtist, id string, ifMatch []int) (*model.Artist, error)
+	ChangeArtist(ctx context.Context, id string, ifMatch []int, change func(model.Artist) (model.PatchArtist, error)) (*model.Artist, error)
+	DeleteArtist(ctx context.Context, id string, ifMatch []int) (*model.ArchivedArtist, error)
+	RestoreArtist(ctx context.Context, id string, cascade bool) (*model.RestoredArtist, error)
+	MatchArtists(ctx context.Context, params model.MatchParams) ([]model.ArtistMatch, error)
+	FindOrCreateArtist(ctx context.Context, artist model.CreateArtist, threshold float64) (*model.Artist, bool, error)
+	MergeArtist(ctx context.Context, id string, targetID int) (*model.MergedArtist, error)
+	BulkArtists(ctx context.Context, items []model.BulkItem[model.CreateArtist, model.UpdateArtist], atomic bool) ([]model.BulkResult, error)
+	ArtistRedirect(ctx context.Context, id string) (int, error)
+}
+
+type AlbumStore interface {
+	GetAlbums(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error)
+	GetArtistAlbums(ctx context.Context, artistID string, params model.ListParams) (*model.Page[model.Album], error)
+	GetCompilations(ctx context.Context, params model.ListParams) (*model.Page[model.Album], error)
+	GetAlbum(ctx context.Context, id string) (*model.AlbumWithSongs, error)
+	CreateAlbum(ctx context.Context, album model.CreateAlbum) (*model.AlbumResponse, error)
+	CreateAlbumTree(ctx context.Context, album model.AlbumTree) (*model.AlbumWithSongs, error)
+	UpdateAlbum(ctx context.Context, album model.UpdateAlbum, id string, ifMatch []int) (*model.AlbumResponse, error)
+	PatchAlbum(ctx context.Context, album model.PatchAlbum, id string, ifMatch []int) (*model.AlbumResponse, error)
+	ChangeAlbum(ctx context.Context, id string, ifMatch []int, change func(model.AlbumWithSongs) (model.AlbumChanges, error)) (*model.AlbumWithSongs, error)
+	DeleteAlbum(ctx context.Context, id string, ifMatch []int) (*model.ArchivedAlbum, error)
+	RestoreAlbum(ctx context.Context, id string, cascade bool) (*model.RestoredAlbum, error)
+	MatchAlbums(ctx context.Context, artistID int, params model.MatchParams) ([]model.AlbumMatch, error)
+	MergeAlbum(ctx context.Context, id string, targetID int) (*model.MergedAlbum, error)
+	BulkAlbums(ctx context.Context, items []model.BulkItem[model.CreateAlbum, model.UpdateAlbum], atomic bool) ([]model.BulkResult, error)
+	AlbumRedirect(ctx context.Context, id string) (int, error)
+}
+
+type SongStore interface {
+	GetSongs(ctx context.Context, params model.ListParams) (*model.Page[model.Song], error)
+	GetAlbumSongs(ctx context.Context, albumID string, params model.ListParams) (*model.Page[model.Song], error)
+	GetSong(ctx context.Context, id string) (*model.Song, error)
+	CreateSong(ctx context.Context, song model.CreateSong) (*model.SongResponse, error)
+	UpdateSong(ctx context.Context, song model.UpdateSong, id string, ifMatch []int) (*model.SongResponse, error)
+	PatchSong(ctx context.Context, song model.PatchSong, id string, ifMatch []int) (*model.SongResponse, error)
+	ChangeSong(ctx context.Context, id string, ifMatch []int, change func(model.Song) (model.PatchSong, error)) (*model.SongResponse, error)
+	DeleteSong(ctx context.Context, id string, ifMatch []int) error
+	RestoreSong(ctx context.Context, id string) (*model.SongResponse, error)
+	BulkSongs(ctx context.Context, items []model.BulkItem[model.CreateSong, model.UpdateSong], atomic bool) ([]model.BulkResult, error)
+}
+
+type PersonStore interface {
+	GetPeople(ctx context.Context, params model.ListParams) (*model.Page[model.Person], error)
+	GetPerson(ctx context.Context, id string) (*model.Person, error)
+	GetPersonCredits(ctx context.Context, id string) ([]model.PersonAlbum, error)
+	CreatePerson(ctx context.Context, person model.CreatePerson) (*model.Person, error)
+	UpdatePerson(ctx context.Context, person model.UpdatePerson, id string, ifMatch []int) (*model.Person, error)
+	PatchPerson(ctx context.Context, person model.PatchPerson, id string, ifMatch []int) (*model.Person, error)
+	ChangePerson(ctx context.Context, id string, ifMatch []int, change func(model.Person) (model.PatchPerson, error)) (*model.Person, error)
+	DeletePerson(ctx context.Context, id string, ifMatch []int) error
+	RestorePerson(ctx context.Context, id string) (*model.Person, error)
+	GetPersonCredit(ctx context.Context, id string) (*model.PersonCredit, error)
+	CreatePersonCredit(ctx context.Context, credit model.CreatePersonCredit) (*model.PersonCredit, error)
+	UpdatePersonCredit(ctx context.Context, credit model.UpdatePersonCredit, id string) (*model.PersonCredit, error)
+	DeletePersonCredit(ctx context.Context, id string) error
+}
+
+type PurgeStore interface {
+	Purge(ctx context.Context, archivedBefore time.Time, dryRun bool) (*model.PurgeResult, error)
+}
+
+type SearchStore interface {
+	Search(ctx context.Context, params model.SearchParams) ([]model.SearchResult, error)
+}
+
+type HistoryStore interface {
+	History(ctx context.Context, entity string, id string) ([]model.Revision, error)
+	Revert(ctx context.Context, entity string, id string, revisionID int) (*model.Revision, error)
+}
+
+var (
+	_ ArtistStore  = (*ArtistRepository)(nil)
+	_ AlbumStore   = (*AlbumRepository)(nil)
+	_ SongStore    = (*SongRepository)(nil)
+	_ PersonStore  = (*PersonRepository)(nil)
+	_ PurgeStore   = (*PurgeRepository)(nil)
+	_ SearchStore  = (*SearchRepository)(nil)
+	_ HistoryStore = (*HistoryRepository)(nil)
+
+	_ ArtistStore  = (*MemoryArtistRepository)(nil)
+	_ AlbumStore   = (*MemoryAlbumRepository)(nil)
+	_ SongStore    = (*MemorySongRepository)(nil)
+	_ PersonStore  = (*MemoryPersonRepository)(nil)
+	_ PurgeStore   = (*MemoryPurgeRepository)(nil)
+	_ SearchStore  = (*MemorySearchRepository)(nil)
+	_ HistoryStore = (*MemoryHistoryRepository)(nil)
+)
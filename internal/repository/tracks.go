@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// trackNumberConstraint keeps the track numbers of the live songs on each
+// disc of an album distinct.
+const trackNumberConstraint = "song_track_number_unique"
+
+// deferTrackNumbers puts off checking track numbers until the transaction
+// commits, for writes that renumber several songs one statement at a time.
+func deferTrackNumbers(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SET CONSTRAINTS `+trackNumberConstraint+` DEFERRED`)
+	return err
+}
+
+// closeTrackGap moves the live songs after track on a disc up by one, into
+// the place of a song that has left it.
+func closeTrackGap(ctx context.Context, tx pgx.Tx, albumID, disc, track int) error {
+	query := `UPDATE song SET track_number = track_number - 1 WHERE album_id = $1 AND disc_number = $2 AND archived = FALSE AND track_number > $3`
+
+	_, err := tx.Exec(ctx, query, albumID, disc, track)
+	return err
+}
+
+// placeRestoredSongs settles the track numbers of songs just restored, which
+// may have been taken while they were archived. A restored song keeps its
+// number when no other live song on its disc has it; otherwise it moves past
+// the last track of the disc, in the order the clashing songs had. Callers
+// defer the constraint, since the songs clash until they are placed.
+func placeRestoredSongs(ctx context.Context, tx pgx.Tx, songIDs []int) error {
+	if len(songIDs) == 0 {
+		return nil
+	}
+
+	query := `WITH restored AS (
+				SELECT id, album_id, disc_number, track_number,
+					row_number() OVER (PARTITION BY album_id, disc_number, track_number ORDER BY id) AS copy
+				FROM song
+				WHERE id = ANY($1)
+			), clashes AS (
+				SELECT id, album_id, disc_number,
+					row_number() OVER (PARTITION BY album_id, disc_number ORDER BY track_number, id) AS n
+				FROM restored
+				WHERE copy > 1 OR EXISTS (
+					SELECT 1 FROM song live
+					WHERE live.album_id = restored.album_id AND live.disc_number = restored.disc_number
+						AND live.track_number = restored.track_number AND live.archived = FALSE AND live.id <> ALL($1)
+				)
+			), last_track AS (
+				SELECT album_id, disc_number, MAX(track_number) AS track_number
+				FROM song
+				WHERE archived = FALSE AND album_id IN (SELECT album_id FROM clashes)
+				GROUP BY album_id, disc_number
+			)
+			UPDATE song SET track_number = last_track.track_number + clashes.n
+			FROM clashes
+			JOIN last_track ON last_track.album_id = clashes.album_id AND last_track.disc_number = clashes.disc_number
+			WHERE song.id = clashes.id`
+
+	_, err := tx.Exec(ctx, query, songIDs)
+	return err
+}
+
+// discID identifies one disc of an album.
+type discID struct {
+	albumID int
+	number  int
+}
+
+// tracklists holds the track numbers taken on some discs, so that writes
+// done one after another can be checked against the constraint, and new
+// songs numbered, before any of them is made.
+type tracklists struct {
+	// discs maps a disc to its taken track numbers and the songs that have
+	// them. Songs yet to be created have negative ids.
+	discs map[discID]map[int]int
+	// songs maps a song id to its disc and track number.
+	songs   map[int]songPlace
+	created int
+}
+
+type songPlace struct {
+	disc  discID
+	track int
+}
+
+func newTracklists() *tracklists {
+	return &tracklists{discs: map[discID]map[int]int{}, songs: map[int]songPlace{}}
+}
+
+// put records that a live song has track on disc.
+func (t *tracklists) put(songID int, disc discID, track int) {
+	if t.discs[disc] == nil {
+		t.discs[disc] = map[int]int{}
+	}
+
+	t.discs[disc][track] = songID
+	t.songs[songID] = songPlace{disc, track}
+}
+
+// add takes track on a disc of the album for a new song and returns it. A
+// track of 0 is the disc's next one, after its last track.
+func (t *tracklists) add(albumID, disc, track int) (int, error) {
+	id := discID{albumID, disc}
+
+	if track == 0 {
+		for taken := range t.discs[id] {
+			track = max(track, taken)
+		}
+		track++
+	}
+
+	if _, taken := t.discs[id][track]; taken {
+		return 0, ErrTrackNumberTaken
+	}
+
+	t.created--
+	t.put(t.created, id, track)
+	return track, nil
+}
+
+// move gives a song a new disc and track number on its album. Songs the
+// tracklists don't hold are left for the write itself to find missing.
+func (t *tracklists) move(songID, disc, track int) error {
+	place, ok := t.songs[songID]
+	to := songPlace{discID{place.disc.albumID, disc}, track}
+	if !ok || place == to {
+		return nil
+	}
+
+	if _, taken := t.discs[to.disc][track]; taken {
+		return ErrTrackNumberTaken
+	}
+
+	delete(t.discs[place.disc], place.track)
+	t.put(songID, to.disc, track)
+	return nil
+}
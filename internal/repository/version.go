@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// matchesVersion reports whether a record at version satisfies ifMatch, the
+// versions a write is conditional on. A nil ifMatch makes the write
+// unconditional.
+func matchesVersion(ifMatch []int, version int) bool {
+	return ifMatch == nil || slices.Contains(ifMatch, version)
+}
+
+// lockVersion locks the live row of table for the rest of the transaction
+// and checks it is still at one of the ifMatch versions.
+func lockVersion(ctx context.Context, tx pgx.Tx, table, id string, ifMatch []int) error {
+	var version int
+	query := `SELECT version FROM ` + table + ` WHERE id = $1 AND archived = FALSE FOR UPDATE`
+
+	err := tx.QueryRow(ctx, query, id).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	if !matchesVersion(ifMatch, version) {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// versionCondition restricts a write to the ifMatch versions, if there are
+// any.
+func versionCondition(args *[]any, ifMatch []int) string {
+	if ifMatch == nil {
+		return ""
+	}
+
+	return " AND version = ANY(" + addArg(args, ifMatch) + ")"
+}
+
+// missingOrModified tells why a write restricted by versionCondition matched
+// no row: either the live row is gone or it is at another version.
+func missingOrModified(ctx context.Context, tx pgx.Tx, table, id string) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $1 AND archived = FALSE)`
+
+	err := tx.QueryRow(ctx, query, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrVersionMismatch
+	}
+
+	return pgx.ErrNoRows
+}
+
+// patchQuery builds a single UPDATE of the supplied columns of the live row,
+// or a plain SELECT when there are none, returning the given columns.
+func patchQuery(table string, sets []string, where, returning string) string {
+	if len(sets) == 0 {
+		return `SELECT ` + returning + ` FROM ` + table + ` WHERE ` + where
+	}
+
+	return `UPDATE ` + table + ` SET ` + strings.Join(sets, ", ") + ` WHERE ` + where + ` RETURNING ` + returning
+}
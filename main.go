@@ -5,7 +5,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/liamcoleman/music-go/internal/purge"
 	"github.com/liamcoleman/music-go/internal/repository"
 
 	"github.com/liamcoleman/music-go/internal/handler"
@@ -16,22 +18,95 @@ import (
 
 func main() {
 
-	dbPool, err := pgxpool.New(context.Background(), os.Getenv("DATABASE_URL"))
-	if err != nil {
-		log.Fatal("Unable to create connection pool:", err)
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
 	}
-	defer dbPool.Close()
 
-	artistRepo := repository.NewArtistRepository(dbPool)
-	artistHandler := handler.NewArtistHandler(artistRepo)
+	var s stores
 
-	albumRepo := repository.NewAlbumRepository(dbPool)
-	albumHandler := handler.NewAlbumHandler(albumRepo)
+	if os.Getenv("STORAGE") == "memory" {
+		s = memoryStores()
+	} else {
+		dbPool := connect(context.Background())
+		defer dbPool.Close()
 
-	songRepo := repository.NewSongRepository(dbPool)
-	songHandler := handler.NewSongHandler(songRepo)
+		if os.Getenv("MIGRATE_ON_START") != "false" {
+			migrateUp(context.Background(), dbPool)
+		}
+
+		s = postgresStores(dbPool)
+	}
+
+	if value := os.Getenv("PURGE_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid PURGE_INTERVAL:", err)
+		}
+
+		retention, err := purge.RetentionFromEnv()
+		if err != nil {
+			log.Fatal("Invalid PURGE_RETENTION:", err)
+		}
+
+		go purge.Schedule(context.Background(), s.purge, interval, retention)
+	}
+
+	newRouter(s).Run()
+
+}
+
+// stores are the repositories the API is served from.
+type stores struct {
+	artists repository.ArtistStore
+	albums  repository.AlbumStore
+	songs   repository.SongStore
+	people  repository.PersonStore
+	purge   repository.PurgeStore
+	search  repository.SearchStore
+	history repository.HistoryStore
+}
+
+// memoryStores keeps everything in one repository.MemoryDB, for running the
+// API without Postgres.
+func memoryStores() stores {
+	memoryDB := repository.NewMemoryDB()
+
+	return stores{
+		artists: repository.NewMemoryArtistRepository(memoryDB),
+		albums:  repository.NewMemoryAlbumRepository(memoryDB),
+		songs:   repository.NewMemorySongRepository(memoryDB),
+		people:  repository.NewMemoryPersonRepository(memoryDB),
+		purge:   repository.NewMemoryPurgeRepository(memoryDB),
+		search:  repository.NewMemorySearchRepository(memoryDB),
+		history: repository.NewMemoryHistoryRepository(memoryDB),
+	}
+}
+
+func postgresStores(dbPool *pgxpool.Pool) stores {
+	return stores{
+		artists: repository.NewArtistRepository(dbPool),
+		albums:  repository.NewAlbumRepository(dbPool),
+		songs:   repository.NewSongRepository(dbPool),
+		people:  repository.NewPersonRepository(dbPool),
+		purge:   repository.NewPurgeRepository(dbPool),
+		search:  repository.NewSearchRepository(dbPool),
+		history: repository.NewHistoryRepository(dbPool),
+	}
+}
+
+// newRouter routes every endpoint of the API to handlers backed by s.
+func newRouter(s stores) *gin.Engine {
+	artistHandler := handler.NewArtistHandler(s.artists)
+	albumHandler := handler.NewAlbumHandler(s.albums)
+	songHandler := handler.NewSongHandler(s.songs)
+	personHandler := handler.NewPersonHandler(s.people)
+	searchHandler := handler.NewSearchHandler(s.search)
+	historyHandler := handler.NewHistoryHandler(s.history)
 
 	router := gin.Default()
+	router.Use(handler.Problems())
+	router.Use(handler.Actor())
 
 	router.GET("/ping", func(c *gin.Context) {
 
@@ -41,26 +116,68 @@ func main() {
 	})
 
 	router.GET("/artists", artistHandler.GetAll)
+	router.GET("/artists/match", artistHandler.MatchArtists)
 	router.GET("/artists/:id", artistHandler.GetArtist)
 	router.POST("/artists", artistHandler.CreateArtist)
+	router.POST("/artists/bulk", artistHandler.BulkArtists)
 	router.PUT("/artists/:id", artistHandler.UpdateArtist)
 	router.PATCH("/artists/:id", artistHandler.PatchArtist)
 	router.DELETE("/artists/:id", artistHandler.DeleteArtist)
+	router.POST("/artists/:id/restore", artistHandler.RestoreArtist)
+	router.GET("/artists/:id/history", historyHandler.History("artist"))
+	router.POST("/artists/:id/history/:revision/revert", historyHandler.Revert("artist"))
+	router.POST("/artists/:id/merge", artistHandler.MergeArtist)
+	router.GET("/artists/:id/albums", albumHandler.GetArtistAlbums)
+	router.POST("/artists/:id/albums", albumHandler.CreateArtistAlbum)
+
+	router.GET("/various-artists/albums", albumHandler.GetCompilations)
 
 	router.GET("/albums", albumHandler.GetAll)
+	router.GET("/albums/match", albumHandler.MatchAlbums)
 	router.GET("/albums/:id", albumHandler.GetAlbum)
 	router.POST("/albums", albumHandler.CreateAlbum)
+	router.POST("/albums/bulk", albumHandler.BulkAlbums)
 	router.PUT("/albums/:id", albumHandler.UpdateAlbum)
 	router.PATCH("/albums/:id", albumHandler.PatchAlbum)
 	router.DELETE("/albums/:id", albumHandler.DeleteAlbum)
+	router.POST("/albums/:id/restore", albumHandler.RestoreAlbum)
+	router.GET("/albums/:id/history", historyHandler.History("album"))
+	router.POST("/albums/:id/history/:revision/revert", historyHandler.Revert("album"))
+	router.POST("/albums/:id/merge", albumHandler.MergeAlbum)
+	router.GET("/albums/:id/songs", songHandler.GetAlbumSongs)
+	router.POST("/albums/:id/songs", songHandler.CreateAlbumSong)
+	router.PUT("/albums/:id/songs", albumHandler.ReplaceSongs)
+	router.POST("/albums/:id/songs/reorder", albumHandler.ReorderSongs)
 
 	router.GET("/songs", songHandler.GetAll)
 	router.GET("/songs/:id", songHandler.GetSong)
 	router.POST("/songs", songHandler.CreateSong)
+	router.POST("/songs/bulk", songHandler.BulkSongs)
 	router.PUT("/songs/:id", songHandler.UpdateSong)
 	router.PATCH("/songs/:id", songHandler.PatchSong)
 	router.DELETE("/songs/:id", songHandler.DeleteSong)
-
-	router.Run()
-
+	router.POST("/songs/:id/restore", songHandler.RestoreSong)
+	router.GET("/songs/:id/history", historyHandler.History("song"))
+	router.POST("/songs/:id/history/:revision/revert", historyHandler.Revert("song"))
+
+	router.GET("/people", personHandler.GetAll)
+	router.GET("/people/:id", personHandler.GetPerson)
+	router.POST("/people", personHandler.CreatePerson)
+	router.PUT("/people/:id", personHandler.UpdatePerson)
+	router.PATCH("/people/:id", personHandler.PatchPerson)
+	router.DELETE("/people/:id", personHandler.DeletePerson)
+	router.POST("/people/:id/restore", personHandler.RestorePerson)
+	router.GET("/people/:id/history", historyHandler.History("person"))
+	router.POST("/people/:id/history/:revision/revert", historyHandler.Revert("person"))
+	router.GET("/people/:id/credits", personHandler.GetPersonCredits)
+
+	router.GET("/credits/roles", personHandler.GetRoles)
+	router.GET("/credits/:id", personHandler.GetCredit)
+	router.POST("/credits", personHandler.CreateCredit)
+	router.PUT("/credits/:id", personHandler.UpdateCredit)
+	router.DELETE("/credits/:id", personHandler.DeleteCredit)
+
+	router.GET("/search", searchHandler.Search)
+
+	return router
 }
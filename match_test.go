@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+type match struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+func TestMatchArtists(t *testing.T) {
+	api := newTestAPI(t)
+
+	gizzard := api.createArtist("King Gizzard & the Lizard Wizard")
+	api.createArtist("Nine Inch Nails")
+
+	var matches []match
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/artists/match?name="+url.QueryEscape("King Gizard and the Lizzard Wizard"), nil), &matches)
+
+	if len(matches) != 1 || matches[0].ID != gizzard || matches[0].Score <= 0.3 || matches[0].Score > 1 {
+		t.Errorf("got %+v, want artist %d with a score above 0.3", matches, gizzard)
+	}
+
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/artists/match?name=Gizzard&threshold=0.99", nil), &matches)
+	if len(matches) != 0 {
+		t.Errorf("got %+v, want nothing above 0.99", matches)
+	}
+
+	for _, query := range []string{"", "name=Gizzard&threshold=2", "name=Gizzard&limit=51"} {
+		api.expect(http.StatusBadRequest, http.MethodGet, "/artists/match?"+query, nil)
+	}
+}
+
+func TestMatchAlbums(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	other := api.createArtist("Tame Impala")
+	fragile := api.createAlbum(nin, "The Fragile")
+	api.createAlbum(other, "The Fragile")
+
+	var matches []match
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/albums/match?artist_id="+strconv.Itoa(nin)+"&name=Fragil", nil), &matches)
+
+	if len(matches) != 1 || matches[0].ID != fragile {
+		t.Errorf("got %+v, want only album %d of the artist", matches, fragile)
+	}
+}
+
+func TestCreateArtistWithMatch(t *testing.T) {
+	api := newTestAPI(t)
+
+	gizzard := api.createArtist("King Gizzard & the Lizard Wizard")
+
+	var found match
+	decode(t, api.expect(http.StatusOK, http.MethodPost, "/artists?match=true", map[string]any{"name": "King Gizzard and the Lizard Wizard"}), &found)
+	if found.ID != gizzard {
+		t.Errorf("got artist %d, want the existing artist %d", found.ID, gizzard)
+	}
+
+	if id := api.create("/artists?match=true", map[string]any{"name": "Nine Inch Nails"}); id == gizzard {
+		t.Errorf("got the existing artist for a new name")
+	}
+}
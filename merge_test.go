@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestMergeArtist(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		targetID := api.createArtist("King Gizzard & the Lizard Wizard")
+		sourceID := api.createArtist("King Gizzard and the Lizard Wizard")
+		albumID := api.createAlbum(sourceID, "Nonagon Infinity")
+
+		var merged struct {
+			ID          int `json:"id"`
+			TargetID    int `json:"target_id"`
+			AlbumsMoved int `json:"albums_moved"`
+		}
+		recorder := api.expect(http.StatusOK, http.MethodPost, path("artists", sourceID, "merge"), map[string]any{"target_id": targetID})
+		expectLocation(t, recorder, path("artists", targetID))
+		decode(t, recorder, &merged)
+
+		if merged.ID != sourceID || merged.TargetID != targetID || merged.AlbumsMoved != 1 {
+			t.Errorf("got %+v, want 1 album moved from %d to %d", merged, sourceID, targetID)
+		}
+
+		expectLocation(t, api.expect(http.StatusMovedPermanently, http.MethodGet, path("artists", sourceID), nil), path("artists", targetID))
+
+		if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/albums?artist_id="+strconv.Itoa(targetID), nil)); !slices.Equal(got, []int{albumID}) {
+			t.Errorf("got albums %v on the target, want %v", got, []int{albumID})
+		}
+
+		api.expect(http.StatusBadRequest, http.MethodPost, path("artists", targetID, "merge"), map[string]any{"target_id": targetID})
+		api.expect(http.StatusUnprocessableEntity, http.MethodPost, path("artists", targetID, "merge"), map[string]any{"target_id": sourceID})
+		api.expect(http.StatusNotFound, http.MethodPost, path("artists", sourceID, "merge"), map[string]any{"target_id": targetID})
+	})
+}
+
+func TestMergeAlbumRenumbersClashingTracks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		artistID := api.createArtist("Nine Inch Nails")
+		targetID := api.createAlbum(artistID, "The Fragile")
+		sourceID := api.createAlbum(artistID, "Fragile")
+
+		song := func(albumID int, title string, track int) int {
+			return api.create("/songs", map[string]any{"album_id": albumID, "title": title, "track_number": track})
+		}
+
+		kept := []int{song(targetID, "Somewhat Damaged", 1), song(targetID, "The Day the World Went Away", 2)}
+		moved := []int{song(sourceID, "The Frail", 2), song(sourceID, "The Wretched", 3)}
+
+		var merged struct {
+			SongsMoved       int `json:"songs_moved"`
+			TracksRenumbered int `json:"tracks_renumbered"`
+		}
+		recorder := api.expect(http.StatusOK, http.MethodPost, path("albums", sourceID, "merge"), map[string]any{"target_id": targetID})
+		expectLocation(t, recorder, path("albums", targetID))
+		decode(t, recorder, &merged)
+
+		if merged.SongsMoved != 2 || merged.TracksRenumbered != 1 {
+			t.Errorf("got %+v, want 2 songs moved and 1 renumbered", merged)
+		}
+
+		expectTracks(t, api, map[int]int{kept[0]: 1, kept[1]: 2, moved[0]: 4, moved[1]: 3})
+
+		expectLocation(t, api.expect(http.StatusMovedPermanently, http.MethodGet, path("albums", sourceID), nil), path("albums", targetID))
+	})
+}
+
+func (api *testAPI) credits(url string) creditedRecord {
+	api.t.Helper()
+
+	var record creditedRecord
+	decode(api.t, api.expect(http.StatusOK, http.MethodGet, url, nil), &record)
+
+	return record
+}
+
+func TestMergeAlbumMovesCredits(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		nin := api.createArtist("Nine Inch Nails")
+		bowie := api.createArtist("David Bowie")
+		eno := api.createArtist("Brian Eno")
+
+		credit := func(artistIDs ...int) []map[string]any {
+			credits := []map[string]any{}
+			for _, id := range artistIDs {
+				credits = append(credits, map[string]any{"artist_id": id, "role": "featured"})
+			}
+			return credits
+		}
+
+		targetID := api.create("/albums", map[string]any{"artist_id": nin, "name": "The Fragile", "release_year": 1999, "artists": credit(bowie)})
+		sourceID := api.create("/albums", map[string]any{"artist_id": nin, "name": "Fragile", "release_year": 1999, "artists": credit(bowie, eno)})
+		songID := api.create("/songs", map[string]any{"album_id": sourceID, "title": "The Frail", "artists": credit(eno)})
+		plainSongID := api.createSong(sourceID, "The Wretched")
+
+		api.expect(http.StatusOK, http.MethodPost, path("albums", sourceID, "merge"), map[string]any{"target_id": targetID})
+
+		if got, want := api.credits(path("albums", targetID)).artistIDs(), []int{nin, bowie, eno}; !slices.Equal(got, want) {
+			t.Errorf("got album credits %v, want %v", got, want)
+		}
+		if got, want := api.credits(path("songs", plainSongID)).artistIDs(), []int{nin, bowie, eno}; !slices.Equal(got, want) {
+			t.Errorf("got song credits %v, want %v", got, want)
+		}
+		if got, want := api.credits(path("songs", songID)).artistIDs(), []int{nin, bowie, eno}; !slices.Equal(got, want) {
+			t.Errorf("got credits %v for a song crediting an artist the album now does, want %v", got, want)
+		}
+	})
+}
+
+func TestMergeAlbumOfAnotherArtistKeepsSongCredits(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		nin := api.createArtist("Nine Inch Nails")
+		duplicate := api.createArtist("NIN")
+		bowie := api.createArtist("David Bowie")
+		eno := api.createArtist("Brian Eno")
+
+		targetID := api.createAlbum(nin, "The Fragile")
+		sourceID := api.create("/albums", map[string]any{
+			"artist_id":    duplicate,
+			"name":         "The Fragile",
+			"release_year": 1999,
+			"artists":      []map[string]any{{"artist_id": bowie, "role": "featured"}},
+		})
+		songID := api.create("/songs", map[string]any{
+			"album_id": sourceID,
+			"title":    "The Frail",
+			"artists":  []map[string]any{{"artist_id": eno, "role": "remixer"}},
+		})
+
+		before := api.credits(path("songs", songID))
+
+		api.expect(http.StatusOK, http.MethodPost, path("albums", sourceID, "merge"), map[string]any{"target_id": targetID})
+
+		after := api.credits(path("songs", songID))
+		if after.ArtistCredit != before.ArtistCredit || !slices.Equal(after.artistIDs(), before.artistIDs()) {
+			t.Errorf("got credits %q %v after the merge, want %q %v", after.ArtistCredit, after.artistIDs(), before.ArtistCredit, before.artistIDs())
+		}
+
+		if got, want := api.credits(path("albums", targetID)).artistIDs(), []int{nin}; !slices.Equal(got, want) {
+			t.Errorf("got album credits %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestNestedCollections(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.create(path("artists", artistID, "albums"), map[string]any{"name": "The Fragile", "release_year": 1999})
+	otherAlbumID := api.createAlbum(api.createArtist("Tame Impala"), "Currents")
+
+	first := api.create(path("albums", albumID, "songs"), map[string]any{"title": "Somewhat Damaged"})
+	second := api.create(path("albums", albumID, "songs"), map[string]any{"title": "The Day the World Went Away"})
+	api.createSong(otherAlbumID, "Let It Happen")
+
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("artists", artistID, "albums"), nil)); !slices.Equal(got, []int{albumID}) {
+		t.Errorf("got albums %v, want %v", got, []int{albumID})
+	}
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("albums", albumID, "songs"), nil)); !slices.Equal(got, []int{first, second}) {
+		t.Errorf("got songs %v, want %v in track order", got, []int{first, second})
+	}
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("albums", albumID, "songs")+"?sort=-track_number&limit=1", nil)); !slices.Equal(got, []int{second}) {
+		t.Errorf("got songs %v, want %v", got, []int{second})
+	}
+}
+
+func TestNestedRoutesNeedALiveParent(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+
+	api.expect(http.StatusBadRequest, http.MethodGet, "/artists/nin/albums", nil)
+	api.expect(http.StatusNotFound, http.MethodGet, "/albums/999/songs", nil)
+	api.expect(http.StatusNotFound, http.MethodPost, "/albums/999/songs", map[string]any{"title": "The Frail"})
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+
+	api.expect(http.StatusNotFound, http.MethodGet, path("artists", artistID, "albums"), nil)
+	api.expect(http.StatusNotFound, http.MethodPost, path("artists", artistID, "albums"), map[string]any{"name": "Broken", "release_year": 1992})
+	api.expect(http.StatusNotFound, http.MethodGet, path("albums", albumID, "songs"), nil)
+	api.expect(http.StatusNotFound, http.MethodPut, path("albums", albumID, "songs"), []map[string]any{{"title": "The Frail"}})
+}
+
+func TestReplaceTracklist(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+	kept := api.createSong(albumID, "Somewhat Damaged")
+	dropped := api.createSong(albumID, "The Day the World Went Away")
+
+	var tracklist []struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		TrackNumber int    `json:"track_number"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPut, path("albums", albumID, "songs"), []map[string]any{
+		{"title": "The Frail"},
+		{"id": kept, "title": "Somewhat Damaged", "duration_seconds": 271},
+	}), &tracklist)
+
+	if len(tracklist) != 2 || tracklist[0].Title != "The Frail" || tracklist[1].ID != kept {
+		t.Fatalf("got %+v, want The Frail added ahead of song %d", tracklist, kept)
+	}
+	for i, song := range tracklist {
+		if song.TrackNumber != i+1 {
+			t.Errorf("got %q at track %d, want track %d", song.Title, song.TrackNumber, i+1)
+		}
+	}
+
+	expectTracks(t, api, map[int]int{tracklist[0].ID: 1, kept: 2})
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", dropped), nil)
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPut, path("albums", albumID, "songs"), []map[string]any{{"title": ""}})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPut, path("albums", albumID, "songs"), []map[string]any{{"id": dropped, "title": "The Day the World Went Away"}})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type page struct {
+	Data []struct {
+		ID int `json:"id"`
+	} `json:"data"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+func (p page) ids() []int {
+	ids := []int{}
+	for _, record := range p.Data {
+		ids = append(ids, record.ID)
+	}
+
+	return ids
+}
+
+func TestPagination(t *testing.T) {
+	api := newTestAPI(t)
+
+	want := []int{}
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		want = append(want, api.createArtist(name))
+	}
+
+	got := []int{}
+	cursors := []string{}
+	next := "/artists?limit=2"
+
+	for next != "" {
+		recorder := api.expect(http.StatusOK, http.MethodGet, next, nil)
+
+		var p page
+		decode(t, recorder, &p)
+		got = append(got, p.ids()...)
+
+		if len(p.Data) > 2 {
+			t.Fatalf("GET %s: got %d records, want at most 2", next, len(p.Data))
+		}
+
+		next = ""
+		if p.NextCursor != "" {
+			cursors = append(cursors, p.NextCursor)
+			next = "/artists?limit=2&cursor=" + url.QueryEscape(p.NextCursor)
+
+			if link := recorder.Header().Get("Link"); !strings.Contains(link, `rel="next"`) {
+				t.Errorf("got Link %q, want a next link", link)
+			}
+		}
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got ids %v across the pages, want %v", got, want)
+	}
+
+	var p page
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/artists?limit=2&cursor="+url.QueryEscape(cursors[1]), nil), &p)
+	if p.PrevCursor == "" {
+		t.Fatal("got no prev_cursor on the last page")
+	}
+
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/artists?limit=2&cursor="+url.QueryEscape(p.PrevCursor), nil), &p)
+	if !slices.Equal(p.ids(), want[2:4]) {
+		t.Errorf("got ids %v going back a page, want %v", p.ids(), want[2:4])
+	}
+}
+
+func TestPaginationRejectsBadParams(t *testing.T) {
+	api := newTestAPI(t)
+
+	for _, target := range []string{"/artists?limit=0", "/artists?limit=501", "/albums?limit=many", "/songs?cursor=nonsense"} {
+		api.expect(http.StatusBadRequest, http.MethodGet, target, nil)
+	}
+}
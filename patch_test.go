@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// problem is an application/problem+json response body.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	Fields   []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields"`
+}
+
+// fieldNames returns the names of the invalid fields, in order.
+func (p problem) fieldNames() []string {
+	names := []string{}
+	for _, field := range p.Fields {
+		names = append(names, field.Field)
+	}
+
+	return names
+}
+
+func TestPatchSongChangesOnlySuppliedFields(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+	songID := api.create("/songs", map[string]any{"album_id": albumID, "title": "The Frail", "duration_seconds": 114})
+
+	var song struct {
+		Title           string `json:"title"`
+		TrackNumber     int    `json:"track_number"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+	decode(t, api.expect(http.StatusCreated, http.MethodPatch, path("songs", songID), map[string]any{"title": "The Wretched"}), &song)
+
+	if song.Title != "The Wretched" || song.TrackNumber != 1 || song.DurationSeconds != 114 {
+		t.Errorf("got %+v, want only the title changed", song)
+	}
+}
+
+func TestPatchSongListsEveryInvalidField(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+	songID := api.createSong(albumID, "The Frail")
+
+	var invalid problem
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPatch, path("songs", songID), map[string]any{
+		"title":            " ",
+		"track_number":     0,
+		"duration_seconds": -1,
+	}), &invalid)
+
+	if got, want := invalid.fieldNames(), []string{"title", "track_number", "duration_seconds"}; !slices.Equal(got, want) {
+		t.Errorf("got invalid fields %v, want %v", got, want)
+	}
+
+	var song struct {
+		Title string `json:"title"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil), &song)
+	if song.Title != "The Frail" {
+		t.Errorf("got title %q, want the song left alone", song.Title)
+	}
+
+	api.expect(http.StatusNotFound, http.MethodPatch, "/songs/999", map[string]any{"title": "The Wretched"})
+	api.expect(http.StatusNotFound, http.MethodPatch, "/artists/999", map[string]any{"name": "NIN"})
+}
+
+func TestMergePatchClearsDescription(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.create("/artists", map[string]any{"name": "Nine Inch Nails", "description": "Industrial rock"})
+	url := path("artists", artistID)
+
+	var artist struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPatch, url, `{"description": null}`, "Content-Type", "application/merge-patch+json"), &artist)
+
+	if artist.Name != "Nine Inch Nails" || artist.Description != "" {
+		t.Errorf("got %+v, want the description cleared", artist)
+	}
+
+	var invalid problem
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPatch, url, `{"name": null, "id": 7}`, "Content-Type", "application/merge-patch+json"), &invalid)
+
+	if got, want := invalid.fieldNames(), []string{"id", "name"}; !slices.Equal(got, want) {
+		t.Errorf("got invalid fields %v, want %v", got, want)
+	}
+}
+
+func TestJSONPatchRearrangesAlbumSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+	songs := []int{api.createSong(albumID, "Somewhat Damaged"), api.createSong(albumID, "The Day the World Went Away"), api.createSong(albumID, "The Frail")}
+	url := path("albums", albumID)
+
+	api.expect(http.StatusOK, http.MethodPatch, url, []map[string]any{
+		{"op": "move", "from": "/discs/0/songs/2", "path": "/discs/0/songs/0"},
+		{"op": "remove", "path": "/discs/0/songs/2"},
+		{"op": "add", "path": "/discs/0/songs/-", "value": map[string]any{"title": "The Wretched"}},
+		{"op": "replace", "path": "/name", "value": "The Fragile (Deviations 1)"},
+	}, "Content-Type", "application/json-patch+json")
+
+	expectTracks(t, api, map[int]int{songs[2]: 1, songs[0]: 2})
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", songs[1]), nil)
+
+	var album struct {
+		Name  string `json:"name"`
+		Discs []struct {
+			Songs []struct {
+				Title       string `json:"title"`
+				TrackNumber int    `json:"track_number"`
+			} `json:"songs"`
+		} `json:"discs"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, url, nil), &album)
+
+	if album.Name != "The Fragile (Deviations 1)" || len(album.Discs) != 1 || len(album.Discs[0].Songs) != 3 {
+		t.Fatalf("got %+v, want the album renamed with three songs", album)
+	}
+	if added := album.Discs[0].Songs[2]; added.Title != "The Wretched" || added.TrackNumber != 3 {
+		t.Errorf("got %+v, want The Wretched added as track 3", added)
+	}
+
+	api.expect(http.StatusConflict, http.MethodPatch, url, []map[string]any{
+		{"op": "replace", "path": "/name", "value": "The Fragile"},
+		{"op": "test", "path": "/release_year", "value": 1999},
+	}, "Content-Type", "application/json-patch+json")
+
+	decode(t, api.expect(http.StatusOK, http.MethodGet, url, nil), &album)
+	if album.Name != "The Fragile (Deviations 1)" {
+		t.Errorf("got name %q, want a failed patch to change nothing", album.Name)
+	}
+
+	api.expect(http.StatusOK, http.MethodPatch, url, `[
+		{"op": "test", "path": "/release_year", "value": 2000.0},
+		{"op": "replace", "path": "/name", "value": "The Fragile"}
+	]`, "Content-Type", "application/json-patch+json")
+}
+
+func TestPatchDocumentLocation(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "The Frail")
+
+	for url, body := range map[string]string{
+		path("artists", artistID): `{"name": "NIN"}`,
+		path("albums", albumID):   `{"name": "Fragile"}`,
+		path("songs", songID):     `{"title": "Frail"}`,
+	} {
+		expectLocation(t, api.expect(http.StatusOK, http.MethodPatch, url, body, "Content-Type", "application/merge-patch+json"), url)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+type personnelRecord struct {
+	Personnel []struct {
+		CreditID int    `json:"credit_id"`
+		PersonID int    `json:"person_id"`
+		Role     string `json:"role"`
+	} `json:"personnel"`
+}
+
+func (api *testAPI) personnel(url string) []string {
+	api.t.Helper()
+
+	var record personnelRecord
+	decode(api.t, api.expect(http.StatusOK, http.MethodGet, url, nil), &record)
+
+	credits := []string{}
+	for _, person := range record.Personnel {
+		credits = append(credits, credit(person.PersonID, person.Role))
+	}
+
+	return credits
+}
+
+// credit names a person's role, as in "1 producer".
+func credit(personID int, role string) string {
+	return strconv.Itoa(personID) + " " + role
+}
+
+func (api *testAPI) createPerson(name string) int {
+	api.t.Helper()
+
+	return api.create("/people", map[string]any{"name": name})
+}
+
+func TestPersonCredits(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(nin, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	reznor := api.createPerson("Trent Reznor")
+	flood := api.createPerson("Alan Moulder")
+
+	api.create("/credits", map[string]any{"person_id": flood, "album_id": albumID, "role": "mixer"})
+	api.create("/credits", map[string]any{"person_id": reznor, "album_id": albumID, "role": "producer"})
+	creditID := api.create("/credits", map[string]any{"person_id": reznor, "song_id": songID, "role": "writer"})
+
+	if got, want := api.personnel(path("albums", albumID)), []string{credit(reznor, "producer"), credit(flood, "mixer")}; !slices.Equal(got, want) {
+		t.Errorf("got album personnel %v, want %v", got, want)
+	}
+	if got, want := api.personnel(path("songs", songID)), []string{credit(reznor, "writer")}; !slices.Equal(got, want) {
+		t.Errorf("got song personnel %v, want %v", got, want)
+	}
+
+	api.expect(http.StatusConflict, http.MethodPost, "/credits", map[string]any{"person_id": reznor, "album_id": albumID, "role": "producer"})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/credits", map[string]any{"person_id": reznor, "album_id": albumID, "song_id": songID, "role": "writer"})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/credits", map[string]any{"person_id": reznor, "album_id": albumID, "role": "drummer"})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/credits", map[string]any{"person_id": 999, "album_id": albumID, "role": "writer"})
+
+	var credits []struct {
+		ID    int      `json:"id"`
+		Roles []string `json:"roles"`
+		Songs []struct {
+			ID int `json:"id"`
+		} `json:"songs"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("people", reznor, "credits"), nil), &credits)
+
+	if len(credits) != 1 || credits[0].ID != albumID || !slices.Equal(credits[0].Roles, []string{"producer"}) || len(credits[0].Songs) != 1 {
+		t.Errorf("got credits %+v, want album %d as producer with one song", credits, albumID)
+	}
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("credits", creditID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("credits", creditID), nil)
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("people", flood), nil)
+	if got, want := api.personnel(path("albums", albumID)), []string{credit(reznor, "producer")}; !slices.Equal(got, want) {
+		t.Errorf("got album personnel %v after archiving a person, want %v", got, want)
+	}
+}
+
+func TestMergeAlbumMovesPersonCredits(t *testing.T) {
+	api := newTestAPI(t)
+
+	nin := api.createArtist("Nine Inch Nails")
+	targetID := api.createAlbum(nin, "The Fragile")
+	sourceID := api.createAlbum(nin, "Fragile")
+
+	reznor := api.createPerson("Trent Reznor")
+	flood := api.createPerson("Alan Moulder")
+
+	api.create("/credits", map[string]any{"person_id": reznor, "album_id": targetID, "role": "producer"})
+	api.create("/credits", map[string]any{"person_id": reznor, "album_id": sourceID, "role": "producer"})
+	api.create("/credits", map[string]any{"person_id": flood, "album_id": sourceID, "role": "mixer"})
+
+	etag := api.expect(http.StatusOK, http.MethodGet, path("albums", targetID), nil).Header().Get("ETag")
+
+	api.expect(http.StatusOK, http.MethodPost, path("albums", sourceID, "merge"), map[string]any{"target_id": targetID})
+
+	if got, want := api.personnel(path("albums", targetID)), []string{credit(reznor, "producer"), credit(flood, "mixer")}; !slices.Equal(got, want) {
+		t.Errorf("got album personnel %v, want %v", got, want)
+	}
+
+	api.expect(http.StatusOK, http.MethodGet, path("albums", targetID), nil, "If-None-Match", etag)
+
+	var credits []struct {
+		ID int `json:"id"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("people", flood, "credits"), nil), &credits)
+
+	if len(credits) != 1 || credits[0].ID != targetID {
+		t.Errorf("got credits %+v, want only album %d", credits, targetID)
+	}
+}
+
+func TestPersonLocation(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+
+	recorder := api.expect(http.StatusCreated, http.MethodPost, "/people", map[string]any{"name": "Trent Reznor"})
+	var person struct {
+		ID int `json:"id"`
+	}
+	decode(t, recorder, &person)
+	url := path("people", person.ID)
+	expectLocation(t, recorder, url)
+
+	expectLocation(t, api.expect(http.StatusOK, http.MethodPut, url, map[string]any{"name": "Trent Reznor"}), url)
+	expectLocation(t, api.expect(http.StatusOK, http.MethodPatch, url, map[string]any{"name": "Reznor"}), url)
+	expectLocation(t, api.expect(http.StatusOK, http.MethodPatch, url, `{"name": "Trent Reznor"}`, "Content-Type", "application/merge-patch+json"), url)
+
+	body := map[string]any{"person_id": person.ID, "album_id": albumID, "role": "producer"}
+	recorder = api.expect(http.StatusCreated, http.MethodPost, "/credits", body)
+	var credit struct {
+		ID int `json:"id"`
+	}
+	decode(t, recorder, &credit)
+	url = path("credits", credit.ID)
+	expectLocation(t, recorder, url)
+
+	body["role"] = "mixer"
+	expectLocation(t, api.expect(http.StatusOK, http.MethodPut, url, body), url)
+}
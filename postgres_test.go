@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/liamcoleman/music-go/internal/migrate"
+	"github.com/liamcoleman/music-go/internal/pgtest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newPostgresTestAPI serves the API from a freshly migrated schema of the
+// database in TEST_DATABASE_URL, and skips the test when it isn't set.
+func newPostgresTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	s := postgresStores(migratedPool(t))
+
+	return &testAPI{t: t, stores: s, router: newRouter(s)}
+}
+
+// migratedPool returns a pool on a freshly migrated schema of the database
+// in TEST_DATABASE_URL. The sample catalogue the migrations load is cleared
+// first, so the test starts from empty tables as it does against the
+// in-memory stores.
+func migratedPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	ctx := context.Background()
+	dbPool := pgtest.Pool(t)
+
+	migrator, err := migrate.NewMigrator(dbPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrating the test schema: %v", err)
+	}
+
+	rows, err := dbPool.Query(ctx, `SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
+		var table string
+		err := row.Scan(&table)
+		return pgx.Identifier{table}.Sanitize(), err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbPool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatalf("clearing the sample catalogue: %v", err)
+	}
+
+	return dbPool
+}
+
+// forEachStore runs test against the in-memory stores and against Postgres.
+func forEachStore(t *testing.T, test func(t *testing.T, api *testAPI)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newTestAPI(t))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		test(t, newPostgresTestAPI(t))
+	})
+}
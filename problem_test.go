@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProblemResponses(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "The Frail")
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("songs", songID), nil)
+	api.expect(http.StatusOK, http.MethodDelete, path("albums", albumID), nil)
+
+	tests := []struct {
+		method string
+		url    string
+		body   any
+		want   problem
+	}{
+		{
+			http.MethodGet, "/artists/999?include=albums", nil,
+			problem{Type: "/problems/not-found", Title: "Not Found", Status: http.StatusNotFound, Detail: "Artist not found", Instance: "/artists/999?include=albums"},
+		},
+		{
+			http.MethodPost, path("songs", songID, "restore"), nil,
+			problem{Type: "/problems/conflict", Title: "Conflict", Status: http.StatusConflict, Detail: "Parent is archived, restore it first", Instance: path("songs", songID, "restore")},
+		},
+		{
+			http.MethodPost, path("artists", artistID, "merge"), map[string]any{"target_id": artistID},
+			problem{Type: "/problems/validation", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "Cannot merge into itself", Instance: path("artists", artistID, "merge")},
+		},
+		{
+			http.MethodPost, path("artists", artistID, "merge"), map[string]any{"target_id": 999},
+			problem{Type: "/problems/foreign-key-violation", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity, Detail: "Merge target not found", Instance: path("artists", artistID, "merge")},
+		},
+	}
+
+	for _, test := range tests {
+		recorder := api.expect(test.want.Status, test.method, test.url, test.body)
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("%s %s: got Content-Type %q, want application/problem+json", test.method, test.url, contentType)
+		}
+
+		var got problem
+		decode(t, recorder, &got)
+		if got.Type != test.want.Type || got.Title != test.want.Title || got.Status != test.want.Status || got.Detail != test.want.Detail || got.Instance != test.want.Instance {
+			t.Errorf("%s %s: got %+v, want %+v", test.method, test.url, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurge(t *testing.T) {
+	forEachStore(t, func(t *testing.T, api *testAPI) {
+		ctx := context.Background()
+
+		artistID := api.createArtist("Nine Inch Nails")
+		albumID := api.createAlbum(artistID, "The Fragile")
+		songID := api.createSong(albumID, "The Frail")
+		keptID := api.createAlbum(api.createArtist("Tame Impala"), "Currents")
+
+		api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+
+		dryRun, err := api.stores.purge.Purge(ctx, time.Now().Add(time.Minute), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(dryRun.ArtistIDs, []int{artistID}) || !slices.Equal(dryRun.AlbumIDs, []int{albumID}) || !slices.Equal(dryRun.SongIDs, []int{songID}) {
+			t.Errorf("got dry run %+v, want the archived artist, album and song", dryRun)
+		}
+		if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/artists?archived=true", nil)); !slices.Equal(got, []int{artistID}) {
+			t.Errorf("got archived artists %v after a dry run, want %v", got, []int{artistID})
+		}
+
+		if early, err := api.stores.purge.Purge(ctx, time.Now().Add(-time.Hour), false); err != nil || len(early.ArtistIDs)+len(early.AlbumIDs)+len(early.SongIDs) != 0 {
+			t.Errorf("got %+v, %v purging records archived an hour ago, want nothing purged", early, err)
+		}
+
+		result, err := api.stores.purge.Purge(ctx, time.Now().Add(time.Minute), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(result.ArtistIDs, []int{artistID}) || !slices.Equal(result.AlbumIDs, []int{albumID}) || !slices.Equal(result.SongIDs, []int{songID}) {
+			t.Errorf("got %+v, want the archived artist, album and song purged", result)
+		}
+
+		api.expect(http.StatusNotFound, http.MethodPost, path("artists", artistID, "restore"), nil)
+		api.expect(http.StatusNotFound, http.MethodPost, path("songs", songID, "restore"), nil)
+		api.expect(http.StatusOK, http.MethodGet, path("albums", keptID), nil)
+	})
+}
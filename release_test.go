@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type release struct {
+	ReleaseYear int    `json:"release_year"`
+	ReleaseDate string `json:"release_date"`
+	ReleaseType string `json:"release_type"`
+}
+
+func TestReleases(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+
+	album := func(name string, fields map[string]any) int {
+		fields["artist_id"] = artistID
+		fields["name"] = name
+		return api.create("/albums", fields)
+	}
+
+	fragile := album("The Fragile", map[string]any{"release_date": "1999-09-21"})
+	broken := album("Broken", map[string]any{"release_date": "1992-09", "release_type": "ep"})
+	spiral := album("The Downward Spiral", map[string]any{"release_year": 1994})
+	live := album("And All That Could Have Been", map[string]any{"release_year": 2002, "release_date": "2002-01-22", "release_type": "live"})
+	remix := album("Things Falling Apart", map[string]any{"release_year": 2000})
+	closer := album("Closer to God", map[string]any{"release_date": "1994-05", "release_type": "single"})
+
+	var got release
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("albums", fragile), nil), &got)
+	if want := (release{1999, "1999-09-21", "lp"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	var artist struct {
+		Albums []struct {
+			ID int `json:"id"`
+			release
+		} `json:"Albums"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path("artists", artistID), nil), &artist)
+
+	discography := []int{}
+	for _, album := range artist.Albums {
+		discography = append(discography, album.ID)
+	}
+	if want := []int{live, remix, fragile, closer, spiral, broken}; !slices.Equal(discography, want) {
+		t.Fatalf("got discography %v, want %v", discography, want)
+	}
+
+	for i, want := range []release{
+		{2002, "2002-01-22", "live"},
+		{2000, "2000", "lp"},
+		{1999, "1999-09-21", "lp"},
+		{1994, "1994-05", "single"},
+		{1994, "1994", "lp"},
+		{1992, "1992-09", "ep"},
+	} {
+		if got := artist.Albums[i].release; got != want {
+			t.Errorf("album %d: got %+v, want %+v", artist.Albums[i].ID, got, want)
+		}
+	}
+
+	if got, want := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/albums?release_type[in]=ep,single&sort=release_date", nil)), []int{broken, closer}; !slices.Equal(got, want) {
+		t.Errorf("got albums %v, want %v", got, want)
+	}
+	if got, want := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/albums?release_date[gte]=2000", nil)), []int{live, remix}; !slices.Equal(got, want) {
+		t.Errorf("got albums %v, want %v", got, want)
+	}
+}
+
+func TestPatchReleaseYear(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.create("/albums", map[string]any{"artist_id": api.createArtist("Nine Inch Nails"), "name": "The Fragile", "release_date": "1999-09-21"})
+	url := path("albums", albumID)
+
+	var got release
+	decode(t, api.expect(http.StatusOK, http.MethodPatch, url, map[string]any{"release_year": 1999}), &got)
+	if got.ReleaseDate != "1999-09-21" {
+		t.Errorf("got date %q after patching the same year, want it kept", got.ReleaseDate)
+	}
+
+	decode(t, api.expect(http.StatusOK, http.MethodPatch, url, map[string]any{"release_year": 2000}), &got)
+	if got.ReleaseYear != 2000 || got.ReleaseDate != "2000" {
+		t.Errorf("got %+v after patching another year, want the date dropped to 2000", got)
+	}
+
+	for _, body := range []map[string]any{
+		{"release_type": "mixtape"},
+		{"release_date": "1999-13"},
+		{"release_year": 1998, "release_date": "1999-09-21"},
+	} {
+		api.expect(http.StatusUnprocessableEntity, http.MethodPatch, url, body)
+	}
+}
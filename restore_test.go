@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRestoreArtist(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+
+	var restored struct {
+		ID             int `json:"id"`
+		AlbumsRestored int `json:"albums_restored"`
+		SongsRestored  int `json:"songs_restored"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPost, path("artists", artistID, "restore"), nil), &restored)
+
+	if restored.ID != artistID || restored.AlbumsRestored != 0 || restored.SongsRestored != 0 {
+		t.Errorf("got %+v, want only the artist restored", restored)
+	}
+	api.expect(http.StatusOK, http.MethodGet, path("artists", artistID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("albums", albumID), nil)
+	api.expect(http.StatusNotFound, http.MethodGet, path("songs", songID), nil)
+
+	api.expect(http.StatusNotFound, http.MethodPost, path("artists", artistID, "restore"), nil)
+}
+
+func TestRestoreArtistCascade(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+
+	var restored struct {
+		AlbumsRestored int `json:"albums_restored"`
+		SongsRestored  int `json:"songs_restored"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPost, path("artists", artistID, "restore")+"?cascade=true", nil), &restored)
+
+	if restored.AlbumsRestored != 1 || restored.SongsRestored != 1 {
+		t.Errorf("got %+v, want 1 album and 1 song restored", restored)
+	}
+
+	api.expect(http.StatusOK, http.MethodGet, path("albums", albumID), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil)
+
+	api.expect(http.StatusBadRequest, http.MethodPost, path("artists", artistID, "restore")+"?cascade=maybe", nil)
+}
+
+func TestRestoreUnderArchivedParentConflicts(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+
+	api.expect(http.StatusConflict, http.MethodPost, path("albums", albumID, "restore"), nil)
+	api.expect(http.StatusConflict, http.MethodPost, path("songs", songID, "restore"), nil)
+
+	api.expect(http.StatusOK, http.MethodPost, path("artists", artistID, "restore"), nil)
+	api.expect(http.StatusOK, http.MethodPost, path("albums", albumID, "restore"), nil)
+	api.expect(http.StatusOK, http.MethodPost, path("songs", songID, "restore"), nil)
+	api.expect(http.StatusOK, http.MethodGet, path("songs", songID), nil)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type searchResult struct {
+	Type    string `json:"type"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Snippet string `json:"snippet"`
+}
+
+func (api *testAPI) search(query string) []searchResult {
+	api.t.Helper()
+
+	var results []searchResult
+	decode(api.t, api.expect(http.StatusOK, http.MethodGet, "/search?"+query, nil), &results)
+
+	return results
+}
+
+func TestSearch(t *testing.T) {
+	api := newTestAPI(t)
+
+	radiohead := api.create("/artists", map[string]any{"name": "Radiohead", "description": "English rock band"})
+	albumID := api.createAlbum(radiohead, "OK Computer")
+	songID := api.createSong(albumID, "Paranoid Android")
+	api.createArtist("Nine Inch Nails")
+
+	results := api.search("q=radio")
+	if len(results) != 1 || results[0].Type != "artist" || results[0].ID != radiohead {
+		t.Fatalf("got %+v, want artist %d", results, radiohead)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>") {
+		t.Errorf("got snippet %q, want the match marked", results[0].Snippet)
+	}
+
+	results = api.search("q=andr")
+	if len(results) != 1 || results[0].Type != "song" || results[0].ID != songID {
+		t.Errorf("got %+v, want song %d", results, songID)
+	}
+
+	results = api.search("q=computer+ok&type=artist,song")
+	if len(results) != 0 {
+		t.Errorf("got %+v, want albums left out", results)
+	}
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("songs", songID), nil)
+	if results := api.search("q=paranoid"); len(results) != 0 {
+		t.Errorf("got %+v, want archived songs left out", results)
+	}
+	if results := api.search("q=paranoid&archived=true"); len(results) != 1 {
+		t.Errorf("got %+v, want the archived song", results)
+	}
+
+	for _, query := range []string{"", "q=", "q=radio&type=band", "q=radio&limit=101"} {
+		api.expect(http.StatusBadRequest, http.MethodGet, "/search?"+query, nil)
+	}
+}
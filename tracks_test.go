@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTrackNumbers(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	first := api.createSong(albumID, "Somewhat Damaged")
+	second := api.createSong(albumID, "The Day the World Went Away")
+	third := api.createSong(albumID, "The Frail")
+
+	expectTracks(t, api, map[int]int{first: 1, second: 2, third: 3})
+
+	api.expect(http.StatusConflict, http.MethodPost, "/songs", map[string]any{"album_id": albumID, "title": "The Wretched", "track_number": 2})
+	api.expect(http.StatusConflict, http.MethodPatch, path("songs", third), map[string]any{"track_number": 1})
+
+	api.expect(http.StatusNoContent, http.MethodDelete, path("songs", second), nil)
+	expectTracks(t, api, map[int]int{first: 1, third: 2})
+
+	api.expect(http.StatusOK, http.MethodPost, path("songs", second, "restore"), nil)
+	expectTracks(t, api, map[int]int{first: 1, third: 2, second: 3})
+}
+
+func TestReorderSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	first := api.createSong(albumID, "Somewhat Damaged")
+	second := api.createSong(albumID, "The Day the World Went Away")
+	third := api.createSong(albumID, "The Frail")
+
+	url := path("albums", albumID, "songs", "reorder")
+
+	api.expect(http.StatusOK, http.MethodPost, url, map[string]any{"song_ids": []int{third, first, second}})
+	expectTracks(t, api, map[int]int{third: 1, first: 2, second: 3})
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, url, map[string]any{"song_ids": []int{third, first}})
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, url, map[string]any{"song_ids": []int{third, first, first}})
+	expectTracks(t, api, map[int]int{third: 1, first: 2, second: 3})
+
+	api.expect(http.StatusPreconditionFailed, http.MethodPost, url, map[string]any{"song_ids": []int{first, second, third}}, "If-Match", `"99"`)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreateAlbumWithSongs(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+
+	var album struct {
+		ID    int `json:"id"`
+		Discs []struct {
+			DiscNumber int `json:"disc_number"`
+			Songs      []struct {
+				ID              int    `json:"id"`
+				Title           string `json:"title"`
+				DiscNumber      int    `json:"disc_number"`
+				TrackNumber     int    `json:"track_number"`
+				DurationSeconds int    `json:"duration_seconds"`
+			} `json:"songs"`
+		} `json:"discs"`
+	}
+	recorder := api.expect(http.StatusCreated, http.MethodPost, "/albums", map[string]any{
+		"artist_id":    artistID,
+		"name":         "The Downward Spiral",
+		"release_year": 1994,
+		"songs": []map[string]any{
+			{"title": "Mr. Self Destruct", "duration_seconds": 270},
+			{"title": "Piggy", "duration_seconds": 264},
+		},
+	})
+	decode(t, recorder, &album)
+	expectLocation(t, recorder, path("albums", album.ID))
+
+	if len(album.Discs) != 1 || album.Discs[0].DiscNumber != 1 || len(album.Discs[0].Songs) != 2 {
+		t.Fatalf("got %+v, want one disc of two songs", album)
+	}
+	for i, want := range []string{"Mr. Self Destruct", "Piggy"} {
+		if song := album.Discs[0].Songs[i]; song.ID == 0 || song.Title != want || song.DiscNumber != 1 || song.TrackNumber != i+1 {
+			t.Errorf("got song %+v, want %q created as track %d of disc 1", song, want, i+1)
+		}
+	}
+	if duration := album.Discs[0].Songs[1].DurationSeconds; duration != 264 {
+		t.Errorf("got Piggy at %d seconds, want 264", duration)
+	}
+
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("albums", album.ID, "songs"), nil)); len(got) != 2 {
+		t.Errorf("got songs %v on the album, want 2", got)
+	}
+}
+
+func TestCreateAlbumWithSongsIsAllOrNothing(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	album := func(songs ...map[string]any) map[string]any {
+		return map[string]any{"artist_id": artistID, "name": "The Downward Spiral", "release_year": 1994, "songs": songs}
+	}
+
+	var invalid problem
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/albums", album(map[string]any{"title": "Piggy"}, map[string]any{"title": ""})), &invalid)
+	if len(invalid.Fields) != 1 || invalid.Fields[0].Field != "songs/1/title" {
+		t.Errorf("got fields %+v, want songs/1/title", invalid.Fields)
+	}
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/albums", album(map[string]any{"title": "Piggy", "track_number": 0}))
+	api.expect(http.StatusConflict, http.MethodPost, "/albums", album(map[string]any{"title": "Piggy", "track_number": 2}, map[string]any{"title": "Heresy", "track_number": 2}))
+
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/albums", nil)); len(got) != 0 {
+		t.Errorf("got albums %v, want none left behind by failed creates", got)
+	}
+}
+
+func TestCreateArtistWithAlbums(t *testing.T) {
+	api := newTestAPI(t)
+
+	var artist struct {
+		ID     int `json:"id"`
+		Albums []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"Albums"`
+	}
+	decode(t, api.expect(http.StatusCreated, http.MethodPost, "/artists", map[string]any{
+		"name": "Nine Inch Nails",
+		"albums": []map[string]any{
+			{"name": "Pretty Hate Machine", "release_year": 1989, "songs": []map[string]any{{"title": "Head Like a Hole"}}},
+			{"name": "The Downward Spiral", "release_year": 1994},
+		},
+	}), &artist)
+
+	if len(artist.Albums) != 2 || artist.Albums[1].Name != "Pretty Hate Machine" {
+		t.Fatalf("got %+v, want the artist with both albums, newest first", artist)
+	}
+
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, path("albums", artist.Albums[1].ID, "songs"), nil)); len(got) != 1 {
+		t.Errorf("got songs %v, want the song created with the album", got)
+	}
+
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/artists", map[string]any{
+		"name":   "Tame Impala",
+		"albums": []map[string]any{{"name": "Currents", "release_year": 2015, "songs": []map[string]any{{"title": "Let It Happen", "duration_seconds": -1}}}},
+	})
+
+	if got := pageIDs(t, api.expect(http.StatusOK, http.MethodGet, "/artists", nil)); len(got) != 1 {
+		t.Errorf("got artists %v, want only the one created", got)
+	}
+}
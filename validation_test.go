@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestCreateSongValidation(t *testing.T) {
+	api := newTestAPI(t)
+
+	albumID := api.createAlbum(api.createArtist("Nine Inch Nails"), "The Fragile")
+
+	var invalid problem
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{
+		"album_id":         albumID,
+		"title":            "",
+		"track_number":     -1,
+		"duration_seconds": -114,
+	}), &invalid)
+
+	if got, want := invalid.fieldNames(), []string{"title", "track_number", "duration_seconds"}; !slices.Equal(got, want) {
+		t.Errorf("got invalid fields %v, want %v", got, want)
+	}
+
+	decode(t, api.expect(http.StatusBadRequest, http.MethodPost, "/songs", map[string]any{"album_id": albumID, "title": "The Frail", "duration_seconds": "1:54"}), &invalid)
+	if got, want := invalid.fieldNames(), []string{"duration_seconds"}; !slices.Equal(got, want) {
+		t.Errorf("got fields %v for a mistyped field, want %v", got, want)
+	}
+
+	api.expect(http.StatusBadRequest, http.MethodPost, "/songs", `{"album_id": `)
+}
+
+func TestCreateRejectsMissingOrArchivedParents(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+
+	var invalid problem
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{"album_id": 999, "title": "The Frail"}), &invalid)
+	if got, want := invalid.fieldNames(), []string{"album_id"}; !slices.Equal(got, want) {
+		t.Errorf("got invalid fields %v, want %v", got, want)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, path("albums", albumID), nil)
+	api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/songs", map[string]any{"album_id": albumID, "title": "The Frail"})
+
+	api.expect(http.StatusOK, http.MethodDelete, path("artists", artistID), nil)
+	decode(t, api.expect(http.StatusUnprocessableEntity, http.MethodPost, "/albums", map[string]any{"artist_id": artistID, "name": "The Fragile", "release_year": 1999}), &invalid)
+	if got, want := invalid.fieldNames(), []string{"artist_id"}; !slices.Equal(got, want) {
+		t.Errorf("got invalid fields %v, want %v", got, want)
+	}
+}
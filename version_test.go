@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	url := path("artists", artistID)
+
+	etag := api.expect(http.StatusOK, http.MethodGet, url, nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+
+	api.expect(http.StatusNotModified, http.MethodGet, url, nil, "If-None-Match", etag)
+
+	updated := api.expect(http.StatusOK, http.MethodPut, url, map[string]any{"name": "NIN"}, "If-Match", etag)
+	if updated.Header().Get("ETag") == etag {
+		t.Errorf("PUT kept ETag %s", etag)
+	}
+
+	api.expect(http.StatusPreconditionFailed, http.MethodPut, url, map[string]any{"name": "Nine Inch Nails"}, "If-Match", etag)
+	api.expect(http.StatusPreconditionFailed, http.MethodPatch, url, map[string]any{"name": "Nine Inch Nails"}, "If-Match", etag)
+	api.expect(http.StatusPreconditionFailed, http.MethodDelete, url, nil, "If-Match", etag)
+	api.expect(http.StatusOK, http.MethodGet, url, nil, "If-None-Match", etag)
+
+	api.expect(http.StatusOK, http.MethodDelete, url, nil, "If-Match", updated.Header().Get("ETag"))
+}
+
+func TestDeleteMissingRecordIsNotFound(t *testing.T) {
+	api := newTestAPI(t)
+
+	artistID := api.createArtist("Nine Inch Nails")
+	albumID := api.createAlbum(artistID, "The Fragile")
+	songID := api.createSong(albumID, "Somewhat Damaged")
+
+	for _, url := range []string{path("songs", songID), path("albums", albumID), path("artists", artistID)} {
+		api.do(http.MethodDelete, url, nil)
+
+		api.expect(http.StatusNotFound, http.MethodDelete, url, nil)
+		api.expect(http.StatusNotFound, http.MethodDelete, url, nil, "If-Match", `"1"`)
+	}
+
+	for _, url := range []string{"/songs/999", "/albums/999", "/artists/999", "/people/999"} {
+		api.expect(http.StatusNotFound, http.MethodDelete, url, nil)
+	}
+}